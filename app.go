@@ -2,21 +2,42 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"syncforge/database"
 	"syncforge/updater"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // App struct
 type App struct {
 	ctx             context.Context
 	connectionStore *database.ConnectionStore
+	syncPlanStore   *database.SyncPlanStore
+	healthCache     *database.HealthCache
+
+	opMu      sync.Mutex
+	opCtx     context.Context // parent of in-flight requests, replaced by CancelOperations
+	opCancel  context.CancelFunc
+	opTimeout time.Duration
+
+	updateMu     sync.Mutex
+	updateCancel context.CancelFunc // cancels the update download in progress
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
-	return &App{}
+	return &App{
+		healthCache: database.NewHealthCache(30 * time.Second),
+	}
 }
 
 // startup is called when the app starts
@@ -26,43 +47,284 @@ func (a *App) startup(ctx context.Context) {
 	if err == nil {
 		a.connectionStore = store
 	}
+	if plans, err := database.NewSyncPlanStore(); err == nil {
+		a.syncPlanStore = plans
+	}
+	// Let a pending update script know this build started
+	updater.SignalReady()
+}
+
+// operationContext returns a cancelable context for one request, bounded by the
+// operation timeout if set. The caller must call the cancel function.
+func (a *App) operationContext() (context.Context, context.CancelFunc) {
+	a.opMu.Lock()
+	defer a.opMu.Unlock()
+
+	if a.opCtx == nil {
+		parent := a.ctx
+		if parent == nil {
+			parent = context.Background()
+		}
+		a.opCtx, a.opCancel = context.WithCancel(parent)
+	}
+	if a.opTimeout > 0 {
+		return context.WithTimeout(a.opCtx, a.opTimeout)
+	}
+	return context.WithCancel(a.opCtx)
+}
+
+// CancelOperations aborts all in-flight schema and data requests
+func (a *App) CancelOperations() {
+	a.opMu.Lock()
+	defer a.opMu.Unlock()
+
+	if a.opCancel != nil {
+		a.opCancel()
+		a.opCtx, a.opCancel = nil, nil
+	}
+}
+
+// SetOperationTimeout sets a deadline in seconds for each schema and data request, 0 disables it
+func (a *App) SetOperationTimeout(seconds int) {
+	a.opMu.Lock()
+	defer a.opMu.Unlock()
+	a.opTimeout = time.Duration(seconds) * time.Second
 }
 
 // TestConnection tests database connection
 func (a *App) TestConnection(config database.ConnectionConfig) error {
-	return database.TestConnection(config)
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	if err := database.TestConnectionContext(ctx, config); err != nil {
+		return err
+	}
+	a.touchConnection(config)
+	return nil
+}
+
+// touchConnection records the use of the saved connections matching configs, if any
+func (a *App) touchConnection(configs ...database.ConnectionConfig) {
+	if a.connectionStore == nil {
+		return
+	}
+	for _, config := range configs {
+		if conn := a.connectionStore.FindByServer(config); conn != nil {
+			// Recency is a convenience; failing to record it doesn't fail the operation
+			a.connectionStore.Touch(conn.Name)
+		}
+	}
+}
+
+// GetConnectionStatus returns the cached health of a connection, probing when stale or forced
+func (a *App) GetConnectionStatus(config database.ConnectionConfig, forceRefresh bool) database.ConnectionHealth {
+	return a.healthCache.Check(config, forceRefresh)
+}
+
+// GetAllConnectionStatus returns the health of all saved connections keyed by name
+func (a *App) GetAllConnectionStatus(forceRefresh bool) map[string]database.ConnectionHealth {
+	if a.connectionStore == nil {
+		return map[string]database.ConnectionHealth{}
+	}
+	return a.healthCache.CheckAll(a.connectionStore.GetAll(), forceRefresh)
+}
+
+// ValidateConnection checks a connection config, splitting a pasted connection string into its fields
+func (a *App) ValidateConnection(config database.ConnectionConfig) (*database.ConnectionValidation, error) {
+	warnings, err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &database.ConnectionValidation{Config: config, Warnings: warnings}, nil
 }
 
 // GetDatabases returns list of databases
 func (a *App) GetDatabases(config database.ConnectionConfig) ([]string, error) {
-	return database.GetDatabases(config)
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.GetDatabasesContext(ctx, config)
 }
 
 // GetSchema retrieves database schema
 func (a *App) GetSchema(config database.ConnectionConfig) (*database.SchemaInfo, error) {
-	return database.GetSchema(config)
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	schema, err := database.GetSchemaContext(ctx, config)
+	if err == nil {
+		a.touchConnection(config)
+	}
+	return schema, err
 }
 
 // CompareSchemas compares two database schemas
 func (a *App) CompareSchemas(source, target database.ConnectionConfig) ([]database.DiffResult, error) {
-	sourceSchema, err := database.GetSchema(source)
+	ctx, cancel := a.operationContext()
+	defer cancel()
+
+	sourceSchema, err := database.GetSchemaContext(ctx, source)
 	if err != nil {
 		return nil, err
 	}
 
-	targetSchema, err := database.GetSchema(target)
+	targetSchema, err := database.GetSchemaContext(ctx, target)
 	if err != nil {
 		return nil, err
 	}
 
+	a.touchConnection(source, target)
 	return database.CompareSchemas(sourceSchema, targetSchema), nil
 }
 
-// ExecuteSQL executes SQL on target database
+// DiffComparisons reports which schema differences are new, resolved or persisting between two runs
+func (a *App) DiffComparisons(previous, current []database.DiffResult) database.ComparisonDelta {
+	return database.DiffComparisons(previous, current)
+}
+
+// CompareSchemaChanges compares two database schemas as structured changes instead of SQL
+func (a *App) CompareSchemaChanges(source, target database.ConnectionConfig) ([]database.SchemaChange, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+
+	sourceSchema, err := database.GetSchemaContext(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	targetSchema, err := database.GetSchemaContext(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	return database.CompareSchemaChanges(sourceSchema, targetSchema), nil
+}
+
+// RenderSchemaChanges renders structured schema changes as SQL for the given database type
+func (a *App) RenderSchemaChanges(changes []database.SchemaChange, dialect database.DBType) ([]string, error) {
+	return database.RenderSchemaChanges(changes, dialect)
+}
+
+// CompareSchemasWithOptions compares two database schemas using the given comparison options
+func (a *App) CompareSchemasWithOptions(source, target database.ConnectionConfig, opts database.CompareOptions) ([]database.DiffResult, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+
+	// Only read the tables the comparison looks at
+	if opts.TableFilter != nil {
+		source.TableFilter, target.TableFilter = opts.TableFilter, opts.TableFilter
+	}
+
+	sourceSchema, err := database.GetSchemaContext(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	targetSchema, err := database.GetSchemaContext(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := database.CompareSchemasWithOptions(sourceSchema, targetSchema, opts)
+	if opts.SampleSize > 0 {
+		if err := database.SampleTypeChangesContext(ctx, target, diffs, opts.SampleSize); err != nil {
+			return nil, err
+		}
+	}
+	a.touchConnection(source, target)
+	return diffs, nil
+}
+
+// CompareSchemasCrossDialect compares schemas of two different database types, translating column types to the target's dialect
+func (a *App) CompareSchemasCrossDialect(source, target database.ConnectionConfig, opts database.CompareOptions) ([]database.DiffResult, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+
+	// Only read the tables the comparison looks at
+	if opts.TableFilter != nil {
+		source.TableFilter, target.TableFilter = opts.TableFilter, opts.TableFilter
+	}
+
+	sourceSchema, err := database.GetSchemaContext(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	targetSchema, err := database.GetSchemaContext(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	a.touchConnection(source, target)
+	return database.CompareSchemasCrossDialect(sourceSchema, targetSchema, source.Type, target.Type, opts), nil
+}
+
+// CompareSchemaDump compares the schema in a SQL dump file, as the source, with a live target database
+func (a *App) CompareSchemaDump(filePath string, dumpType database.DBType, target database.ConnectionConfig, opts database.CompareOptions) ([]database.DiffResult, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sourceSchema, err := database.ParseSchemaFromSQL(f, dumpType)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.TableFilter != nil {
+		target.TableFilter = opts.TableFilter
+	}
+	targetSchema, err := database.GetSchemaContext(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	a.touchConnection(target)
+	return database.CompareSchemasWithOptions(sourceSchema, targetSchema, opts), nil
+}
+
+// CompareGrants compares user/role privileges between two databases
+func (a *App) CompareGrants(source, target database.ConnectionConfig) ([]database.DiffResult, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.CompareGrantsContext(ctx, source, target)
+}
+
+// ExecuteSQL executes SQL on target database without confirmation, so destructive
+// statements against a prod-tagged connection fail with ErrConfirmationRequired;
+// the caller then asks for the connection's name and uses ExecuteSQLConfirmed
 func (a *App) ExecuteSQL(config database.ConnectionConfig, sql string) error {
+	return a.ExecuteSQLConfirmed(config, sql, "")
+}
+
+// ExecuteSQLConfirmed executes SQL on target database; destructive statements against a
+// prod-tagged connection need the connection's name as confirmation
+func (a *App) ExecuteSQLConfirmed(config database.ConnectionConfig, sql, confirmation string) error {
+	_, err := a.ExecuteSQLWithOptions(config, sql, confirmation, false)
+	return err
+}
+
+// ExecuteSQLWithOptions executes SQL on target database like ExecuteSQLConfirmed; when
+// transactional, the statements are applied all-or-nothing and the returned warnings
+// note where the database can't guarantee that
+func (a *App) ExecuteSQLWithOptions(config database.ConnectionConfig, sql, confirmation string, transactional bool) ([]string, error) {
+	if a.connectionStore != nil {
+		conn := a.connectionStore.FindByServer(config)
+		if err := database.RequireConfirmation(conn, splitSQLStatements(sql), confirmation); err != nil {
+			return nil, err
+		}
+	}
+
+	if transactional {
+		ctx, cancel := a.operationContext()
+		defer cancel()
+		return database.ExecuteInTransactionContext(ctx, config, splitSQLStatements(sql))
+	}
+
 	db, err := database.Connect(config)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer db.Close()
 
@@ -71,7 +333,7 @@ func (a *App) ExecuteSQL(config database.ConnectionConfig, sql string) error {
 	dbType := config.Type
 	if dbType == "" || dbType == database.MySQL {
 		_, err = db.Exec(sql)
-		return err
+		return nil, err
 	}
 
 	// Split and execute statements one by one for non-MySQL databases
@@ -82,10 +344,36 @@ func (a *App) ExecuteSQL(config database.ConnectionConfig, sql string) error {
 			continue
 		}
 		if _, err := db.Exec(stmt); err != nil {
-			return err
+			return nil, err
 		}
 	}
-	return nil
+	return nil, nil
+}
+
+// ExecuteQuery runs an ad-hoc script on a database and returns the rows of its final
+// statement along with the affected-row counts of the earlier ones
+func (a *App) ExecuteQuery(config database.ConnectionConfig, sql string) (*database.QueryResult, error) {
+	statements := splitSQLStatements(sql)
+	if a.connectionStore != nil {
+		conn := a.connectionStore.FindByServer(config)
+		if err := database.RequireConfirmation(conn, statements, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.ExecuteQueryContext(ctx, config, statements)
+}
+
+// dollarQuoteTag matches the opening tag of a PostgreSQL dollar-quoted string, e.g. $$ or $body$
+var dollarQuoteTag = regexp.MustCompile(`^\$[A-Za-z_][A-Za-z0-9_]*\$|^\$\$`)
+
+// ValidateSQL checks each statement of a script against the target without applying it
+func (a *App) ValidateSQL(config database.ConnectionConfig, sql string) ([]database.StatementCheck, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.ValidateStatements(ctx, config, splitSQLStatements(sql))
 }
 
 // splitSQLStatements splits SQL string into individual statements
@@ -94,20 +382,39 @@ func splitSQLStatements(sql string) []string {
 	var current strings.Builder
 	inString := false
 	stringChar := rune(0)
+	skipUntil := 0
 
 	for i, c := range sql {
+		if i < skipUntil {
+			continue
+		}
 		if inString {
 			current.WriteRune(c)
 			// Check for end of string (handle escaped quotes)
 			if c == stringChar {
 				// Check if it's an escaped quote (two consecutive quotes)
 				if i+1 < len(sql) && rune(sql[i+1]) == stringChar {
+					current.WriteRune(c)
+					skipUntil = i + 2
 					continue
 				}
 				inString = false
 			}
 		} else {
-			if c == '\'' || c == '"' {
+			tag := ""
+			if c == '$' {
+				tag = dollarQuoteTag.FindString(sql[i:])
+			}
+			if tag != "" {
+				// Function bodies are dollar-quoted and contain semicolons; copy them whole
+				end := strings.Index(sql[i+len(tag):], tag)
+				if end < 0 {
+					skipUntil = len(sql)
+				} else {
+					skipUntil = i + len(tag) + end + len(tag)
+				}
+				current.WriteString(sql[i:skipUntil])
+			} else if c == '\'' || c == '"' {
 				inString = true
 				stringChar = c
 				current.WriteRune(c)
@@ -134,17 +441,120 @@ func splitSQLStatements(sql string) []string {
 
 // GetTablesForSync returns tables available for data sync
 func (a *App) GetTablesForSync(config database.ConnectionConfig) ([]database.TableDataInfo, error) {
-	return database.GetTablesForSync(config)
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	tables, err := database.GetTablesForSyncContext(ctx, config)
+	if err == nil {
+		a.touchConnection(config)
+	}
+	return tables, err
+}
+
+// CheckCharsetConversion warns about text columns whose values the target's character set can't store
+func (a *App) CheckCharsetConversion(source, target database.ConnectionConfig, tableName string, sampleRows int) ([]database.CharsetWarning, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.CheckCharsetConversionContext(ctx, source, target, tableName, sampleRows)
 }
 
 // CompareTableData compares data between source and target tables
 func (a *App) CompareTableData(source, target database.ConnectionConfig, tableName string) ([]database.DataDiffResult, error) {
-	return database.CompareTableData(source, target, tableName)
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	diffs, err := database.CompareTableDataContext(ctx, source, target, tableName, database.DataCompareOptions{})
+	if err == nil {
+		a.touchConnection(source, target)
+	}
+	return diffs, err
+}
+
+// CompareTableDataWithOptions compares data of a specific table using the given options
+func (a *App) CompareTableDataWithOptions(source, target database.ConnectionConfig, tableName string, opts database.DataCompareOptions) ([]database.DataDiffResult, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.CompareTableDataContext(ctx, source, target, tableName, opts)
+}
+
+// ApplyDataSync executes data diffs on the target in one transaction; deletes against a
+// prod-tagged connection need the connection's name as confirmation
+func (a *App) ApplyDataSync(target database.ConnectionConfig, diffs []database.DataDiffResult, opts database.SyncOptions, confirmation string) (database.SyncReport, error) {
+	if a.connectionStore != nil {
+		var statements []string
+		for _, d := range diffs {
+			statements = append(statements, d.SQL)
+		}
+		if err := database.RequireConfirmation(a.connectionStore.FindByServer(target), statements, confirmation); err != nil {
+			return database.SyncReport{}, err
+		}
+	}
+
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.ApplyDataSyncContext(ctx, target, diffs, opts)
+}
+
+// SyncRowsByPK syncs only the rows with the given primary keys from source to target;
+// deletes against a prod-tagged connection need the connection's name as confirmation
+func (a *App) SyncRowsByPK(source, target database.ConnectionConfig, tableName string, pks []map[string]interface{}, confirmation string) (database.SyncReport, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+
+	diffs, err := database.CompareRowsByPKContext(ctx, source, target, tableName, pks)
+	if err != nil {
+		return database.SyncReport{}, err
+	}
+	if a.connectionStore != nil {
+		var statements []string
+		for _, d := range diffs {
+			statements = append(statements, d.SQL)
+		}
+		if err := database.RequireConfirmation(a.connectionStore.FindByServer(target), statements, confirmation); err != nil {
+			return database.SyncReport{}, err
+		}
+	}
+	return database.ApplyDataSyncContext(ctx, target, diffs, database.SyncOptions{SyncInsert: true, SyncUpdate: true, SyncDelete: true})
+}
+
+// CompareTableDataStream compares table data chunk by chunk, emitting a
+// "data-compare:progress" event with the diffs of each chunk
+func (a *App) CompareTableDataStream(source, target database.ConnectionConfig, tableName string, opts database.DataCompareOptions) error {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.CompareTableDataStream(ctx, source, target, tableName, opts, func(p database.DataCompareProgress) error {
+		runtime.EventsEmit(a.ctx, "data-compare:progress", p)
+		return nil
+	})
+}
+
+// WriteDiffToReportDB records comparison results as a run in a SQLite report database
+func (a *App) WriteDiffToReportDB(reportPath, runID string, diffs []database.DiffResult, dataDiffs []database.DataDiffResult) error {
+	return database.WriteDiffToReportDB(reportPath, runID, diffs, dataDiffs)
+}
+
+// GetDataDiffFingerprints returns the fingerprints of a data comparison result for a later delta run
+func (a *App) GetDataDiffFingerprints(diffs []database.DataDiffResult) []string {
+	return database.DiffFingerprints(diffs)
+}
+
+// CompareTableDataSampled compares a deterministic sample of table rows
+func (a *App) CompareTableDataSampled(source, target database.ConnectionConfig, tableName string, samplePercent float64) (*database.SampledDataDiff, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.CompareTableDataSampledContext(ctx, source, target, tableName, samplePercent)
+}
+
+// VerifySync checks that a table's data in target matches source after a sync
+func (a *App) VerifySync(source, target database.ConnectionConfig, tableName string) (*database.VerifyResult, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.VerifySyncContext(ctx, source, target, tableName)
 }
 
 // GetDataSyncSummary returns sync summary for a table
 func (a *App) GetDataSyncSummary(source, target database.ConnectionConfig, tableName string) (*database.TableDataInfo, error) {
-	return database.GetDataSyncSummary(source, target, tableName)
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.GetDataSyncSummaryContext(ctx, source, target, tableName)
 }
 
 // CreateDatabase creates a new database
@@ -152,19 +562,189 @@ func (a *App) CreateDatabase(config database.ConnectionConfig, dbName, charset,
 	return database.CreateDatabase(config, dbName, charset, collation)
 }
 
+// CloneDatabaseStructure recreates the tables of source in target without their data
+func (a *App) CloneDatabaseStructure(source, target database.ConnectionConfig) error {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.CloneDatabaseStructureContext(ctx, source, target)
+}
+
 // GetTableStructure retrieves detailed table structure
 func (a *App) GetTableStructure(config database.ConnectionConfig, tableName string) (*database.TableInfo, error) {
-	return database.GetTableStructure(config, tableName)
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.GetTableStructureContext(ctx, config, tableName)
+}
+
+// GetTableData retrieves paginated table data, sorted and searched as query asks
+func (a *App) GetTableData(config database.ConnectionConfig, tableName string, page, pageSize int, query database.TableQuery) (*database.TableDataResult, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.GetTableDataQueryContext(ctx, config, tableName, page, pageSize, query)
+}
+
+// GetTableDataFiltered retrieves paginated table data matching a WHERE condition
+func (a *App) GetTableDataFiltered(config database.ConnectionConfig, tableName, where string, page, pageSize int) (*database.TableDataResult, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.GetTableDataFilteredContext(ctx, config, tableName, where, page, pageSize)
+}
+
+// GetTableDataAdaptive retrieves paginated table data sized to a byte budget per page
+func (a *App) GetTableDataAdaptive(config database.ConnectionConfig, tableName string, page, maxPageSize, byteBudget int) (*database.TableDataResult, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.GetTableDataAdaptiveContext(ctx, config, tableName, page, maxPageSize, byteBudget)
+}
+
+// UpdateTableRow sets column values on the row with the given primary key; against a
+// prod-tagged connection it needs the connection's name as confirmation
+func (a *App) UpdateTableRow(config database.ConnectionConfig, tableName string, primaryKey, values map[string]interface{}, confirmation string) (int64, error) {
+	if err := a.confirmRowEdit(config, database.WriteUpdate, confirmation); err != nil {
+		return 0, err
+	}
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.UpdateTableRowContext(ctx, config, tableName, primaryKey, values)
+}
+
+// InsertTableRow inserts a row with the given column values; against a prod-tagged
+// connection it needs the connection's name as confirmation
+func (a *App) InsertTableRow(config database.ConnectionConfig, tableName string, values map[string]interface{}, confirmation string) (int64, error) {
+	if err := a.confirmRowEdit(config, database.WriteInsert, confirmation); err != nil {
+		return 0, err
+	}
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.InsertTableRowContext(ctx, config, tableName, values)
+}
+
+// DeleteTableRow deletes the row with the given primary key; against a prod-tagged
+// connection it needs the connection's name as confirmation
+func (a *App) DeleteTableRow(config database.ConnectionConfig, tableName string, primaryKey map[string]interface{}, confirmation string) (int64, error) {
+	if err := a.confirmRowEdit(config, database.WriteDelete, confirmation); err != nil {
+		return 0, err
+	}
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.DeleteTableRowContext(ctx, config, tableName, primaryKey)
+}
+
+// confirmRowEdit gates a row edit on the saved connection matching config, if any
+func (a *App) confirmRowEdit(config database.ConnectionConfig, kind database.WriteKind, confirmation string) error {
+	if a.connectionStore == nil {
+		return nil
+	}
+	return database.RequireConfirmationFor(a.connectionStore.FindByServer(config), kind, confirmation)
+}
+
+// GetTableDataKeyset retrieves the page of table data following a primary-key cursor
+func (a *App) GetTableDataKeyset(config database.ConnectionConfig, tableName string, after map[string]interface{}, pageSize int) (*database.TableDataResult, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	return database.GetTableDataKeysetContext(ctx, config, tableName, after, pageSize)
+}
+
+// ExportTableCSV exports a table's rows to a CSV file
+func (a *App) ExportTableCSV(config database.ConnectionConfig, tableName, filePath string, opts database.ExportOptions) error {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return database.ExportTableCSV(config, tableName, f, opts)
+}
+
+// ExportTableJSON exports a table's rows to a JSON file
+func (a *App) ExportTableJSON(config database.ConnectionConfig, tableName, filePath string, opts database.ExportOptions) error {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return database.ExportTableJSON(config, tableName, f, opts)
+}
+
+// ExportFlywayMigration writes the schema diff as a Flyway migration into dir and returns its path
+func (a *App) ExportFlywayMigration(source, target database.ConnectionConfig, version, description, dir string) (string, error) {
+	diffs, err := a.CompareSchemas(source, target)
+	if err != nil {
+		return "", err
+	}
+	filePath := filepath.Join(dir, database.FlywayFileName(version, description))
+	f, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return filePath, database.ExportFlywayMigration(diffs, version, description, f)
+}
+
+// ExportAlembicMigration writes the schema diff as an Alembic revision with a reverse-diff downgrade
+func (a *App) ExportAlembicMigration(source, target database.ConnectionConfig, revision, downRevision, description, filePath string) error {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	sourceSchema, err := database.GetSchemaContext(ctx, source)
+	if err != nil {
+		return err
+	}
+	targetSchema, err := database.GetSchemaContext(ctx, target)
+	if err != nil {
+		return err
+	}
+	upDiffs := database.CompareSchemas(sourceSchema, targetSchema)
+	downDiffs := database.CompareSchemas(targetSchema, sourceSchema)
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return database.ExportAlembicMigration(upDiffs, downDiffs, revision, downRevision, description, f)
 }
 
-// GetTableData retrieves paginated table data
-func (a *App) GetTableData(config database.ConnectionConfig, tableName string, page, pageSize int) (*database.TableDataResult, error) {
-	return database.GetTableData(config, tableName, page, pageSize)
+// ExportERDiagram writes the database schema as a Mermaid ER diagram to filePath
+func (a *App) ExportERDiagram(config database.ConnectionConfig, filePath string) error {
+	schema, err := a.GetSchema(config)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return database.ExportERDiagram(schema, f)
+}
+
+// ImportTableCSV imports rows from a CSV file into a table
+func (a *App) ImportTableCSV(config database.ConnectionConfig, tableName, filePath string, opts database.ExportOptions) (int, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return database.ImportTableCSV(config, tableName, f, opts)
+}
+
+// ImportTableJSON imports rows from a JSON export into a table, inserting or upserting by mode
+func (a *App) ImportTableJSON(config database.ConnectionConfig, tableName, filePath, mode string) (int, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return database.ImportTableJSON(config, tableName, f, mode)
 }
 
 // GetAllTables returns all tables with basic info
 func (a *App) GetAllTables(config database.ConnectionConfig) ([]database.TableDataInfo, error) {
-	return database.GetAllTables(config)
+	ctx, cancel := a.operationContext()
+	defer cancel()
+	tables, err := database.GetTablesForSyncContext(ctx, config)
+	if err == nil {
+		a.touchConnection(config)
+	}
+	return tables, err
 }
 
 // GetSavedConnections returns all saved connections
@@ -175,11 +755,21 @@ func (a *App) GetSavedConnections() []database.SavedConnection {
 	return a.connectionStore.GetAll()
 }
 
+// GetSavedConnectionsByRecency returns all saved connections, most recently used first
+func (a *App) GetSavedConnectionsByRecency() []database.SavedConnection {
+	if a.connectionStore == nil {
+		return []database.SavedConnection{}
+	}
+	return a.connectionStore.GetAllByRecency()
+}
+
 // SaveConnection saves a connection configuration
 func (a *App) SaveConnection(name string, config database.ConnectionConfig) error {
 	if a.connectionStore == nil {
 		return nil
 	}
+	// The password or other settings may have changed; probe afresh next time
+	a.healthCache.Invalidate(config)
 	return a.connectionStore.Save(database.SavedConnection{
 		Name:   name,
 		Config: config,
@@ -194,14 +784,161 @@ func (a *App) DeleteConnection(name string) error {
 	return a.connectionStore.Delete(name)
 }
 
+// ExportConnections writes the saved connections to a file, optionally without passwords
+func (a *App) ExportConnections(filePath string, includePasswords bool) error {
+	if a.connectionStore == nil {
+		return fmt.Errorf("connection store is not available")
+	}
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := a.connectionStore.ExportConnections(f, includePasswords); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ImportConnections adds the connections of an exported file, replacing same-named ones with overwrite
+func (a *App) ImportConnections(filePath string, overwrite bool) (int, error) {
+	if a.connectionStore == nil {
+		return 0, fmt.Errorf("connection store is not available")
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return a.connectionStore.ImportConnections(f, overwrite)
+}
+
+// SetConnectionEnvironment tags a saved connection as dev, staging or prod and sets its color
+func (a *App) SetConnectionEnvironment(name, environment, color string) error {
+	if a.connectionStore == nil {
+		return nil
+	}
+	return a.connectionStore.SetEnvironment(name, database.Environment(environment), color)
+}
+
+// FindDuplicateConnections returns groups of saved connections sharing the same configuration
+func (a *App) FindDuplicateConnections() [][]database.SavedConnection {
+	if a.connectionStore == nil {
+		return [][]database.SavedConnection{}
+	}
+	return a.connectionStore.FindDuplicateConnections()
+}
+
+// GetSyncPlans returns the sync plans saved for a source/target pair
+func (a *App) GetSyncPlans(source, target database.ConnectionConfig) []database.SyncPlan {
+	if a.syncPlanStore == nil {
+		return []database.SyncPlan{}
+	}
+	return a.syncPlanStore.List(source, target)
+}
+
+// SaveSyncPlan saves per-table sync options under a name for a source/target pair
+func (a *App) SaveSyncPlan(source, target database.ConnectionConfig, plan database.SyncPlan) error {
+	if a.syncPlanStore == nil {
+		return nil
+	}
+	return a.syncPlanStore.Save(source, target, plan)
+}
+
+// DeleteSyncPlan deletes a saved sync plan
+func (a *App) DeleteSyncPlan(source, target database.ConnectionConfig, name string) error {
+	if a.syncPlanStore == nil {
+		return nil
+	}
+	return a.syncPlanStore.Delete(source, target, name)
+}
+
+// RunSyncPlan compares and syncs every table of a saved plan with its stored options;
+// deletes against a prod-tagged target need the connection's name as confirmation
+func (a *App) RunSyncPlan(source, target database.ConnectionConfig, name, confirmation string) (map[string]database.SyncReport, error) {
+	if a.syncPlanStore == nil {
+		return nil, fmt.Errorf("sync plans are unavailable")
+	}
+	plan := a.syncPlanStore.Get(source, target, name)
+	if plan == nil {
+		return nil, fmt.Errorf("sync plan %s not found", name)
+	}
+
+	ctx, cancel := a.operationContext()
+	defer cancel()
+
+	diffs, err := database.CompareSyncPlan(ctx, source, target, plan)
+	if err != nil {
+		return nil, err
+	}
+	if a.connectionStore != nil {
+		var statements []string
+		for _, tableDiffs := range diffs {
+			for _, d := range tableDiffs {
+				statements = append(statements, d.SQL)
+			}
+		}
+		if err := database.RequireConfirmation(a.connectionStore.FindByServer(target), statements, confirmation); err != nil {
+			return nil, err
+		}
+	}
+
+	tables := make([]string, 0, len(diffs))
+	for table := range diffs {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	reports := make(map[string]database.SyncReport, len(diffs))
+	for _, table := range tables {
+		opts, _ := plan.OptionsFor(table)
+		report, err := database.ApplyDataSyncContext(ctx, target, diffs[table], opts.Sync)
+		reports[table] = report
+		if err != nil {
+			return reports, fmt.Errorf("failed to sync %s: %v", table, err)
+		}
+	}
+	return reports, nil
+}
+
+// CompareDatabaseSettings reports server settings such as sql_mode that differ between source and target
+func (a *App) CompareDatabaseSettings(source, target database.ConnectionConfig) ([]database.SettingDifference, error) {
+	ctx, cancel := a.operationContext()
+	defer cancel()
+
+	sourceSettings, err := database.GetDatabaseSettingsContext(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("source: %v", err)
+	}
+	targetSettings, err := database.GetDatabaseSettingsContext(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("target: %v", err)
+	}
+	return database.CompareDatabaseSettings(sourceSettings, targetSettings), nil
+}
+
 // GetAppVersion returns the current app version
 func (a *App) GetAppVersion() string {
 	return updater.GetCurrentVersion()
 }
 
-// CheckForUpdates checks for available updates
-func (a *App) CheckForUpdates() (*updater.UpdateInfo, error) {
-	return updater.CheckForUpdates()
+// CheckForUpdates checks for available updates on the "stable" or "beta" channel
+func (a *App) CheckForUpdates(channel string) (*updater.UpdateInfo, error) {
+	return updater.CheckForUpdates(updater.Channel(channel))
+}
+
+// SetUpdateToken sets the GitHub token used to check for and download updates
+func (a *App) SetUpdateToken(token string) {
+	updater.SetGithubToken(token)
+}
+
+// CancelUpdateDownload stops the update download in progress, if any
+func (a *App) CancelUpdateDownload() {
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+	if a.updateCancel != nil {
+		a.updateCancel()
+	}
 }
 
 // OpenReleaseURL opens the release page in browser
@@ -209,10 +946,33 @@ func (a *App) OpenReleaseURL(url string) error {
 	return updater.OpenReleaseURL(url)
 }
 
-// DownloadAndApplyUpdate downloads and applies the update
-func (a *App) DownloadAndApplyUpdate(downloadURL string) error {
+// DownloadAndApplyUpdate downloads, verifies and applies the update, emitting
+// "update:progress" events with the download percentage
+func (a *App) DownloadAndApplyUpdate(downloadURL, checksum string) error {
+	parent := a.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	a.updateMu.Lock()
+	a.updateCancel = cancel
+	a.updateMu.Unlock()
+
+	progress := make(chan int, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for p := range progress {
+			if a.ctx != nil {
+				runtime.EventsEmit(a.ctx, "update:progress", p)
+			}
+		}
+	}()
+
 	// Download the update
-	filePath, err := updater.DownloadUpdate(downloadURL, nil)
+	filePath, err := updater.DownloadUpdateContext(ctx, downloadURL, checksum, progress)
+	<-done
 	if err != nil {
 		return err
 	}
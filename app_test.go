@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"syncforge/database"
+)
+
+func TestSplitSQLStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{"single without semicolon", "SELECT 1", []string{"SELECT 1"}},
+		{"several", "CREATE TABLE a (x int);\nINSERT INTO a VALUES (1);  ", []string{"CREATE TABLE a (x int)", "INSERT INTO a VALUES (1)"}},
+		{"empty statements dropped", ";;SELECT 1;;", []string{"SELECT 1"}},
+		{"semicolon in string", "INSERT INTO a VALUES ('x;y'); SELECT 2", []string{"INSERT INTO a VALUES ('x;y')", "SELECT 2"}},
+		{"doubled quote", "INSERT INTO a VALUES ('it''s;'); SELECT 2", []string{"INSERT INTO a VALUES ('it''s;')", "SELECT 2"}},
+		{"quoted identifier", `SELECT "a;b" FROM t; SELECT 2`, []string{`SELECT "a;b" FROM t`, "SELECT 2"}},
+		{
+			"dollar-quoted body",
+			"CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql; SELECT f()",
+			[]string{"CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql", "SELECT f()"},
+		},
+		{"anonymous dollar quote", "DO $$ BEGIN PERFORM 1; END $$; SELECT 2", []string{"DO $$ BEGIN PERFORM 1; END $$", "SELECT 2"}},
+		{"blank", "  \n ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitSQLStatements(tt.sql); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRowEditsRequireConfirmationOnProd(t *testing.T) {
+	config := database.ConnectionConfig{Type: database.SQLite, FilePath: filepath.Join(t.TempDir(), "prod.db")}
+	a := &App{connectionStore: &database.ConnectionStore{Connections: []database.SavedConnection{
+		{Name: "prod", Config: config, Environment: database.EnvironmentProd},
+	}}}
+	key := map[string]interface{}{"id": 1}
+	values := map[string]interface{}{"name": "x"}
+
+	edits := map[string]func() error{
+		"update": func() error { _, err := a.UpdateTableRow(config, "item", key, values, ""); return err },
+		"insert": func() error { _, err := a.InsertTableRow(config, "item", values, ""); return err },
+		"delete": func() error { _, err := a.DeleteTableRow(config, "item", key, ""); return err },
+	}
+	for name, edit := range edits {
+		if err := edit(); !errors.Is(err, database.ErrConfirmationRequired) {
+			t.Errorf("%s: err = %v, want ErrConfirmationRequired", name, err)
+		}
+	}
+}
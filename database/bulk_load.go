@@ -0,0 +1,64 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/lib/pq"
+)
+
+// bulkLoadSQL returns the driver's bulk load statement for the table: COPY FROM
+// STDIN on PostgreSQL and bulk copy on SQL Server. Rows are sent with one Exec
+// each and flushed by a final Exec without arguments. Other dialects return "".
+func bulkLoadSQL(dbType DBType, tableName string, columns []string) string {
+	switch dbType {
+	case PostgreSQL:
+		return pq.CopyIn(tableName, columns...)
+	case SQLServer:
+		return mssql.CopyIn(quoteIdentifier(SQLServer, tableName), mssql.BulkOptions{}, columns...)
+	default:
+		return ""
+	}
+}
+
+// bulkValueConverters returns a converter per column for values read as text.
+// SQL Server bulk copy doesn't convert text into integer, float or bit columns
+// itself; PostgreSQL COPY takes text for every type.
+func bulkValueConverters(db *sql.DB, dbType DBType, tableName string, columns []string) ([]func(string) (interface{}, error), error) {
+	converters := make([]func(string) (interface{}, error), len(columns))
+	for i := range converters {
+		converters[i] = func(s string) (interface{}, error) { return s, nil }
+	}
+	if dbType != SQLServer {
+		return converters, nil
+	}
+
+	info, err := getSQLServerTableInfo(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]string, len(info.Columns))
+	for _, col := range info.Columns {
+		types[col.Name] = strings.ToLower(col.Type)
+	}
+	for i, col := range columns {
+		switch types[col] {
+		case "tinyint", "smallint", "int", "bigint":
+			converters[i] = func(s string) (interface{}, error) { return strconv.ParseInt(strings.TrimSpace(s), 10, 64) }
+		case "real", "float":
+			converters[i] = func(s string) (interface{}, error) { return strconv.ParseFloat(strings.TrimSpace(s), 64) }
+		case "bit":
+			converters[i] = func(s string) (interface{}, error) {
+				b, err := strconv.ParseBool(strings.TrimSpace(s))
+				if err != nil {
+					return nil, fmt.Errorf("invalid bit value %q", s)
+				}
+				return b, nil
+			}
+		}
+	}
+	return converters, nil
+}
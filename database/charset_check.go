@@ -0,0 +1,291 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Character sets as compared by CheckCharsetConversion
+const (
+	charsetUnicode = "unicode" // UTF-8, UTF-16 and SQL Server N-types
+	charsetBMP     = "utf8mb3" // MySQL utf8/utf8mb3 and ucs2: no characters past U+FFFF
+	charsetCP1252  = "cp1252"  // MySQL latin1, SQL Server code page 1252, PostgreSQL WIN1252
+	charsetLatin1  = "latin1"  // ISO-8859-1, PostgreSQL LATIN1
+	charsetASCII   = "ascii"
+)
+
+// CharsetWarning reports a text column whose values may not survive being written
+// to the target column's character set
+type CharsetWarning struct {
+	TableName     string   `json:"tableName"`
+	Column        string   `json:"column"`
+	SourceCharset string   `json:"sourceCharset"`
+	TargetCharset string   `json:"targetCharset"`
+	Message       string   `json:"message"`
+	RowsChecked   int      `json:"rowsChecked"`       // sampled rows, 0 when values weren't read
+	LossyRows     int      `json:"lossyRows"`         // sampled rows with characters the target can't store
+	Samples       []string `json:"samples,omitempty"` // a few of those values
+}
+
+// charsetSampleLimit caps the values kept per warning
+const charsetSampleLimit = 5
+
+// CheckCharsetConversion compares the character sets of a table's text columns on
+// source and target and warns about columns whose target character set can't hold
+// every character the source's can, such as utf8mb4 into a varchar with code page
+// 1252. With sampleRows > 0 up to that many source rows are read, and a column is
+// only reported if some of its values actually contain characters the target lacks.
+func CheckCharsetConversion(source, target ConnectionConfig, tableName string, sampleRows int) ([]CharsetWarning, error) {
+	return CheckCharsetConversionContext(context.Background(), source, target, tableName, sampleRows)
+}
+
+// CheckCharsetConversionContext is CheckCharsetConversion, aborting when ctx is done
+func CheckCharsetConversionContext(ctx context.Context, source, target ConnectionConfig, tableName string, sampleRows int) ([]CharsetWarning, error) {
+	sourceDB, err := ConnectContext(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to source: %v", err)
+	}
+	defer sourceDB.Close()
+
+	targetDB, err := ConnectContext(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to target: %v", err)
+	}
+	defer targetDB.Close()
+
+	sourceType, targetType := source.Type, target.Type
+	if sourceType == "" {
+		sourceType = MySQL
+	}
+	if targetType == "" {
+		targetType = MySQL
+	}
+
+	sourceCharsets, err := getColumnCharsets(sourceDB, sourceType, source.Database, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source charsets: %v", err)
+	}
+	targetCharsets, err := getColumnCharsets(targetDB, targetType, target.Database, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get target charsets: %v", err)
+	}
+
+	columns, err := getColumns(sourceDB, sourceType, source.Database, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []CharsetWarning
+	for _, col := range columns {
+		from, to := sourceCharsets[col], targetCharsets[col]
+		if from == "" || to == "" || charsetCovers(to, from) {
+			continue
+		}
+		warnings = append(warnings, CharsetWarning{
+			TableName:     tableName,
+			Column:        col,
+			SourceCharset: from,
+			TargetCharset: to,
+			Message:       fmt.Sprintf("%s values may hold characters the target's %s cannot store; they would be replaced", from, to),
+		})
+	}
+	if sampleRows <= 0 || len(warnings) == 0 {
+		return warnings, nil
+	}
+
+	sampled := make([]string, len(warnings))
+	for i, w := range warnings {
+		sampled[i] = w.Column
+	}
+	rowsChecked := 0
+	err = forEachTableRow(sourceDB, sourceType, tableName, sampled, tableReadOptions{limit: sampleRows}, func(row map[string]interface{}) {
+		rowsChecked++
+		for i := range warnings {
+			w := &warnings[i]
+			s, ok := row[w.Column].(string)
+			if !ok || charsetRepresents(w.TargetCharset, s) {
+				continue
+			}
+			w.LossyRows++
+			if len(w.Samples) < charsetSampleLimit {
+				w.Samples = append(w.Samples, s)
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample source rows: %v", err)
+	}
+
+	var confirmed []CharsetWarning
+	for _, w := range warnings {
+		w.RowsChecked = rowsChecked
+		if !charsetCheckable(w.TargetCharset) {
+			// Unknown character set: the values can't be checked, keep the warning
+			confirmed = append(confirmed, w)
+			continue
+		}
+		if w.LossyRows > 0 {
+			w.Message = fmt.Sprintf("%d of %d sampled rows hold characters the target's %s cannot store; they would be replaced",
+				w.LossyRows, rowsChecked, w.TargetCharset)
+			confirmed = append(confirmed, w)
+		}
+	}
+	return confirmed, nil
+}
+
+// getColumnCharsets returns the normalized character set of each text column of a
+// table; other columns are left out
+func getColumnCharsets(db *sql.DB, dbType DBType, database, tableName string) (map[string]string, error) {
+	charsets := make(map[string]string)
+	switch dbType {
+	case MySQL, "":
+		rows, err := db.Query(`
+			SELECT COLUMN_NAME, CHARACTER_SET_NAME
+			FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND CHARACTER_SET_NAME IS NOT NULL`, database, tableName)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var name, charset string
+			if err := rows.Scan(&name, &charset); err != nil {
+				return nil, err
+			}
+			charsets[name] = normalizeCharset(dbType, charset)
+		}
+		return charsets, rows.Err()
+	case PostgreSQL:
+		// Text is stored in the database encoding
+		var encoding string
+		if err := db.QueryRow("SHOW server_encoding").Scan(&encoding); err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(encoding, "SQL_ASCII") {
+			// Bytes are stored without any conversion
+			return charsets, nil
+		}
+		types, err := getColumnDataTypes(db, dbType, database, tableName)
+		if err != nil {
+			return nil, err
+		}
+		for name, dataType := range types {
+			if isTextType(dbType, dataType) {
+				charsets[name] = normalizeCharset(dbType, encoding)
+			}
+		}
+		return charsets, nil
+	case SQLServer:
+		rows, err := db.Query(`
+			SELECT COLUMN_NAME, DATA_TYPE, COALESCE(CAST(COLLATIONPROPERTY(COLLATION_NAME, 'CodePage') AS int), 0)
+			FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_NAME = @p1 AND COLLATION_NAME IS NOT NULL`, tableName)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var name, dataType string
+			var codePage int
+			if err := rows.Scan(&name, &dataType, &codePage); err != nil {
+				return nil, err
+			}
+			if strings.HasPrefix(strings.ToLower(dataType), "n") {
+				charsets[name] = charsetUnicode
+			} else {
+				charsets[name] = normalizeCharset(dbType, fmt.Sprintf("cp%d", codePage))
+			}
+		}
+		return charsets, rows.Err()
+	case SQLite:
+		types, err := getColumnDataTypes(db, dbType, database, tableName)
+		if err != nil {
+			return nil, err
+		}
+		for name, dataType := range types {
+			if isTextType(dbType, dataType) {
+				charsets[name] = charsetUnicode
+			}
+		}
+		return charsets, nil
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", dbType)
+	}
+}
+
+// normalizeCharset maps a dialect's name of a character set to the name compared
+func normalizeCharset(dbType DBType, name string) string {
+	n := strings.ToLower(strings.ReplaceAll(name, "-", ""))
+	switch {
+	case n == "utf8" && (dbType == MySQL || dbType == ""):
+		return charsetBMP // MySQL's utf8 is utf8mb3
+	case n == "latin1" && dbType == PostgreSQL:
+		return charsetLatin1
+	}
+	switch n {
+	case "utf8mb4", "utf8", "utf16", "utf16le", "utf32", "cp65001":
+		return charsetUnicode
+	case "utf8mb3", "ucs2":
+		return charsetBMP
+	case "latin1", "cp1252", "win1252", "windows1252":
+		return charsetCP1252 // MySQL's latin1 is really cp1252
+	case "iso88591":
+		return charsetLatin1
+	case "ascii", "usascii", "cp20127":
+		return charsetASCII
+	default:
+		return n
+	}
+}
+
+// charsetCovers reports whether every character of from can be stored in to
+func charsetCovers(to, from string) bool {
+	if to == from || to == charsetUnicode {
+		return true
+	}
+	switch from {
+	case charsetASCII:
+		return charsetCheckable(to)
+	case charsetCP1252, charsetLatin1:
+		return to == charsetBMP
+	}
+	return false
+}
+
+// charsetCheckable reports whether charsetRepresents knows the character set
+func charsetCheckable(charset string) bool {
+	switch charset {
+	case charsetUnicode, charsetBMP, charsetCP1252, charsetLatin1, charsetASCII:
+		return true
+	}
+	return false
+}
+
+// cp1252Extras are the characters code page 1252 puts at 0x80-0x9F instead of the C1 controls
+const cp1252Extras = "€‚ƒ„…†‡ˆ‰Š‹ŒŽ‘’“”•–—˜™š›œžŸ"
+
+// charsetRepresents reports whether every character of s can be stored in charset;
+// unknown character sets are assumed to store it
+func charsetRepresents(charset, s string) bool {
+	for _, r := range s {
+		var ok bool
+		switch charset {
+		case charsetBMP:
+			ok = r <= 0xFFFF
+		case charsetCP1252:
+			ok = r < 0x80 || (r >= 0xA0 && r <= 0xFF) || strings.ContainsRune(cp1252Extras, r)
+		case charsetLatin1:
+			ok = r <= 0xFF
+		case charsetASCII:
+			ok = r < 0x80
+		default:
+			ok = true
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
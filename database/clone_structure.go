@@ -0,0 +1,221 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// autoIncrementOption matches the AUTO_INCREMENT=n table option of SHOW CREATE TABLE,
+// which would carry the source's counter into an empty clone
+var autoIncrementOption = regexp.MustCompile(`\s+AUTO_INCREMENT=\d+`)
+
+// CloneDatabaseStructure recreates the tables of source in target, without data
+func CloneDatabaseStructure(source, target ConnectionConfig) error {
+	return CloneDatabaseStructureContext(context.Background(), source, target)
+}
+
+// CloneDatabaseStructureContext creates the target database if it doesn't exist, then
+// recreates every table of source in it with its primary key, indexes and foreign
+// keys, and no rows. Tables are created after the tables they reference. Views,
+// routines and triggers are not cloned. Both databases must be of the same type,
+// and none of the source's tables may exist in the target yet.
+func CloneDatabaseStructureContext(ctx context.Context, source, target ConnectionConfig) error {
+	dbType := target.Type
+	if dbType == "" {
+		dbType = MySQL
+	}
+	if sourceType := source.Type; sourceType != dbType && !(sourceType == "" && dbType == MySQL) {
+		return fmt.Errorf("cannot clone a %s database into %s", source.Type, dbType)
+	}
+
+	schema, err := GetSchemaContext(ctx, source)
+	if err != nil {
+		return fmt.Errorf("failed to read source schema: %v", err)
+	}
+
+	var sqliteIndexes []string
+	if dbType == SQLite {
+		// SQLite's captured indexes lack their columns; take their DDL as written
+		if sqliteIndexes, err = sqliteIndexSQL(ctx, source); err != nil {
+			return fmt.Errorf("failed to read source indexes: %v", err)
+		}
+	}
+
+	if err := ensureDatabase(target); err != nil {
+		return fmt.Errorf("failed to create target database: %v", err)
+	}
+
+	db, err := ConnectContext(ctx, target)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	existing, err := getTableNames(db, dbType, target.Database)
+	if err != nil {
+		return err
+	}
+	var clashes []string
+	for _, name := range existing {
+		if _, ok := schema.Tables[name]; ok {
+			clashes = append(clashes, name)
+		}
+	}
+	if len(clashes) > 0 {
+		return fmt.Errorf("target already has tables %s", strings.Join(clashes, ", "))
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if dbType == MySQL {
+		// SHOW CREATE TABLE carries the foreign keys, which may form a cycle
+		if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+			return err
+		}
+		defer conn.ExecContext(context.Background(), "SET FOREIGN_KEY_CHECKS = 1")
+	}
+
+	for _, stmt := range cloneStatements(schema, dbType, sqliteIndexes) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to execute %q: %v", stmt, err)
+		}
+	}
+	return nil
+}
+
+// ensureDatabase creates the database config points at unless it already exists.
+// SQLite creates the file when it is first opened.
+func ensureDatabase(config ConnectionConfig) error {
+	if config.Type == SQLite {
+		return nil
+	}
+	databases, err := GetDatabases(config)
+	if err != nil {
+		return err
+	}
+	for _, name := range databases {
+		if name == config.Database {
+			return nil
+		}
+	}
+	return CreateDatabase(config, config.Database, "", "")
+}
+
+// cloneStatements builds the DDL recreating schema's tables in dependency order.
+// MySQL and SQLite declare foreign keys in CREATE TABLE; PostgreSQL and SQL Server
+// add them once every table exists.
+func cloneStatements(schema *SchemaInfo, dbType DBType, sqliteIndexes []string) []string {
+	opts := CompareOptions{Dialect: dbType}
+	var statements []string
+
+	if dbType == PostgreSQL {
+		for _, diff := range compareEnums(schema.Enums, nil) {
+			statements = append(statements, diff.SQL)
+		}
+		owned := identitySequences(schema.Tables)
+		for _, diff := range compareSequences(schema.Sequences, nil) {
+			if !owned[diff.TableName] {
+				statements = append(statements, diff.SQL)
+			}
+		}
+	}
+
+	depth := foreignKeyDepth(schema.Tables)
+	names := sortedSchemaTables(schema.Tables)
+	sort.SliceStable(names, func(i, j int) bool { return depth[names[i]] < depth[names[j]] })
+
+	for _, name := range names {
+		table := schema.Tables[name]
+		switch dbType {
+		case MySQL:
+			statements = append(statements, autoIncrementOption.ReplaceAllString(table.CreateSQL, ""))
+		case SQLite:
+			statements = append(statements, table.CreateSQL)
+		default:
+			statements = append(statements, cloneTableSQL(opts, name, table)...)
+		}
+	}
+
+	switch dbType {
+	case SQLite:
+		statements = append(statements, sqliteIndexes...)
+	case PostgreSQL, SQLServer:
+		for _, name := range names {
+			for _, fk := range schema.Tables[name].ForeignKeys {
+				statements = append(statements, buildAddForeignKey(name, fk, opts.quote))
+			}
+		}
+	}
+	return statements
+}
+
+// cloneTableSQL builds CREATE TABLE with the primary key, followed by the table's
+// indexes, keeping unique indexes unique. PostgreSQL indexes are replayed from
+// their captured definition.
+func cloneTableSQL(opts CompareOptions, name string, table TableInfo) []string {
+	indexes := buildIndexDefs(table)
+	table.Indexes = nil
+	statements := []string{opts.createTableSQL(name, table)}
+
+	for _, indexName := range sortedIndexNames(indexes) {
+		def := indexes[indexName]
+		if opts.Dialect == PostgreSQL && def.Definition != "" {
+			statements = append(statements, def.Definition+";")
+			continue
+		}
+		unique := ""
+		if def.Unique {
+			unique = "UNIQUE "
+		}
+		statements = append(statements, fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);",
+			unique, opts.quote(indexName), opts.quote(name), strings.Join(opts.quoteIndexParts(def.Parts), ", ")))
+	}
+	return statements
+}
+
+// identitySequences returns the names of the sequences PostgreSQL creates itself
+// for identity columns, which must not be created ahead of their table
+func identitySequences(tables map[string]TableInfo) map[string]bool {
+	owned := make(map[string]bool)
+	for name, table := range tables {
+		for _, col := range table.Columns {
+			if col.Identity != nil {
+				owned[fmt.Sprintf("%s_%s_seq", name, col.Name)] = true
+			}
+		}
+	}
+	return owned
+}
+
+// sqliteIndexSQL reads the CREATE INDEX statements of a SQLite database, leaving
+// out the automatic indexes behind PRIMARY KEY and UNIQUE constraints
+func sqliteIndexSQL(ctx context.Context, config ConnectionConfig) ([]string, error) {
+	db, err := ConnectContext(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, "SELECT sql FROM sqlite_master WHERE type = 'index' AND sql IS NOT NULL ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statements []string
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			return nil, err
+		}
+		statements = append(statements, stmt)
+	}
+	return statements, rows.Err()
+}
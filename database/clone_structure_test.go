@@ -0,0 +1,104 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCloneDatabaseStructureSQLite(t *testing.T) {
+	dir := t.TempDir()
+	source := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(dir, "source.db")}
+	target := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(dir, "target.db")}
+
+	db, err := sql.Open("sqlite3", source.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE item (id INTEGER PRIMARY KEY AUTOINCREMENT, order_id INT REFERENCES orders(id), sku TEXT UNIQUE)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, customer TEXT, parent INT REFERENCES orders(id))",
+		"CREATE INDEX ix_customer ON orders (customer)",
+		"CREATE UNIQUE INDEX ux_item ON item (order_id, sku)",
+		"INSERT INTO orders VALUES (1, 'a', NULL)",
+		"INSERT INTO item (order_id, sku) VALUES (1, 'x')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	db.Close()
+
+	if err := CloneDatabaseStructure(source, target); err != nil {
+		t.Fatalf("CloneDatabaseStructure: %v", err)
+	}
+
+	cloned, err := sql.Open("sqlite3", target.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cloned.Close()
+
+	names := func(objectType string) string {
+		rows, err := cloned.Query("SELECT name FROM sqlite_master WHERE type = ? AND name NOT LIKE 'sqlite_%'", objectType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var found []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatal(err)
+			}
+			found = append(found, name)
+		}
+		sort.Strings(found)
+		return strings.Join(found, ",")
+	}
+	if got := names("table"); got != "item,orders" {
+		t.Errorf("tables = %s, want item,orders", got)
+	}
+	if got := names("index"); got != "ix_customer,ux_item" {
+		t.Errorf("indexes = %s, want ix_customer,ux_item", got)
+	}
+
+	for _, table := range []string{"item", "orders"} {
+		var count int
+		if err := cloned.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("%s has %d rows, want 0", table, count)
+		}
+	}
+
+	if err := CloneDatabaseStructure(source, target); err == nil {
+		t.Error("cloning into a target that already has the tables succeeded")
+	}
+}
+
+func TestCloneStatementsPostgreSQLIndexes(t *testing.T) {
+	schema := &SchemaInfo{Tables: map[string]TableInfo{
+		"users": {
+			Name:       "users",
+			Columns:    []ColumnInfo{{Name: "id", Type: "integer", Nullable: "NO", Position: 1}, {Name: "email", Type: "text", Nullable: "YES", Position: 2}},
+			PrimaryKey: &PrimaryKeyInfo{Name: "users_pkey", Columns: []string{"id"}},
+			Indexes: []IndexInfo{
+				{Name: "users_pkey", Column: "id", SeqInIdx: 1, Definition: "CREATE UNIQUE INDEX users_pkey ON public.users USING btree (id)"},
+				{Name: "users_email_key", Column: "email", SeqInIdx: 1, Definition: "CREATE UNIQUE INDEX users_email_key ON public.users USING btree (email)"},
+			},
+		},
+	}}
+
+	got := cloneStatements(schema, PostgreSQL, nil)
+	want := []string{
+		"CREATE TABLE \"users\" (\n  \"id\" integer NOT NULL,\n  \"email\" text,\n  PRIMARY KEY (\"id\")\n);",
+		"CREATE UNIQUE INDEX users_email_key ON public.users USING btree (email);",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// connectionExportFormat tags files written by ExportConnections
+const connectionExportFormat = "syncforge-connections"
+
+// connectionExportVersion is the current layout of exported connections
+const connectionExportVersion = 1
+
+// connectionExportFile is the envelope of exported connections
+type connectionExportFile struct {
+	Format      string            `json:"format"`
+	Version     int               `json:"version"`
+	Connections []SavedConnection `json:"connections"`
+}
+
+// ExportConnections writes all saved connections to w for sharing or backup.
+// Without includePasswords, database and SSH passwords and key passphrases are
+// left out, so whoever imports them enters their own.
+func (s *ConnectionStore) ExportConnections(w io.Writer, includePasswords bool) error {
+	connections := s.GetAll()
+	if !includePasswords {
+		for i := range connections {
+			connections[i].Config = withoutSecrets(connections[i].Config)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(connectionExportFile{
+		Format:      connectionExportFormat,
+		Version:     connectionExportVersion,
+		Connections: connections,
+	})
+}
+
+// ImportConnections adds the connections of a file written by ExportConnections.
+// A connection named like an existing one replaces it with overwrite set and is
+// skipped otherwise. Returns the number of connections imported.
+func (s *ConnectionStore) ImportConnections(r io.Reader, overwrite bool) (int, error) {
+	var file connectionExportFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return 0, fmt.Errorf("failed to read connections: %v", err)
+	}
+	if file.Format != connectionExportFormat {
+		return 0, fmt.Errorf("not a connections export file")
+	}
+	if file.Version > connectionExportVersion {
+		return 0, fmt.Errorf("connections export version %d is newer than supported version %d", file.Version, connectionExportVersion)
+	}
+	for i := range file.Connections {
+		conn := &file.Connections[i]
+		if conn.Name == "" {
+			return 0, fmt.Errorf("connection without a name")
+		}
+		if _, err := conn.Config.Validate(); err != nil {
+			return 0, fmt.Errorf("connection %s: %v", conn.Name, err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := make(map[string]int, len(s.Connections))
+	for i, c := range s.Connections {
+		existing[c.Name] = i
+	}
+
+	imported := 0
+	for _, conn := range file.Connections {
+		if i, exists := existing[conn.Name]; exists {
+			if !overwrite {
+				continue
+			}
+			s.Connections[i] = conn
+		} else {
+			existing[conn.Name] = len(s.Connections)
+			s.Connections = append(s.Connections, conn)
+		}
+		imported++
+	}
+	if imported == 0 {
+		return 0, nil
+	}
+	return imported, s.save()
+}
+
+// withoutSecrets returns config with its passwords and passphrases cleared
+func withoutSecrets(config ConnectionConfig) ConnectionConfig {
+	config.Password = ""
+	if config.SSHTunnel != nil {
+		tunnel := *config.SSHTunnel
+		tunnel.Password = ""
+		tunnel.Passphrase = ""
+		config.SSHTunnel = &tunnel
+	}
+	return config
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportImportConnections(t *testing.T) {
+	dir := t.TempDir()
+	source := &ConnectionStore{filePath: filepath.Join(dir, "source.json"), Connections: []SavedConnection{{
+		Name: "prod",
+		Config: ConnectionConfig{Type: PostgreSQL, Host: "db", Port: 5432, User: "app", Password: "s3cret",
+			SSHTunnel: &SSHTunnelConfig{Host: "bastion", User: "ops", Password: "tunnel-pw", Passphrase: "key-pass"}},
+		Environment: "prod",
+	}}}
+
+	var buf bytes.Buffer
+	if err := source.ExportConnections(&buf, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"s3cret", "tunnel-pw", "key-pass"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("export without passwords contains %q", secret)
+		}
+	}
+	if source.Connections[0].Config.Password != "s3cret" || source.Connections[0].Config.SSHTunnel.Password != "tunnel-pw" {
+		t.Error("export cleared the store's own passwords")
+	}
+
+	target := &ConnectionStore{filePath: filepath.Join(dir, "target.json"), Connections: []SavedConnection{
+		{Name: "prod", Config: ConnectionConfig{Type: MySQL, Host: "old", Port: 3306}},
+	}}
+	exported := buf.String()
+	if n, err := target.ImportConnections(strings.NewReader(exported), false); err != nil || n != 0 {
+		t.Fatalf("import without overwrite = %d, %v", n, err)
+	}
+	if target.Connections[0].Config.Host != "old" {
+		t.Error("existing connection replaced without overwrite")
+	}
+	if n, err := target.ImportConnections(strings.NewReader(exported), true); err != nil || n != 1 {
+		t.Fatalf("import with overwrite = %d, %v", n, err)
+	}
+	if got := target.Connections[0]; got.Config.Host != "db" || got.Environment != "prod" || got.Config.Password != "" {
+		t.Errorf("imported %+v", got)
+	}
+
+	buf.Reset()
+	if err := source.ExportConnections(&buf, true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "s3cret") {
+		t.Error("export with passwords left out the password")
+	}
+}
+
+func TestImportConnectionsChecksEnvelope(t *testing.T) {
+	tests := []struct {
+		name, file, wantErr string
+	}{
+		{"other format", `{"format": "something-else", "version": 1, "connections": []}`, "not a connections export file"},
+		{"bare store file", `{"version": 1, "connections": []}`, "not a connections export file"},
+		{"newer version", `{"format": "syncforge-connections", "version": 2, "connections": []}`, "newer than supported"},
+		{"unnamed connection", `{"format": "syncforge-connections", "version": 1, "connections": [{"config": {"host": "db"}}]}`, "without a name"},
+		{"not json", `connections`, "failed to read connections"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &ConnectionStore{filePath: filepath.Join(t.TempDir(), "connections.json")}
+			_, err := store.ImportConnections(strings.NewReader(tt.file), true)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+			if len(store.Connections) != 0 {
+				t.Errorf("store has %d connections after a failed import", len(store.Connections))
+			}
+		})
+	}
+}
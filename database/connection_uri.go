@@ -0,0 +1,235 @@
+package database
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// mysqlDriverDSN matches the go-sql-driver form user:pass@tcp(host:port)/dbname
+var mysqlDriverDSN = regexp.MustCompile(`^(?:([^:@/]*)(?::([^@]*))?@)?tcp\(([^)]*)\)/([^?]*)`)
+
+// ConnectionValidation is the outcome of validating a connection configuration
+type ConnectionValidation struct {
+	Config   ConnectionConfig `json:"config"`
+	Warnings []string         `json:"warnings"`
+}
+
+// ParseConnectionURI parses a connection string into a ConnectionConfig. Supported forms:
+// URLs (mysql://, postgres://, sqlserver://, sqlite://), the MySQL driver DSN
+// user:pass@tcp(host:port)/db, and key=value strings such as
+// "host=db port=5432 dbname=app" or "Server=db;Database=app;User Id=sa".
+func ParseConnectionURI(uri string) (ConnectionConfig, error) {
+	uri = strings.TrimSpace(uri)
+	switch {
+	case strings.Contains(uri, "://"):
+		return parseURLConnection(uri)
+	case mysqlDriverDSN.MatchString(uri):
+		return parseMySQLDriverDSN(uri)
+	case strings.Contains(uri, "="):
+		return parseKeyValueConnection(uri)
+	}
+	return ConnectionConfig{}, fmt.Errorf("unrecognized connection string")
+}
+
+func parseURLConnection(uri string) (ConnectionConfig, error) {
+	var config ConnectionConfig
+	scheme := strings.ToLower(uri[:strings.Index(uri, "://")])
+	switch scheme {
+	case "mysql", "mariadb":
+		config.Type = MySQL
+	case "postgres", "postgresql":
+		config.Type = PostgreSQL
+	case "sqlserver", "mssql":
+		config.Type = SQLServer
+	case "sqlite", "sqlite3", "file":
+		config.Type = SQLite
+		config.FilePath = strings.SplitN(uri[len(scheme)+3:], "?", 2)[0]
+		return config, nil
+	default:
+		return config, fmt.Errorf("unsupported connection scheme: %s", scheme)
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return config, fmt.Errorf("invalid connection URL: %v", err)
+	}
+
+	config.Host = u.Hostname()
+	if port := u.Port(); port != "" {
+		config.Port, err = strconv.Atoi(port)
+		if err != nil {
+			return config, fmt.Errorf("invalid port: %s", port)
+		}
+	}
+	if u.User != nil {
+		config.User = u.User.Username()
+		config.Password, _ = u.User.Password()
+	}
+	config.Database = strings.TrimPrefix(u.Path, "/")
+	if db := u.Query().Get("database"); db != "" && config.Database == "" {
+		// sqlserver://host?database=name
+		config.Database = db
+	}
+	return config, nil
+}
+
+func parseMySQLDriverDSN(dsn string) (ConnectionConfig, error) {
+	m := mysqlDriverDSN.FindStringSubmatch(dsn)
+	config := ConnectionConfig{
+		Type:     MySQL,
+		User:     m[1],
+		Password: m[2],
+		Database: m[4],
+	}
+
+	config.Host = m[3]
+	if host, port, err := net.SplitHostPort(m[3]); err == nil {
+		config.Host = host
+		config.Port, err = strconv.Atoi(port)
+		if err != nil {
+			return config, fmt.Errorf("invalid port: %s", port)
+		}
+	}
+	return config, nil
+}
+
+func parseKeyValueConnection(s string) (ConnectionConfig, error) {
+	var config ConnectionConfig
+
+	// SQL Server connection strings separate pairs with ';', libpq with spaces
+	sep := func(r rune) bool { return r == ' ' || r == '\t' || r == '\n' }
+	if strings.Contains(s, ";") {
+		sep = func(r rune) bool { return r == ';' }
+		config.Type = SQLServer
+	} else {
+		config.Type = PostgreSQL
+	}
+
+	for _, pair := range strings.FieldsFunc(s, sep) {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		value := strings.Trim(strings.TrimSpace(kv[1]), `'"`)
+
+		switch key {
+		case "host", "server", "data source", "address", "addr":
+			config.Host = value
+			// SQL Server allows Server=host,port
+			if host, port, ok := strings.Cut(value, ","); ok {
+				config.Host = host
+				config.Port, _ = strconv.Atoi(strings.TrimSpace(port))
+			}
+		case "port":
+			p, err := strconv.Atoi(value)
+			if err != nil {
+				return config, fmt.Errorf("invalid port: %s", value)
+			}
+			config.Port = p
+		case "user", "user id", "uid", "username":
+			config.User = value
+		case "password", "pwd":
+			config.Password = value
+		case "dbname", "database", "initial catalog":
+			config.Database = value
+		}
+	}
+
+	if config.Host == "" {
+		return config, fmt.Errorf("connection string has no host")
+	}
+	return config, nil
+}
+
+// looksLikeConnectionString reports whether a host value is actually a pasted DSN/URI
+func looksLikeConnectionString(host string) bool {
+	return strings.Contains(host, "://") ||
+		strings.Contains(host, "@") ||
+		strings.Contains(host, "=") ||
+		mysqlDriverDSN.MatchString(host)
+}
+
+// Validate checks the configuration for a connection string pasted into the
+// host field. When one is found it is parsed into the individual fields and a
+// warning is returned; an error is returned if it can't be parsed.
+func (c *ConnectionConfig) Validate() ([]string, error) {
+	var warnings []string
+
+	if c.PasswordRef != "" {
+		if _, _, err := credentialProvider(c.PasswordRef); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Type == SQLite {
+		if strings.Contains(c.FilePath, "://") {
+			parsed, err := ParseConnectionURI(c.FilePath)
+			if err != nil {
+				return nil, fmt.Errorf("file path looks like a connection string but could not be parsed: %v", err)
+			}
+			c.FilePath = parsed.FilePath
+			warnings = append(warnings, "File path contained a connection string; extracted the file path")
+		}
+		return warnings, nil
+	}
+
+	host := strings.TrimSpace(c.Host)
+	if !looksLikeConnectionString(host) {
+		return warnings, nil
+	}
+
+	uri := host
+	if !strings.Contains(uri, "://") && !strings.Contains(uri, "=") && !mysqlDriverDSN.MatchString(uri) {
+		// user:pass@host[:port][/db] without a scheme; assume the configured type
+		uri = connectionScheme(c.Type) + "://" + uri
+	}
+
+	parsed, err := ParseConnectionURI(uri)
+	if err != nil {
+		return nil, fmt.Errorf("host looks like a connection string but could not be parsed: %v", err)
+	}
+
+	warnings = append(warnings, "Host contained a connection string; it was split into host, port, user, password and database")
+	if parsed.Type != c.Type && !(c.Type == "" && parsed.Type == MySQL) {
+		warnings = append(warnings, fmt.Sprintf("Connection string is for %s; database type changed from %s", parsed.Type, displayDBType(c.Type)))
+		c.Type = parsed.Type
+	}
+
+	c.Host = parsed.Host
+	if parsed.Port != 0 {
+		c.Port = parsed.Port
+	}
+	if parsed.User != "" {
+		c.User = parsed.User
+	}
+	if parsed.Password != "" {
+		c.Password = parsed.Password
+	}
+	if parsed.Database != "" {
+		c.Database = parsed.Database
+	}
+	return warnings, nil
+}
+
+func connectionScheme(dbType DBType) string {
+	switch dbType {
+	case PostgreSQL:
+		return "postgres"
+	case SQLServer:
+		return "sqlserver"
+	default:
+		return "mysql"
+	}
+}
+
+func displayDBType(dbType DBType) string {
+	if dbType == "" {
+		return string(MySQL)
+	}
+	return string(dbType)
+}
@@ -1,16 +1,40 @@
 package database
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"time"
 )
 
 // SavedConnection holds a saved database connection
 type SavedConnection struct {
-	Name     string           `json:"name"`
-	Config   ConnectionConfig `json:"config"`
+	Name   string           `json:"name"`
+	Config ConnectionConfig `json:"config"`
+	// Environment and Color label the connection; prod gates destructive operations
+	Environment Environment `json:"environment,omitempty"`
+	Color       string      `json:"color,omitempty"`
+	// LastUsedAt is when the connection was last used successfully, zero if never
+	LastUsedAt time.Time `json:"lastUsedAt"`
+}
+
+// connectionStoreVersion is the current layout of connections.json.
+// Version 0 files are a bare JSON array of connections.
+const connectionStoreVersion = 1
+
+// connectionStoreFile is the on-disk layout of connections.json
+type connectionStoreFile struct {
+	Version     int               `json:"version"`
+	Connections []SavedConnection `json:"connections"`
+}
+
+// connectionStoreMigrations upgrade a file from version i to i+1
+var connectionStoreMigrations = []func(*connectionStoreFile){
+	migrateConnectionStoreV1,
 }
 
 // ConnectionStore manages saved connections
@@ -42,7 +66,7 @@ func NewConnectionStore() (*ConnectionStore, error) {
 	return store, nil
 }
 
-// load reads connections from file
+// load reads connections from file, migrating files written by older versions
 func (s *ConnectionStore) load() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -56,12 +80,92 @@ func (s *ConnectionStore) load() error {
 		return err
 	}
 
-	return json.Unmarshal(data, &s.Connections)
+	file, err := parseConnectionStoreFile(data)
+	if err != nil {
+		return err
+	}
+	if file.Version > connectionStoreVersion {
+		return fmt.Errorf("connections file version %d is newer than supported version %d", file.Version, connectionStoreVersion)
+	}
+
+	s.Connections = file.Connections
+	if s.Connections == nil {
+		s.Connections = []SavedConnection{}
+	}
+	if file.Version == connectionStoreVersion {
+		return nil
+	}
+
+	// Keep the original around in case the upgrade loses something
+	backup := fmt.Sprintf("%s.v%d.bak", s.filePath, file.Version)
+	if err := os.WriteFile(backup, data, 0600); err != nil {
+		return fmt.Errorf("failed to back up connections file: %v", err)
+	}
+	for v := file.Version; v < connectionStoreVersion; v++ {
+		connectionStoreMigrations[v](file)
+	}
+	s.Connections = file.Connections
+	return s.save()
+}
+
+// parseConnectionStoreFile decodes any known layout of connections.json
+func parseConnectionStoreFile(data []byte) (*connectionStoreFile, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		file := &connectionStoreFile{Version: 0}
+		if err := json.Unmarshal(trimmed, &file.Connections); err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+
+	file := &connectionStoreFile{}
+	if err := json.Unmarshal(trimmed, file); err != nil {
+		return nil, err
+	}
+	return file, nil
 }
 
-// save writes connections to file
+// migrateConnectionStoreV1 fills in the database type and port, which
+// early versions left empty for MySQL and default ports
+func migrateConnectionStoreV1(file *connectionStoreFile) {
+	for i := range file.Connections {
+		config := &file.Connections[i].Config
+		if config.Type == "" {
+			config.Type = MySQL
+		}
+		if config.Port == 0 {
+			config.Port = defaultPort(config.Type)
+		}
+	}
+}
+
+// defaultPort returns the standard port of a database type, 0 for SQLite
+func defaultPort(dbType DBType) int {
+	switch dbType {
+	case MySQL, "":
+		return 3306
+	case PostgreSQL:
+		return 5432
+	case SQLServer:
+		return 1433
+	default:
+		return 0
+	}
+}
+
+// save writes connections to file. Passwords of connections with a PasswordRef
+// are dropped, since they only ever come from the credential provider.
 func (s *ConnectionStore) save() error {
-	data, err := json.MarshalIndent(s.Connections, "", "  ")
+	for i := range s.Connections {
+		if s.Connections[i].Config.PasswordRef != "" {
+			s.Connections[i].Config.Password = ""
+		}
+	}
+	data, err := json.MarshalIndent(connectionStoreFile{
+		Version:     connectionStoreVersion,
+		Connections: s.Connections,
+	}, "", "  ")
 	if err != nil {
 		return err
 	}
@@ -78,14 +182,26 @@ func (s *ConnectionStore) GetAll() []SavedConnection {
 	return result
 }
 
-// Save adds or updates a connection
+// Save adds or updates a connection. A connection string pasted into the host
+// field is split into its fields before saving. An update without an environment
+// keeps the existing tag and color, which only SetEnvironment clears.
 func (s *ConnectionStore) Save(conn SavedConnection) error {
+	if _, err := conn.Config.Validate(); err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// Check if connection with same name exists
 	for i, c := range s.Connections {
 		if c.Name == conn.Name {
+			if conn.Environment == "" && conn.Color == "" {
+				conn.Environment, conn.Color = c.Environment, c.Color
+			}
+			if conn.LastUsedAt.IsZero() {
+				conn.LastUsedAt = c.LastUsedAt
+			}
 			s.Connections[i] = conn
 			return s.save()
 		}
@@ -96,6 +212,30 @@ func (s *ConnectionStore) Save(conn SavedConnection) error {
 	return s.save()
 }
 
+// GetAllByRecency returns all saved connections, most recently used first;
+// connections never used come last, in the order they were saved
+func (s *ConnectionStore) GetAllByRecency() []SavedConnection {
+	result := s.GetAll()
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].LastUsedAt.After(result[j].LastUsedAt)
+	})
+	return result
+}
+
+// Touch records that a connection was just used
+func (s *ConnectionStore) Touch(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, c := range s.Connections {
+		if c.Name == name {
+			s.Connections[i].LastUsedAt = time.Now().UTC()
+			return s.save()
+		}
+	}
+	return fmt.Errorf("connection not found: %s", name)
+}
+
 // Delete removes a connection by name
 func (s *ConnectionStore) Delete(name string) error {
 	s.mu.Lock()
@@ -109,3 +249,53 @@ func (s *ConnectionStore) Delete(name string) error {
 	}
 	return nil
 }
+
+// FindDuplicateConnections groups saved connections whose configuration is
+// identical apart from the name and credentials. Only groups with two or more
+// members are returned.
+func (s *ConnectionStore) FindDuplicateConnections() [][]SavedConnection {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	groups := make(map[string][]SavedConnection)
+	var order []string
+	for _, c := range s.Connections {
+		key, err := duplicateKey(c.Config)
+		if err != nil {
+			continue
+		}
+		if _, seen := groups[key]; !seen {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], c)
+	}
+
+	var duplicates [][]SavedConnection
+	for _, key := range order {
+		if len(groups[key]) > 1 {
+			duplicates = append(duplicates, groups[key])
+		}
+	}
+	return duplicates
+}
+
+// duplicateKey encodes the parts of cfg that identify a connection. Passwords
+// and other secrets are left out so they never end up in the key.
+func duplicateKey(cfg ConnectionConfig) (string, error) {
+	if cfg.Type == "" {
+		cfg.Type = MySQL
+	}
+	cfg.Password = ""
+	cfg.PasswordRef = ""
+	if cfg.SSHTunnel != nil {
+		tunnel := *cfg.SSHTunnel
+		tunnel.Password = ""
+		tunnel.Passphrase = ""
+		cfg.SSHTunnel = &tunnel
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindDuplicateConnections(t *testing.T) {
+	base := ConnectionConfig{Type: PostgreSQL, Host: "db", Port: 5432, User: "app", Password: "s3cret", Database: "shop"}
+	withTunnel := base
+	withTunnel.SSHTunnel = &SSHTunnelConfig{Host: "bastion", User: "ops", Password: "tunnel-pw"}
+	otherTunnelPassword := withTunnel
+	otherTunnelPassword.SSHTunnel = &SSHTunnelConfig{Host: "bastion", User: "ops", Password: "other-pw"}
+	otherPassword := base
+	otherPassword.Password = ""
+	otherPassword.PasswordRef = "env:SHOP_DB_PASSWORD"
+	otherDatabase := base
+	otherDatabase.Database = "crm"
+	implicitMySQL := ConnectionConfig{Host: "db", Port: 3306, User: "root", Database: "shop"}
+	explicitMySQL := implicitMySQL
+	explicitMySQL.Type = MySQL
+
+	store := &ConnectionStore{Connections: []SavedConnection{
+		{Name: "shop", Config: base},
+		{Name: "shop copy", Config: base},
+		{Name: "shop via env", Config: otherPassword},
+		{Name: "crm", Config: otherDatabase},
+		{Name: "tunnel", Config: withTunnel},
+		{Name: "tunnel copy", Config: otherTunnelPassword},
+		{Name: "mysql", Config: implicitMySQL},
+		{Name: "mysql explicit", Config: explicitMySQL},
+	}}
+
+	var got []string
+	for _, group := range store.FindDuplicateConnections() {
+		var names []string
+		for _, c := range group {
+			names = append(names, c.Name)
+		}
+		got = append(got, strings.Join(names, ","))
+	}
+	want := []string{"shop,shop copy,shop via env", "tunnel,tunnel copy", "mysql,mysql explicit"}
+	if strings.Join(got, " | ") != strings.Join(want, " | ") {
+		t.Errorf("groups = %q, want %q", got, want)
+	}
+}
+
+func TestDuplicateKeyOmitsCredentials(t *testing.T) {
+	key, err := duplicateKey(ConnectionConfig{
+		Host:        "db",
+		Password:    "s3cret",
+		PasswordRef: "keychain:syncforge/prod",
+		SSHTunnel:   &SSHTunnelConfig{Host: "bastion", Password: "tunnel-pw", Passphrase: "key-pass"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"s3cret", "keychain:syncforge/prod", "tunnel-pw", "key-pass"} {
+		if strings.Contains(key, secret) {
+			t.Errorf("key contains %q: %s", secret, key)
+		}
+	}
+}
+
+func TestConnectionStoreLoadMigratesV0(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "connections.json")
+	v0 := `[
+  {"name": "legacy", "config": {"host": "db", "user": "root", "database": "shop"}},
+  {"name": "pg", "config": {"type": "postgresql", "host": "pg", "port": 6543, "user": "app"}}
+]`
+	if err := os.WriteFile(path, []byte(v0), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &ConnectionStore{filePath: path}
+	if err := store.load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.Connections[0].Config; got.Type != MySQL || got.Port != 3306 {
+		t.Errorf("legacy = %s:%d, want mysql:3306", got.Type, got.Port)
+	}
+	if got := store.Connections[1].Config; got.Type != PostgreSQL || got.Port != 6543 {
+		t.Errorf("pg = %s:%d, want postgresql:6543", got.Type, got.Port)
+	}
+
+	backup, err := os.ReadFile(path + ".v0.bak")
+	if err != nil {
+		t.Fatalf("backup not written: %v", err)
+	}
+	if string(backup) != v0 {
+		t.Errorf("backup = %s, want the original file", backup)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file connectionStoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	if file.Version != connectionStoreVersion || len(file.Connections) != 2 {
+		t.Errorf("rewritten file has version %d with %d connections", file.Version, len(file.Connections))
+	}
+
+	// A current file loads as is, without another backup
+	if err := os.Remove(path + ".v0.bak"); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&ConnectionStore{filePath: path}).load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".v0.bak"); !os.IsNotExist(err) {
+		t.Errorf("current file was backed up again: %v", err)
+	}
+}
+
+func TestConnectionStoreLoadRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "connections.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "connections": []}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	err := (&ConnectionStore{filePath: path}).load()
+	if err == nil || !strings.Contains(err.Error(), "newer than supported") {
+		t.Errorf("load = %v, want a newer version error", err)
+	}
+}
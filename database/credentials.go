@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// CredentialProvider resolves a secret reference to the password it stands for.
+// A ConnectionConfig with a PasswordRef such as "env:PROD_DB_PASSWORD" has its
+// password resolved by the provider registered for the scheme before the
+// prefix, at connect time; only the reference is ever saved.
+type CredentialProvider interface {
+	ResolvePassword(ctx context.Context, ref string) (string, error)
+}
+
+// CredentialProviderFunc adapts a function to a CredentialProvider
+type CredentialProviderFunc func(ctx context.Context, ref string) (string, error)
+
+// ResolvePassword calls f
+func (f CredentialProviderFunc) ResolvePassword(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+var (
+	credentialProvidersMu sync.RWMutex
+	credentialProviders   = map[string]CredentialProvider{
+		"env":      CredentialProviderFunc(resolveEnvPassword),
+		"keychain": CredentialProviderFunc(resolveKeychainPassword),
+	}
+)
+
+// RegisterCredentialProvider makes provider resolve references with the given
+// scheme, e.g. "vault" for "vault:secret/data/prod#password". It replaces any
+// provider registered for the scheme, including the built-in ones.
+func RegisterCredentialProvider(scheme string, provider CredentialProvider) {
+	credentialProvidersMu.Lock()
+	defer credentialProvidersMu.Unlock()
+	credentialProviders[scheme] = provider
+}
+
+// credentialProvider returns the provider for a reference and the part after its scheme
+func credentialProvider(ref string) (CredentialProvider, string, error) {
+	scheme, rest, ok := strings.Cut(ref, ":")
+	if !ok || rest == "" {
+		return nil, "", fmt.Errorf("invalid password reference %q: expected scheme:reference", ref)
+	}
+
+	credentialProvidersMu.RLock()
+	defer credentialProvidersMu.RUnlock()
+	provider, ok := credentialProviders[scheme]
+	if !ok {
+		return nil, "", fmt.Errorf("no credential provider for %q", scheme)
+	}
+	return provider, rest, nil
+}
+
+// resolveCredentials returns config with the password its PasswordRef points to.
+// config is a copy, so the resolved password never reaches the caller's config.
+func resolveCredentials(ctx context.Context, config ConnectionConfig) (ConnectionConfig, error) {
+	if config.PasswordRef == "" {
+		return config, nil
+	}
+	provider, ref, err := credentialProvider(config.PasswordRef)
+	if err != nil {
+		return config, err
+	}
+	password, err := provider.ResolvePassword(ctx, ref)
+	if err != nil {
+		return config, fmt.Errorf("failed to resolve password: %v", err)
+	}
+	config.Password = password
+	return config, nil
+}
+
+// resolveEnvPassword reads the password from an environment variable
+func resolveEnvPassword(ctx context.Context, name string) (string, error) {
+	password, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return password, nil
+}
+
+// resolveKeychainPassword reads a generic password stored as "service/account"
+// from the macOS keychain or the freedesktop secret service on Linux
+func resolveKeychainPassword(ctx context.Context, ref string) (string, error) {
+	service, account, ok := strings.Cut(ref, "/")
+	if !ok || service == "" || account == "" {
+		return "", fmt.Errorf("keychain reference %q must be service/account", ref)
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "security", "find-generic-password", "-s", service, "-a", account, "-w")
+	case "linux":
+		cmd = exec.CommandContext(ctx, "secret-tool", "lookup", "service", service, "account", account)
+	default:
+		return "", fmt.Errorf("keychain credentials are not supported on %s", runtime.GOOS)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("keychain lookup of %s failed: %v", ref, err)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
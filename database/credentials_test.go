@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveCredentials(t *testing.T) {
+	t.Setenv("SYNCFORGE_TEST_PASSWORD", "from-env")
+	RegisterCredentialProvider("test", CredentialProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		if ref == "missing" {
+			return "", errors.New("no such secret")
+		}
+		return "secret-of-" + ref, nil
+	}))
+	defer func() {
+		credentialProvidersMu.Lock()
+		delete(credentialProviders, "test")
+		credentialProvidersMu.Unlock()
+	}()
+
+	tests := []struct {
+		name, password, ref string
+		want, wantErr       string
+	}{
+		{name: "no reference keeps the password", password: "plain", want: "plain"},
+		{name: "env", password: "stale", ref: "env:SYNCFORGE_TEST_PASSWORD", want: "from-env"},
+		{name: "env not set", ref: "env:SYNCFORGE_TEST_UNSET", wantErr: "is not set"},
+		{name: "registered provider", ref: "test:prod/db", want: "secret-of-prod/db"},
+		{name: "provider error", ref: "test:missing", wantErr: "no such secret"},
+		{name: "unknown scheme", ref: "vault:secret/prod", wantErr: `no credential provider for "vault"`},
+		{name: "no scheme", ref: "SYNCFORGE_TEST_PASSWORD", wantErr: "expected scheme:reference"},
+		{name: "empty reference", ref: "env:", wantErr: "expected scheme:reference"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ConnectionConfig{Password: tt.password, PasswordRef: tt.ref}
+			resolved, err := resolveCredentials(context.Background(), config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resolved.Password != tt.want {
+				t.Errorf("password = %q, want %q", resolved.Password, tt.want)
+			}
+			if config.Password != tt.password {
+				t.Error("the caller's config was changed")
+			}
+		})
+	}
+}
+
+func TestResolveKeychainPasswordRejectsBadReference(t *testing.T) {
+	if _, err := resolveKeychainPassword(context.Background(), "no-account"); err == nil {
+		t.Error("expected an error for a reference without an account")
+	}
+}
+
+func TestConnectionStoreSaveDropsReferencedPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "connections.json")
+	store := &ConnectionStore{filePath: path}
+	if err := store.Save(SavedConnection{Name: "ref", Config: ConnectionConfig{Host: "db", Password: "resolved", PasswordRef: "env:DB_PASSWORD"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(SavedConnection{Name: "plain", Config: ConnectionConfig{Host: "db", Password: "typed"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "resolved") {
+		t.Errorf("password of a referenced connection was saved:\n%s", data)
+	}
+	var file connectionStoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	if got := file.Connections[0].Config.PasswordRef; got != "env:DB_PASSWORD" {
+		t.Errorf("saved reference = %q", got)
+	}
+	if got := file.Connections[1].Config.Password; got != "typed" {
+		t.Errorf("plain password = %q, want it kept", got)
+	}
+}
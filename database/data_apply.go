@@ -0,0 +1,242 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// SyncOptions selects which kinds of data diffs ApplyDataSync executes
+type SyncOptions struct {
+	SyncInsert bool `json:"syncInsert"`
+	SyncUpdate bool `json:"syncUpdate"`
+	SyncDelete bool `json:"syncDelete"` // also covers the truncate step of a reload
+	// CommitEvery commits after every N statements to bound the transaction log
+	// on large applies; a failure then only rolls back the current chunk and the
+	// chunks before it stay applied. 0 applies everything in one transaction.
+	CommitEvery int `json:"commitEvery,omitempty"`
+}
+
+// Options returns the sync flags of the config
+func (c DataSyncConfig) Options() SyncOptions {
+	return SyncOptions{SyncInsert: c.SyncInsert, SyncUpdate: c.SyncUpdate, SyncDelete: c.SyncDelete}
+}
+
+// SyncReport counts the statements run by ApplyDataSync
+type SyncReport struct {
+	Applied    int    `json:"applied"`   // statements executed, those after Committed undone again if RolledBack
+	Committed  int    `json:"committed"` // statements made durable, in diff order
+	Skipped    int    `json:"skipped"`   // diffs of a kind not enabled in SyncOptions
+	Failed     int    `json:"failed"`    // the statement that aborted the sync, if any
+	Inserted   int    `json:"inserted"`
+	Updated    int    `json:"updated"`
+	Deleted    int    `json:"deleted"`
+	Upserted   int    `json:"upserted"`
+	RolledBack bool   `json:"rolledBack"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ApplyDataSync executes data diffs on the target in a single transaction
+func ApplyDataSync(targetConfig ConnectionConfig, diffs []DataDiffResult, opts SyncOptions) (SyncReport, error) {
+	return ApplyDataSyncContext(context.Background(), targetConfig, diffs, opts)
+}
+
+// ApplyDataSyncContext executes data diffs on the target in a single transaction,
+// or one per opts.CommitEvery statements, rolling back the current transaction on
+// the first failing statement or when ctx is done. Statements run
+// in their parameterized form where the diff has one. Inserts that supply an
+// identity column's value are wrapped in SET IDENTITY_INSERT on SQL Server and
+// use OVERRIDING SYSTEM VALUE on PostgreSQL. Rows of a table referencing itself
+// are inserted parents first and deleted children first.
+func ApplyDataSyncContext(ctx context.Context, targetConfig ConnectionConfig, diffs []DataDiffResult, opts SyncOptions) (SyncReport, error) {
+	var report SyncReport
+	dbType := targetConfig.Type
+	if dbType == "" {
+		dbType = MySQL
+	}
+
+	db, err := ConnectContext(ctx, targetConfig)
+	if err != nil {
+		return report, fmt.Errorf("target connection failed: %w", err)
+	}
+	defer db.Close()
+
+	diffs, err = orderSelfReferences(db, dbType, targetConfig.Database, diffs)
+	if err != nil {
+		return report, fmt.Errorf("failed to read foreign keys: %v", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return report, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	fail := func(err error) (SyncReport, error) {
+		tx.Rollback()
+		report.RolledBack = true
+		report.Error = err.Error()
+		return report, err
+	}
+
+	identities := make(map[string][]string)
+	identityInsertTable := ""
+	// commit switches IDENTITY_INSERT off first: the setting belongs to the
+	// session, which the pool may hand to other work once the transaction ends
+	commit := func() error {
+		if identityInsertTable != "" {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET IDENTITY_INSERT %s OFF", quoteIdentifier(dbType, identityInsertTable))); err != nil {
+				return err
+			}
+			identityInsertTable = ""
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit: %v", err)
+		}
+		report.Committed = report.Applied
+		return nil
+	}
+	for _, d := range diffs {
+		if !opts.allows(d.Type) {
+			if d.Type != "begin" && d.Type != "commit" {
+				report.Skipped++
+			}
+			continue
+		}
+
+		query, args := d.Query, d.Args
+		if query == "" {
+			query, args = d.SQL, nil
+		}
+
+		if (d.Type == "insert" || d.Type == "upsert") && (dbType == SQLServer || dbType == PostgreSQL) {
+			cols, ok := identities[d.TableName]
+			if !ok {
+				if cols, err = getIdentityColumns(tx, dbType, d.TableName); err != nil {
+					return fail(fmt.Errorf("failed to read identity columns of %s: %v", d.TableName, err))
+				}
+				identities[d.TableName] = cols
+			}
+			var explicit bool
+			query, explicit = overrideIdentity(dbType, query, cols)
+			if dbType == SQLServer && explicit && identityInsertTable != d.TableName {
+				// Only one table per session can have IDENTITY_INSERT on
+				if identityInsertTable != "" {
+					if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET IDENTITY_INSERT %s OFF", quoteIdentifier(dbType, identityInsertTable))); err != nil {
+						return fail(err)
+					}
+				}
+				if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET IDENTITY_INSERT %s ON", quoteIdentifier(dbType, d.TableName))); err != nil {
+					return fail(err)
+				}
+				identityInsertTable = d.TableName
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			report.Failed++
+			return fail(fmt.Errorf("failed to %s %s: %v", d.Type, d.TableName, err))
+		}
+		report.Applied++
+		switch d.Type {
+		case "insert":
+			report.Inserted++
+		case "update":
+			report.Updated++
+		case "delete", "truncate":
+			report.Deleted++
+		case "upsert":
+			report.Upserted++
+		}
+
+		if opts.CommitEvery > 0 && report.Applied%opts.CommitEvery == 0 {
+			if err := commit(); err != nil {
+				return fail(err)
+			}
+			// The next chunk may run on another connection; IDENTITY_INSERT is
+			// switched on again by the first insert that needs it
+			if tx, err = db.BeginTx(ctx, nil); err != nil {
+				report.Error = err.Error()
+				return report, fmt.Errorf("failed to begin transaction: %v", err)
+			}
+		}
+	}
+
+	if err := commit(); err != nil {
+		return fail(err)
+	}
+	return report, nil
+}
+
+// allows reports whether a diff of the given type is executed. An upsert may
+// insert or update, so it needs both. The begin and commit steps of a reload
+// are replaced by ApplyDataSync's own transaction.
+func (o SyncOptions) allows(diffType string) bool {
+	switch diffType {
+	case "insert":
+		return o.SyncInsert
+	case "update":
+		return o.SyncUpdate
+	case "upsert":
+		return o.SyncInsert && o.SyncUpdate
+	case "delete", "truncate":
+		return o.SyncDelete
+	default:
+		return false
+	}
+}
+
+// getIdentityColumns lists the identity columns of a table; only SQL Server and
+// PostgreSQL need explicit values for them allowed
+func getIdentityColumns(tx *sql.Tx, dbType DBType, tableName string) ([]string, error) {
+	var query string
+	switch dbType {
+	case SQLServer:
+		query = "SELECT name FROM sys.identity_columns WHERE object_id = OBJECT_ID(@p1)"
+	case PostgreSQL:
+		query = `
+			SELECT column_name
+			FROM information_schema.columns
+			WHERE table_schema = 'public' AND table_name = $1 AND is_identity = 'YES'`
+	default:
+		return nil, nil
+	}
+
+	rows, err := tx.Query(query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			return nil, err
+		}
+		cols = append(cols, col)
+	}
+	return cols, rows.Err()
+}
+
+// overrideIdentity reports whether an INSERT supplies a value for one of the
+// identity columns and, on PostgreSQL, rewrites it to override the generated value
+func overrideIdentity(dbType DBType, query string, identityCols []string) (string, bool) {
+	explicit := false
+	for _, col := range identityCols {
+		explicit = explicit || insertsColumn(dbType, query, col)
+	}
+	if dbType == PostgreSQL && explicit {
+		query = strings.Replace(query, ") VALUES", ") OVERRIDING SYSTEM VALUE VALUES", 1)
+	}
+	return query, explicit
+}
+
+// insertsColumn reports whether a generated INSERT lists the column
+func insertsColumn(dbType DBType, query, column string) bool {
+	end := strings.Index(query, ") VALUES")
+	if end < 0 {
+		return false
+	}
+	return strings.Contains(query[:end], quoteIdentifier(dbType, column))
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyDataSyncCommitEvery(t *testing.T) {
+	tests := []struct {
+		name          string
+		commitEvery   int
+		failAt        int // 1-based statement that fails, 0 for none
+		wantCommitted int
+		wantIDs       []int
+	}{
+		{"single transaction rolls back everything", 0, 4, 0, nil},
+		{"single transaction", 0, 0, 5, []int{1, 2, 3, 4, 5}},
+		{"every 2, fails in the second chunk", 2, 4, 2, []int{1, 2}},
+		{"every 2, fails in the last chunk", 2, 5, 4, []int{1, 2, 3, 4}},
+		{"every 3, fails in the second chunk", 3, 5, 3, []int{1, 2, 3}},
+		{"every 2 without failure", 2, 0, 5, []int{1, 2, 3, 4, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(t.TempDir(), "target.db")}
+			db, err := sql.Open("sqlite3", config.FilePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			if _, err := db.Exec("CREATE TABLE item (id INTEGER PRIMARY KEY, name TEXT NOT NULL)"); err != nil {
+				t.Fatal(err)
+			}
+
+			var diffs []DataDiffResult
+			for id := 1; id <= 5; id++ {
+				name := fmt.Sprintf("'item %d'", id)
+				if id == tt.failAt {
+					name = "NULL"
+				}
+				diffs = append(diffs, DataDiffResult{
+					Type:      "insert",
+					TableName: "item",
+					SQL:       fmt.Sprintf(`INSERT INTO "item" ("id", "name") VALUES (%d, %s);`, id, name),
+				})
+			}
+
+			report, err := ApplyDataSync(config, diffs, SyncOptions{SyncInsert: true, CommitEvery: tt.commitEvery})
+			if (err != nil) != (tt.failAt > 0) {
+				t.Fatalf("err = %v", err)
+			}
+			if report.Committed != tt.wantCommitted {
+				t.Errorf("committed %d statements, want %d", report.Committed, tt.wantCommitted)
+			}
+			if report.RolledBack != (tt.failAt > 0) {
+				t.Errorf("RolledBack = %v", report.RolledBack)
+			}
+
+			var ids []int
+			rows, err := db.Query("SELECT id FROM item ORDER BY id")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var id int
+				if err := rows.Scan(&id); err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, id)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("target holds %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestApplyDataSync(t *testing.T) {
+	config := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(t.TempDir(), "target.db")}
+	db, err := sql.Open("sqlite3", config.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE TABLE item (id INTEGER PRIMARY KEY, name TEXT); INSERT INTO item VALUES (1, 'old'), (2, 'gone')"); err != nil {
+		t.Fatal(err)
+	}
+
+	diffs := []DataDiffResult{
+		{Type: "insert", TableName: "item", SQL: `INSERT INTO "item" ("id", "name") VALUES (3, 'new');`,
+			Query: `INSERT INTO "item" ("id", "name") VALUES (?, ?)`, Args: []any{3, "new"}},
+		{Type: "update", TableName: "item", SQL: `UPDATE "item" SET "name" = 'renamed' WHERE "id" = 1;`},
+		{Type: "delete", TableName: "item", SQL: `DELETE FROM "item" WHERE "id" = 2;`},
+		{Type: "insert", TableName: "item", SQL: `INSERT INTO "item" ("id", "name") VALUES (4, 'extra');`},
+	}
+	report, err := ApplyDataSync(config, diffs, SyncOptions{SyncInsert: true, SyncUpdate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SyncReport{Applied: 3, Committed: 3, Skipped: 1, Inserted: 2, Updated: 1}
+	if report != want {
+		t.Errorf("report = %+v, want %+v", report, want)
+	}
+
+	var got []string
+	rows, err := db.Query("SELECT id || ':' || name FROM item ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var row string
+		if err := rows.Scan(&row); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, row)
+	}
+	wantRows := []string{"1:renamed", "2:gone", "3:new", "4:extra"}
+	if !reflect.DeepEqual(got, wantRows) {
+		t.Errorf("target holds %q, want %q", got, wantRows)
+	}
+}
+
+func TestOverrideIdentity(t *testing.T) {
+	tests := []struct {
+		name         string
+		dbType       DBType
+		query        string
+		wantExplicit bool
+		wantOverride bool
+	}{
+		{"postgres with identity", PostgreSQL, `INSERT INTO "item" ("id", "name") VALUES ($1, $2)`, true, true},
+		{"postgres without identity", PostgreSQL, `INSERT INTO "item" ("name") VALUES ($1)`, false, false},
+		{"postgres identity only in values", PostgreSQL, `INSERT INTO "item" ("name") VALUES ('"id"')`, false, false},
+		{"sql server with identity", SQLServer, `INSERT INTO [item] ([id], [name]) VALUES (@p1, @p2)`, true, false},
+		{"sql server without identity", SQLServer, `INSERT INTO [item] ([name]) VALUES (@p1)`, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, explicit := overrideIdentity(tt.dbType, tt.query, []string{"id"})
+			if explicit != tt.wantExplicit {
+				t.Errorf("explicit = %v, want %v", explicit, tt.wantExplicit)
+			}
+			if got := strings.Contains(query, ") OVERRIDING SYSTEM VALUE VALUES"); got != tt.wantOverride {
+				t.Errorf("query = %s, override %v, want %v", query, got, tt.wantOverride)
+			}
+		})
+	}
+}
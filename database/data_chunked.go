@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultDataChunkSize is the number of rows read per chunk when comparing table data
+const DefaultDataChunkSize = 5000
+
+// DataCompareProgress reports one compared chunk of a table
+type DataCompareProgress struct {
+	TableName   string           `json:"tableName"`
+	RowsScanned int              `json:"rowsScanned"` // source rows compared so far
+	Diffs       []DataDiffResult `json:"diffs"`       // diffs found in this chunk
+	Done        bool             `json:"done"`
+	Warning     string           `json:"warning,omitempty"` // why the table wasn't compared in chunks
+}
+
+// CompareTableDataStream compares table data chunk by chunk, calling emit after
+// each chunk so callers can show progress; returning an error from emit stops
+// the comparison. Tables compared by MatchColumns, as a multiset or with the
+// reload or upsert strategy are compared in one go and reported as a single chunk, as are
+// tables whose primary key collation differs between source and target.
+func CompareTableDataStream(ctx context.Context, sourceConfig, targetConfig ConnectionConfig, tableName string, opts DataCompareOptions, emit func(DataCompareProgress) error) error {
+	if opts.Multiset || opts.Strategy == SyncStrategyReload || opts.Strategy == SyncStrategyUpsert || len(opts.MatchColumns) > 0 {
+		diffs, err := compareTableData(ctx, sourceConfig, targetConfig, tableName, opts)
+		if err != nil {
+			return err
+		}
+		return emit(DataCompareProgress{TableName: tableName, Diffs: diffs, Done: true})
+	}
+
+	cmp, err := openDataComparison(ctx, sourceConfig, targetConfig, tableName, opts)
+	if err != nil {
+		return err
+	}
+	defer cmp.Close()
+
+	if cmp.keyOrderWarning != "" {
+		diffs, err := cmp.compareInMemory()
+		if err != nil {
+			return err
+		}
+		return emit(DataCompareProgress{TableName: tableName, Diffs: diffs, Done: true, Warning: cmp.keyOrderWarning})
+	}
+	return cmp.compareChunked(ctx, emit)
+}
+
+// compareChunked walks the source in primary-key order, DataCompareOptions.ChunkSize
+// rows at a time, and pairs each chunk with the target rows in the same key range,
+// so at most one chunk per side is held in memory. Key ranges are bounded with
+// the source's values but evaluated by each database, so both sides must order
+// the primary key the same way; a collation difference on a text key can make
+// rows land in the wrong chunk and show up as a delete plus an insert.
+func (c *dataComparison) compareChunked(ctx context.Context, emit func(DataCompareProgress) error) error {
+	chunkSize := c.options.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultDataChunkSize
+	}
+
+	var lower map[string]interface{}
+	scanned := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		sourceRows, order, err := c.readSourceChunk(lower, chunkSize)
+		if err != nil {
+			return fmt.Errorf("failed to get source data: %v", err)
+		}
+		last := len(order) < chunkSize
+
+		// The final chunk is open-ended so trailing target rows are deleted
+		var upper map[string]interface{}
+		if !last {
+			upper = extractPrimaryKey(sourceRows[order[len(order)-1]], c.primaryKeys)
+		}
+
+		var diffs []DataDiffResult
+		err = c.forEachTargetRow(lower, upper, chunkSize, func(targetRow map[string]interface{}) {
+			key := rowKey(targetRow, c.primaryKeys)
+			sourceRow, exists := sourceRows[key]
+			if !exists {
+				diffs = append(diffs, c.deleteDiff(targetRow))
+				return
+			}
+			delete(sourceRows, key)
+			if update := c.updateDiff(sourceRow, targetRow); update != nil {
+				diffs = append(diffs, *update)
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get target data: %v", err)
+		}
+		for _, key := range order {
+			if sourceRow, remaining := sourceRows[key]; remaining {
+				diffs = append(diffs, c.insertDiff(sourceRow))
+			}
+		}
+
+		if len(c.options.PreviousFingerprints) > 0 {
+			diffs = DeltaDataDiffs(diffs, c.options.PreviousFingerprints)
+		}
+		scanned += len(order)
+		if err := emit(DataCompareProgress{TableName: c.tableName, RowsScanned: scanned, Diffs: diffs, Done: last}); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		lower = upper
+	}
+}
+
+// readSourceChunk reads up to limit source rows after the lower key, returning
+// them by key along with the keys in primary-key order
+func (c *dataComparison) readSourceChunk(lower map[string]interface{}, limit int) (map[string]map[string]interface{}, []string, error) {
+	opts := tableReadOptions{
+		bitColumns:      c.sourceBits,
+		readExpressions: c.options.SourceReadExpressions,
+		orderBy:         c.primaryKeys,
+		limit:           limit,
+	}
+	if lower != nil {
+		opts.where, opts.args = keysetAfter(c.sourceType, c.primaryKeys, lower, nil)
+	}
+	opts.where = c.filtered(opts.where)
+
+	rows := make(map[string]map[string]interface{})
+	var order []string
+	err := forEachTableRow(c.sourceDB, c.sourceType, c.tableName, c.columns, opts, func(row map[string]interface{}) {
+		key := rowKey(row, c.primaryKeys)
+		rows[key] = row
+		order = append(order, key)
+	})
+	return rows, order, err
+}
+
+// forEachTargetRow streams the target rows with a key in (lower, upper], page by
+// page; a nil bound leaves that end of the range open
+func (c *dataComparison) forEachTargetRow(lower, upper map[string]interface{}, pageSize int, fn func(row map[string]interface{})) error {
+	for {
+		opts := tableReadOptions{
+			bitColumns:      c.targetBits,
+			readExpressions: c.options.TargetReadExpressions,
+			orderBy:         c.primaryKeys,
+			limit:           pageSize,
+		}
+		conds := []string{c.options.WhereClause}
+		if lower != nil {
+			var cond string
+			cond, opts.args = keysetAfter(c.targetType, c.primaryKeys, lower, opts.args)
+			conds = append(conds, cond)
+		}
+		if upper != nil {
+			var cond string
+			cond, opts.args = keysetAfter(c.targetType, c.primaryKeys, upper, opts.args)
+			conds = append(conds, "NOT "+cond)
+		}
+		opts.where = andWhere(conds...)
+
+		var lastRow map[string]interface{}
+		count := 0
+		err := forEachTableRow(c.targetDB, c.targetType, c.tableName, c.columns, opts, func(row map[string]interface{}) {
+			fn(row)
+			lastRow = row
+			count++
+		})
+		if err != nil {
+			return err
+		}
+		if count < pageSize {
+			return nil
+		}
+		lower = extractPrimaryKey(lastRow, c.primaryKeys)
+	}
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"hash/fnv"
+)
+
+// DiffFingerprint identifies a data diff by its type, primary key and the values it
+// writes. The same pending change found again in a later run has the same fingerprint;
+// a row whose source values changed since then gets a new one.
+func DiffFingerprint(diff DataDiffResult) string {
+	// json.Marshal sorts map keys, so equal maps encode identically
+	encoded, _ := json.Marshal(struct {
+		Type       string                 `json:"t"`
+		TableName  string                 `json:"n"`
+		PrimaryKey map[string]interface{} `json:"k"`
+		NewValues  map[string]interface{} `json:"v"`
+	}{diff.Type, diff.TableName, diff.PrimaryKey, diff.NewValues})
+
+	h := fnv.New128a()
+	h.Write(encoded)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// DiffFingerprints returns the fingerprints of a comparison result, to be kept and
+// passed back as DataCompareOptions.PreviousFingerprints on the next run
+func DiffFingerprints(diffs []DataDiffResult) []string {
+	fingerprints := make([]string, len(diffs))
+	for i, diff := range diffs {
+		fingerprints[i] = DiffFingerprint(diff)
+	}
+	return fingerprints
+}
+
+// DeltaDataDiffs drops the diffs already reported by a previous run
+func DeltaDataDiffs(diffs []DataDiffResult, previousFingerprints []string) []DataDiffResult {
+	seen := make(map[string]bool, len(previousFingerprints))
+	for _, fp := range previousFingerprints {
+		seen[fp] = true
+	}
+	var delta []DataDiffResult
+	for _, diff := range diffs {
+		if !seen[DiffFingerprint(diff)] {
+			delta = append(delta, diff)
+		}
+	}
+	return delta
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// checkKeyCollations compares the collations of the primary key columns on both
+// sides. Chunked comparison relies on both servers ordering the key the same
+// way; when they may not, the mismatch is recorded so the rows are paired in
+// memory instead.
+func (c *dataComparison) checkKeyCollations(sourceDatabase, targetDatabase string) error {
+	sourceCollations, err := getTextCollations(c.sourceDB, c.sourceType, sourceDatabase, c.tableName)
+	if err != nil {
+		return fmt.Errorf("failed to get source collations: %v", err)
+	}
+	targetCollations, err := getTextCollations(c.targetDB, c.targetType, targetDatabase, c.tableName)
+	if err != nil {
+		return fmt.Errorf("failed to get target collations: %v", err)
+	}
+
+	var mismatches []string
+	for _, key := range c.primaryKeys {
+		s, t := sourceCollations[key], targetCollations[key]
+		if canonicalCollation(s) != canonicalCollation(t) {
+			mismatches = append(mismatches, fmt.Sprintf("%s (%s vs %s)", key, displayCollation(s), displayCollation(t)))
+		}
+	}
+	if len(mismatches) > 0 {
+		c.keyOrderWarning = fmt.Sprintf("primary key collations differ: %s; rows were paired in memory instead of by key range", strings.Join(mismatches, ", "))
+	}
+	return nil
+}
+
+// getTextCollations returns the collation of each text column of a table.
+// PostgreSQL's "default" is resolved to the database collation; SQLite columns
+// are taken to use its default BINARY collation.
+func getTextCollations(db *sql.DB, dbType DBType, database, tableName string) (map[string]string, error) {
+	collations := make(map[string]string)
+
+	var rows *sql.Rows
+	var err error
+	switch dbType {
+	case MySQL, "":
+		rows, err = db.Query(`
+			SELECT COLUMN_NAME, COLLATION_NAME
+			FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND COLLATION_NAME IS NOT NULL`, database, tableName)
+	case SQLServer:
+		rows, err = db.Query(`
+			SELECT COLUMN_NAME, COLLATION_NAME
+			FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_NAME = @p1 AND COLLATION_NAME IS NOT NULL`, tableName)
+	case PostgreSQL:
+		rows, err = db.Query(`
+			SELECT a.attname,
+				CASE WHEN co.collname = 'default'
+					THEN (SELECT datcollate FROM pg_database WHERE datname = current_database())
+					ELSE co.collname END
+			FROM pg_attribute a
+			JOIN pg_class t ON t.oid = a.attrelid
+			JOIN pg_namespace n ON n.oid = t.relnamespace
+			JOIN pg_collation co ON co.oid = a.attcollation
+			WHERE n.nspname = 'public' AND t.relname = $1 AND a.attnum > 0 AND NOT a.attisdropped`, tableName)
+	default:
+		types, err := getColumnDataTypes(db, dbType, database, tableName)
+		if err != nil {
+			return nil, err
+		}
+		for col, dataType := range types {
+			if isTextType(dbType, dataType) {
+				collations[col] = "BINARY"
+			}
+		}
+		return collations, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var col, collation string
+		if err := rows.Scan(&col, &collation); err != nil {
+			return nil, err
+		}
+		collations[col] = collation
+	}
+	return collations, rows.Err()
+}
+
+// canonicalCollation folds the names of collations that order by code point
+// into one, so e.g. MySQL utf8mb4_bin and PostgreSQL "C" count as the same
+func canonicalCollation(collation string) string {
+	c := strings.ToLower(collation)
+	switch {
+	case c == "binary", c == "c", c == "posix", c == "ucs_basic",
+		strings.HasSuffix(c, "_bin"), strings.HasSuffix(c, "_bin2"):
+		return "binary"
+	}
+	return c
+}
+
+func displayCollation(collation string) string {
+	if collation == "" {
+		return "none"
+	}
+	return collation
+}
+
+// compareInMemory reads both sides whole and pairs rows by their normalized
+// primary key, so keys equal under the comparison's text options pair up even
+// when the servers would order them differently. Every diff carries the key order
+// warning, so callers of CompareTableData, which only get the diffs, see it too.
+func (c *dataComparison) compareInMemory() ([]DataDiffResult, error) {
+	sourceData, targetData, err := c.readBoth(tableReadOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if sourceData, err = c.rekeyNormalized(sourceData); err != nil {
+		return nil, fmt.Errorf("source: %v", err)
+	}
+	if targetData, err = c.rekeyNormalized(targetData); err != nil {
+		return nil, fmt.Errorf("target: %v", err)
+	}
+
+	diffs := c.diff(sourceData, targetData)
+	if len(c.options.PreviousFingerprints) > 0 {
+		diffs = DeltaDataDiffs(diffs, c.options.PreviousFingerprints)
+	}
+	for i := range diffs {
+		diffs[i].Warning = joinWarnings(diffs[i].Warning, c.keyOrderWarning)
+	}
+	return diffs, nil
+}
+
+// joinWarnings appends a warning to those already on a diff
+func joinWarnings(existing, warning string) string {
+	switch {
+	case warning == "":
+		return existing
+	case existing == "":
+		return warning
+	default:
+		return existing + "; " + warning
+	}
+}
+
+// rekeyNormalized re-indexes rows by the comparable form of their primary key
+func (c *dataComparison) rekeyNormalized(data map[string]map[string]interface{}) (map[string]map[string]interface{}, error) {
+	result := make(map[string]map[string]interface{}, len(data))
+	for _, row := range data {
+		normalized := make(map[string]interface{}, len(c.primaryKeys))
+		for _, col := range c.primaryKeys {
+			if row[col] != nil {
+				normalized[col] = comparableValue(c.kinds[col], row[col])
+			}
+		}
+		key := rowKey(normalized, c.primaryKeys)
+		if _, exists := result[key]; exists {
+			return nil, fmt.Errorf("primary key %s collides with another row once normalized", rowKey(row, c.primaryKeys))
+		}
+		result[key] = row
+	}
+	return result, nil
+}
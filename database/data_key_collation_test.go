@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalCollation(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"utf8mb4_bin", "C", true},
+		{"BINARY", "POSIX", true},
+		{"Latin1_General_BIN2", "ucs_basic", true},
+		{"utf8mb4_0900_ai_ci", "utf8mb4_0900_AI_CI", true},
+		{"utf8mb4_0900_ai_ci", "utf8mb4_bin", false},
+		{"en_US.UTF-8", "C", false},
+	}
+	for _, tt := range tests {
+		if got := canonicalCollation(tt.a) == canonicalCollation(tt.b); got != tt.same {
+			t.Errorf("%s vs %s: same = %v, want %v", tt.a, tt.b, got, tt.same)
+		}
+	}
+}
+
+// openItemComparison compares an item table that differs in rows 2 (updated),
+// 3 (only in the source) and 4 (only in the target)
+func openItemComparison(t *testing.T, opts DataCompareOptions) *dataComparison {
+	t.Helper()
+	dir := t.TempDir()
+	source := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(dir, "source.db")}
+	target := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(dir, "target.db")}
+	for config, data := range map[ConnectionConfig]string{
+		source: "INSERT INTO item VALUES ('a', 1), ('b', 2), ('c', 3)",
+		target: "INSERT INTO item VALUES ('a', 1), ('b', 20), ('d', 4)",
+	} {
+		db, err := sql.Open("sqlite3", config.FilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec("CREATE TABLE item (code TEXT PRIMARY KEY, qty INTEGER); " + data); err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+	}
+	cmp, err := openDataComparison(context.Background(), source, target, "item", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cmp.Close)
+	return cmp
+}
+
+func TestCompareInMemoryCarriesKeyOrderWarning(t *testing.T) {
+	cmp := openItemComparison(t, DataCompareOptions{})
+	if cmp.keyOrderWarning != "" {
+		t.Fatalf("unexpected warning for equal collations: %s", cmp.keyOrderWarning)
+	}
+	cmp.keyOrderWarning = "primary key collations differ"
+
+	diffs, err := cmp.compareInMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 3 {
+		t.Fatalf("got %d diffs, want 3", len(diffs))
+	}
+	for _, d := range diffs {
+		if !strings.Contains(d.Warning, cmp.keyOrderWarning) {
+			t.Errorf("%s diff lacks the key order warning: %q", d.Type, d.Warning)
+		}
+	}
+
+	if got := joinWarnings("value too long", "collations differ"); got != "value too long; collations differ" {
+		t.Errorf("joinWarnings = %q", got)
+	}
+}
+
+func TestPreviousFingerprintsOnEveryPairing(t *testing.T) {
+	first, err := openItemComparison(t, DataCompareOptions{}).compareInMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Keep the update, so it is the only diff already reported
+	var previous []string
+	for _, d := range first {
+		if d.Type == "update" {
+			previous = append(previous, DiffFingerprint(d))
+		}
+	}
+
+	opts := DataCompareOptions{PreviousFingerprints: previous, ChunkSize: 1}
+	byPairing := map[string]func(*dataComparison) ([]DataDiffResult, error){
+		"in memory": (*dataComparison).compareInMemory,
+		"by key range": func(c *dataComparison) ([]DataDiffResult, error) {
+			var diffs []DataDiffResult
+			err := c.compareChunked(context.Background(), func(p DataCompareProgress) error {
+				diffs = append(diffs, p.Diffs...)
+				return nil
+			})
+			return diffs, err
+		},
+	}
+	for name, compare := range byPairing {
+		t.Run(name, func(t *testing.T) {
+			diffs, err := compare(openItemComparison(t, opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var types []string
+			for _, d := range diffs {
+				types = append(types, d.Type)
+			}
+			if strings.Join(types, ",") != "insert,delete" && strings.Join(types, ",") != "delete,insert" {
+				t.Errorf("diffs = %v, want the insert and delete only", types)
+			}
+		})
+	}
+}
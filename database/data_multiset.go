@@ -0,0 +1,163 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// rowBag counts the copies of each distinct row, keyed by a hash of the whole row
+type rowBag struct {
+	counts map[string]int
+	rows   map[string]map[string]interface{}
+}
+
+// compareMultiset compares the table as a bag of rows. Rows can't be told apart
+// without a key, so a row present more often on one side is reported as that many
+// inserts or deletes of identical copies.
+func (c *dataComparison) compareMultiset() ([]DataDiffResult, error) {
+	var sourceBag *rowBag
+	var sourceErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sourceBag, sourceErr = c.readBag(c.sourceDB, c.sourceType, tableReadOptions{
+			bitColumns:      c.sourceBits,
+			readExpressions: c.options.SourceReadExpressions,
+			where:           c.filtered(""),
+			fetchSize:       c.options.FetchSize,
+		})
+	}()
+
+	targetBag, err := c.readBag(c.targetDB, c.targetType, tableReadOptions{
+		bitColumns:      c.targetBits,
+		readExpressions: c.options.TargetReadExpressions,
+		where:           c.filtered(""),
+		fetchSize:       c.options.FetchSize,
+	})
+	<-done
+
+	if sourceErr != nil {
+		return nil, fmt.Errorf("failed to get source data: %v", sourceErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get target data: %v", err)
+	}
+
+	var results []DataDiffResult
+	for _, hash := range sortedKeys(sourceBag.counts) {
+		row := sourceBag.rows[hash]
+		query, args := insertStatement(c.targetType, c.tableName, row, c.columns)
+		for i := targetBag.counts[hash]; i < sourceBag.counts[hash]; i++ {
+			results = append(results, DataDiffResult{
+				Type:      "insert",
+				TableName: c.tableName,
+				NewValues: row,
+				SQL:       generateInsertSQL(c.targetType, c.tableName, row, c.columns),
+				Query:     query,
+				Args:      args,
+				Warning:   checkEnumValues(row, c.targetEnums),
+			})
+		}
+	}
+	for _, hash := range sortedKeys(targetBag.counts) {
+		row := targetBag.rows[hash]
+		query, args := deleteOneStatement(c.targetType, c.tableName, c.columns, row)
+		for i := sourceBag.counts[hash]; i < targetBag.counts[hash]; i++ {
+			results = append(results, DataDiffResult{
+				Type:      "delete",
+				TableName: c.tableName,
+				OldValues: row,
+				SQL:       generateDeleteOneSQL(c.targetType, c.tableName, c.columns, row),
+				Query:     query,
+				Args:      args,
+			})
+		}
+	}
+
+	return results, nil
+}
+
+func (c *dataComparison) readBag(db *sql.DB, dbType DBType, opts tableReadOptions) (*rowBag, error) {
+	bag := &rowBag{
+		counts: make(map[string]int),
+		rows:   make(map[string]map[string]interface{}),
+	}
+	err := forEachTableRow(db, dbType, c.tableName, c.columns, opts, func(row map[string]interface{}) {
+		hash := rowHash(row, c.columns, c.kinds)
+		if bag.counts[hash] == 0 {
+			bag.rows[hash] = row
+		}
+		bag.counts[hash]++
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bag, nil
+}
+
+// rowHash hashes the values of a row in column order, in the form rowsEqual compares them
+func rowHash(row map[string]interface{}, columns []string, kinds map[string]valueKind) string {
+	values := make([]interface{}, len(columns))
+	for i, col := range columns {
+		if row[col] != nil {
+			values[i] = comparableValue(kinds[col], row[col])
+		}
+	}
+	encoded, _ := json.Marshal(values)
+
+	h := fnv.New128a()
+	h.Write(encoded)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// generateDeleteOneSQL deletes a single one of possibly several identical rows,
+// matching on every column since there is no key
+func generateDeleteOneSQL(dbType DBType, tableName string, columns []string, row map[string]interface{}) string {
+	return buildDeleteOneSQL(&sqlValues{dbType: dbType}, tableName, columns, row)
+}
+
+// deleteOneStatement is generateDeleteOneSQL with bind placeholders and their ordered arguments
+func deleteOneStatement(dbType DBType, tableName string, columns []string, row map[string]interface{}) (string, []interface{}) {
+	values := &sqlValues{dbType: dbType, bind: true}
+	query := buildDeleteOneSQL(values, tableName, columns, row)
+	return query, values.args
+}
+
+func buildDeleteOneSQL(values *sqlValues, tableName string, columns []string, row map[string]interface{}) string {
+	dbType := values.dbType
+	table := quoteIdentifier(dbType, tableName)
+	var wheres []string
+	for _, col := range columns {
+		if row[col] == nil {
+			wheres = append(wheres, fmt.Sprintf("%s IS NULL", quoteIdentifier(dbType, col)))
+			continue
+		}
+		wheres = append(wheres, fmt.Sprintf("%s = %s", quoteIdentifier(dbType, col), values.render(row[col])))
+	}
+	where := strings.Join(wheres, " AND ")
+
+	switch dbType {
+	case PostgreSQL:
+		return fmt.Sprintf("DELETE FROM %s WHERE ctid IN (SELECT ctid FROM %s WHERE %s LIMIT 1);", table, table, where)
+	case SQLite:
+		return fmt.Sprintf("DELETE FROM %s WHERE rowid IN (SELECT rowid FROM %s WHERE %s LIMIT 1);", table, table, where)
+	case SQLServer:
+		return fmt.Sprintf("DELETE TOP (1) FROM %s WHERE %s;", table, where)
+	default:
+		return fmt.Sprintf("DELETE FROM %s WHERE %s LIMIT 1;", table, where)
+	}
+}
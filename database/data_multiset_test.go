@@ -0,0 +1,75 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCompareMultiset(t *testing.T) {
+	dir := t.TempDir()
+	source := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(dir, "source.db")}
+	target := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(dir, "target.db")}
+	for config, data := range map[ConnectionConfig]string{
+		source: "INSERT INTO tag VALUES ('a', 1), ('a', 1), ('a', 1), ('b', NULL)",
+		target: "INSERT INTO tag VALUES ('a', 1), ('c', 2), ('b', NULL), ('c', 2)",
+	} {
+		db, err := sql.Open("sqlite3", config.FilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec("CREATE TABLE tag (name TEXT, weight INTEGER); " + data); err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+	}
+
+	diffs, err := CompareTableDataWithOptions(source, target, "tag", DataCompareOptions{Multiset: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range diffs {
+		row := d.NewValues
+		if d.Type == "delete" {
+			row = d.OldValues
+		}
+		got = append(got, fmt.Sprintf("%s %v", d.Type, row["name"]))
+	}
+	want := []string{"insert a", "insert a", "delete c", "delete c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("diffs = %q, want %q", got, want)
+	}
+
+	if _, err := ApplyDataSync(target, diffs, SyncOptions{SyncInsert: true, SyncDelete: true}); err != nil {
+		t.Fatal(err)
+	}
+	diffs, err = CompareTableDataWithOptions(source, target, "tag", DataCompareOptions{Multiset: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("%d diffs left after applying", len(diffs))
+	}
+}
+
+func TestGenerateDeleteOneSQL(t *testing.T) {
+	row := map[string]interface{}{"name": "a", "weight": nil}
+	columns := []string{"name", "weight"}
+	tests := []struct {
+		dbType DBType
+		want   string
+	}{
+		{MySQL, "DELETE FROM `tag` WHERE `name` = 'a' AND `weight` IS NULL LIMIT 1;"},
+		{PostgreSQL, `DELETE FROM "tag" WHERE ctid IN (SELECT ctid FROM "tag" WHERE "name" = 'a' AND "weight" IS NULL LIMIT 1);`},
+		{SQLite, `DELETE FROM "tag" WHERE rowid IN (SELECT rowid FROM "tag" WHERE "name" = 'a' AND "weight" IS NULL LIMIT 1);`},
+		{SQLServer, "DELETE TOP (1) FROM [tag] WHERE [name] = 'a' AND [weight] IS NULL;"},
+	}
+	for _, tt := range tests {
+		if got := generateDeleteOneSQL(tt.dbType, "tag", columns, row); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.dbType, got, tt.want)
+		}
+	}
+}
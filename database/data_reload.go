@@ -0,0 +1,167 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SyncStrategy selects how a table's data is brought in line with the source
+type SyncStrategy string
+
+const (
+	// SyncStrategyDiff generates row-level INSERT/UPDATE/DELETE statements
+	SyncStrategyDiff SyncStrategy = "diff"
+	// SyncStrategyReload empties the target table and inserts every source row,
+	// which is simpler and faster for small reference tables
+	SyncStrategyReload SyncStrategy = "reload"
+	// SyncStrategyUpsert writes every source row with an idempotent upsert,
+	// leaving the database to insert or update it; the target isn't read, so
+	// rows only in the target are kept
+	SyncStrategyUpsert SyncStrategy = "upsert"
+)
+
+// DefaultReloadBatchSize is the number of rows per INSERT when reloading a table.
+// SQL Server accepts at most 1000 rows in a VALUES list.
+const DefaultReloadBatchSize = 500
+
+// reload generates a transaction that empties the target table and inserts all source rows
+func (c *dataComparison) reload(sourceData map[string]map[string]interface{}) ([]DataDiffResult, error) {
+	clearSQL, err := c.clearTableSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	batchSize := c.options.ReloadBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultReloadBatchSize
+	}
+	if c.targetType == SQLServer && batchSize > 1000 {
+		batchSize = 1000
+	}
+
+	results := []DataDiffResult{
+		{Type: "begin", TableName: c.tableName, SQL: beginTransactionSQL(c.targetType)},
+		{Type: "truncate", TableName: c.tableName, SQL: clearSQL},
+	}
+
+	// Insert in primary key order so the output is stable between runs
+	keys := make([]string, 0, len(sourceData))
+	for key := range sourceData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for start := 0; start < len(keys); start += batchSize {
+		end := start + batchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		rows := make([]map[string]interface{}, 0, end-start)
+		var warning string
+		for _, key := range keys[start:end] {
+			rows = append(rows, sourceData[key])
+			if warning == "" {
+				warning = checkEnumValues(sourceData[key], c.targetEnums)
+			}
+		}
+		results = append(results, DataDiffResult{
+			Type:      "insert",
+			TableName: c.tableName,
+			SQL:       generateBatchInsertSQL(c.targetType, c.tableName, rows, c.columns),
+			Warning:   warning,
+		})
+	}
+
+	results = append(results, DataDiffResult{Type: "commit", TableName: c.tableName, SQL: "COMMIT;"})
+	return results, nil
+}
+
+// clearTableSQL returns TRUNCATE where it is transactional and allowed, DELETE otherwise.
+// MySQL's TRUNCATE commits implicitly and SQLite has none; Postgres and SQL Server
+// refuse to truncate tables referenced by other tables' foreign keys.
+func (c *dataComparison) clearTableSQL() (string, error) {
+	table := quoteIdentifier(c.targetType, c.tableName)
+	if c.options.WhereClause != "" {
+		// Only the filtered rows are reloaded
+		return fmt.Sprintf("DELETE FROM %s WHERE %s;", table, c.options.WhereClause), nil
+	}
+	deleteSQL := fmt.Sprintf("DELETE FROM %s;", table)
+
+	switch c.targetType {
+	case PostgreSQL, SQLServer:
+		referenced, err := isReferencedByForeignKeys(c.targetDB, c.targetType, c.tableName)
+		if err != nil {
+			return "", err
+		}
+		if referenced {
+			return deleteSQL, nil
+		}
+		return fmt.Sprintf("TRUNCATE TABLE %s;", table), nil
+	default:
+		return deleteSQL, nil
+	}
+}
+
+// isReferencedByForeignKeys reports whether other tables hold foreign keys to the table
+func isReferencedByForeignKeys(db *sql.DB, dbType DBType, tableName string) (bool, error) {
+	var query string
+	switch dbType {
+	case PostgreSQL:
+		query = `
+			SELECT COUNT(*)
+			FROM pg_constraint c
+			JOIN pg_class t ON t.oid = c.confrelid
+			JOIN pg_namespace n ON n.oid = t.relnamespace
+			WHERE c.contype = 'f' AND n.nspname = 'public' AND t.relname = $1
+			AND c.conrelid <> c.confrelid`
+	case SQLServer:
+		query = `
+			SELECT COUNT(*)
+			FROM sys.foreign_keys
+			WHERE referenced_object_id = OBJECT_ID(@p1)
+			AND parent_object_id <> referenced_object_id`
+	default:
+		return false, nil
+	}
+
+	var count int
+	if err := db.QueryRow(query, tableName).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check foreign key references: %v", err)
+	}
+	return count > 0, nil
+}
+
+func beginTransactionSQL(dbType DBType) string {
+	switch dbType {
+	case SQLServer:
+		return "BEGIN TRANSACTION;"
+	case PostgreSQL, SQLite:
+		return "BEGIN;"
+	default:
+		return "START TRANSACTION;"
+	}
+}
+
+// generateBatchInsertSQL builds one multi-row INSERT statement
+func generateBatchInsertSQL(dbType DBType, tableName string, rows []map[string]interface{}, columns []string) string {
+	quotedCols := make([]string, len(columns))
+	for i, col := range columns {
+		quotedCols[i] = quoteIdentifier(dbType, col)
+	}
+
+	tuples := make([]string, len(rows))
+	for i, row := range rows {
+		vals := make([]string, len(columns))
+		for j, col := range columns {
+			vals[j] = escapeValueFor(dbType, row[col])
+		}
+		tuples[i] = "(" + strings.Join(vals, ", ") + ")"
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES\n%s;",
+		quoteIdentifier(dbType, tableName),
+		strings.Join(quotedCols, ", "),
+		strings.Join(tuples, ",\n"))
+}
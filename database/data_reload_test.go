@@ -0,0 +1,97 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReloadStrategy(t *testing.T) {
+	tests := []struct {
+		name      string
+		where     string
+		wantTypes []string
+		wantClear string
+		wantRows  []string
+	}{
+		{
+			"whole table", "",
+			[]string{"begin", "truncate", "insert", "insert", "insert", "commit"},
+			`DELETE FROM "item";`,
+			[]string{"1:a", "2:b", "3:c", "4:d", "5:e"},
+		},
+		{
+			"filtered", "tenant = 1",
+			[]string{"begin", "truncate", "insert", "insert", "commit"},
+			`DELETE FROM "item" WHERE tenant = 1;`,
+			[]string{"1:a", "2:b", "3:c", "5:e", "7:other tenant"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			source := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(dir, "source.db")}
+			target := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(dir, "target.db")}
+			for config, data := range map[ConnectionConfig]string{
+				source: "INSERT INTO item VALUES (1, 'a', 1), (2, 'b', 1), (3, 'c', 1), (4, 'd', 2), (5, 'e', 1)",
+				target: "INSERT INTO item VALUES (1, 'stale', 1), (6, 'target only', 1), (7, 'other tenant', 2)",
+			} {
+				db, err := sql.Open("sqlite3", config.FilePath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := db.Exec("CREATE TABLE item (id INTEGER PRIMARY KEY, name TEXT, tenant INTEGER); " + data); err != nil {
+					t.Fatal(err)
+				}
+				db.Close()
+			}
+
+			diffs, err := CompareTableDataWithOptions(source, target, "item", DataCompareOptions{
+				Strategy:        SyncStrategyReload,
+				ReloadBatchSize: 2,
+				WhereClause:     tt.where,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var types []string
+			for _, d := range diffs {
+				types = append(types, d.Type)
+			}
+			if !reflect.DeepEqual(types, tt.wantTypes) {
+				t.Fatalf("diff types = %q, want %q", types, tt.wantTypes)
+			}
+			if diffs[1].SQL != tt.wantClear {
+				t.Errorf("clear = %s, want %s", diffs[1].SQL, tt.wantClear)
+			}
+
+			if _, err := ApplyDataSync(target, diffs, SyncOptions{SyncInsert: true, SyncDelete: true}); err != nil {
+				t.Fatal(err)
+			}
+			db, err := sql.Open("sqlite3", target.FilePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			rows, err := db.Query("SELECT id, name FROM item ORDER BY id")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+			var got []string
+			for rows.Next() {
+				var id int
+				var name string
+				if err := rows.Scan(&id, &name); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, fmt.Sprintf("%d:%s", id, name))
+			}
+			if !reflect.DeepEqual(got, tt.wantRows) {
+				t.Errorf("target holds %q, want %q", got, tt.wantRows)
+			}
+		})
+	}
+}
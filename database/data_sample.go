@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"strings"
+)
+
+// sampleBuckets is the resolution of the sample predicate (0.01%)
+const sampleBuckets = 10000
+
+// SampledDataDiff is the result of comparing a deterministic sample of a table
+type SampledDataDiff struct {
+	TableName      string           `json:"tableName"`
+	SamplePercent  float64          `json:"samplePercent"`
+	SourceRows     int              `json:"sourceRows"`     // rows read from the source sample
+	TargetRows     int              `json:"targetRows"`     // rows read from the target sample
+	Diffs          []DataDiffResult `json:"diffs"`          // differences found within the sample
+	EstimatedDiffs int              `json:"estimatedDiffs"` // extrapolated to the whole table
+}
+
+// CompareTableDataSampled compares only a deterministic sample of primary keys.
+// The same keys are picked on both sides and on every run, so a row that
+// differs and falls in the sample is always reported.
+func CompareTableDataSampled(sourceConfig, targetConfig ConnectionConfig, tableName string, samplePercent float64) (*SampledDataDiff, error) {
+	return CompareTableDataSampledContext(context.Background(), sourceConfig, targetConfig, tableName, samplePercent)
+}
+
+// CompareTableDataSampledContext is CompareTableDataSampled, aborting when ctx is done
+func CompareTableDataSampledContext(ctx context.Context, sourceConfig, targetConfig ConnectionConfig, tableName string, samplePercent float64) (*SampledDataDiff, error) {
+	if samplePercent <= 0 || samplePercent > 100 || math.IsNaN(samplePercent) {
+		return nil, fmt.Errorf("sample percent must be greater than 0 and at most 100")
+	}
+
+	cmp, err := openDataComparison(ctx, sourceConfig, targetConfig, tableName, DataCompareOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer cmp.Close()
+
+	threshold := int(math.Round(samplePercent * sampleBuckets / 100))
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	var opts tableReadOptions
+	if threshold < sampleBuckets {
+		where := samplePredicate(cmp.sourceType, cmp.primaryKeys, threshold)
+		if cmp.sourceType == cmp.targetType && where != "" {
+			// Both sides hash the key the same way, so filter in the database
+			opts.where = where
+		} else {
+			// Different hash functions per dialect; pick keys client-side instead
+			opts.keep = func(pkKey string) bool {
+				return int(crc32.ChecksumIEEE([]byte(pkKey))%sampleBuckets) < threshold
+			}
+		}
+	}
+
+	sourceData, targetData, err := cmp.readBoth(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := cmp.diff(sourceData, targetData)
+	return &SampledDataDiff{
+		TableName:      tableName,
+		SamplePercent:  samplePercent,
+		SourceRows:     len(sourceData),
+		TargetRows:     len(targetData),
+		Diffs:          diffs,
+		EstimatedDiffs: int(math.Round(float64(len(diffs)) * 100 / samplePercent)),
+	}, nil
+}
+
+// samplePredicate returns a condition selecting keys whose hash falls below threshold,
+// or "" when the dialect has no suitable hash function
+func samplePredicate(dbType DBType, primaryKeys []string, threshold int) string {
+	quoted := make([]string, len(primaryKeys))
+	for i, pk := range primaryKeys {
+		quoted[i] = quoteIdentifier(dbType, pk)
+	}
+	cols := strings.Join(quoted, ", ")
+
+	switch dbType {
+	case PostgreSQL:
+		return fmt.Sprintf("ABS(hashtext(CONCAT_WS('|', %s))::bigint) %% %d < %d", cols, sampleBuckets, threshold)
+	case SQLServer:
+		return fmt.Sprintf("ABS(CAST(CHECKSUM(%s) AS BIGINT)) %% %d < %d", cols, sampleBuckets, threshold)
+	case SQLite:
+		return ""
+	default: // MySQL
+		return fmt.Sprintf("MOD(CRC32(CONCAT_WS('|', %s)), %d) < %d", cols, sampleBuckets, threshold)
+	}
+}
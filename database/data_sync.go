@@ -1,8 +1,11 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -14,33 +17,43 @@ type DataSyncConfig struct {
 	SyncInsert   bool             `json:"syncInsert"`
 	SyncUpdate   bool             `json:"syncUpdate"`
 	SyncDelete   bool             `json:"syncDelete"`
+	// WhereClause limits the sync to matching rows on both sides; see DataCompareOptions.WhereClause
+	WhereClause string `json:"whereClause,omitempty"`
 }
 
 // TableDataInfo holds table data comparison info
 type TableDataInfo struct {
-	TableName    string   `json:"tableName"`
-	PrimaryKeys  []string `json:"primaryKeys"`
-	Columns      []string `json:"columns"`
-	SourceCount  int      `json:"sourceCount"`
-	TargetCount  int      `json:"targetCount"`
-	InsertCount  int      `json:"insertCount"`
-	UpdateCount  int      `json:"updateCount"`
-	DeleteCount  int      `json:"deleteCount"`
+	TableName   string   `json:"tableName"`
+	PrimaryKeys []string `json:"primaryKeys"`
+	Columns     []string `json:"columns"`
+	SourceCount int      `json:"sourceCount"`
+	TargetCount int      `json:"targetCount"`
+	InsertCount int      `json:"insertCount"`
+	UpdateCount int      `json:"updateCount"`
+	DeleteCount int      `json:"deleteCount"`
 }
 
 // DataDiffResult holds data difference details
 type DataDiffResult struct {
-	Type       string                 `json:"type"` // "insert", "update", "delete"
+	Type       string                 `json:"type"` // "insert", "update", "delete"; a reload adds "begin", "truncate", "commit"; "upsert" for the upsert strategy
 	TableName  string                 `json:"tableName"`
 	PrimaryKey map[string]interface{} `json:"primaryKey"`
 	OldValues  map[string]interface{} `json:"oldValues,omitempty"`
 	NewValues  map[string]interface{} `json:"newValues,omitempty"`
-	SQL        string                 `json:"sql"`
+	SQL        string                 `json:"sql"`               // for display and export
+	Query      string                 `json:"query,omitempty"`   // SQL with bind placeholders, the form statements are executed in
+	Args       []interface{}          `json:"args,omitempty"`    // arguments of Query in order
+	Warning    string                 `json:"warning,omitempty"` // e.g. values the target column can't hold
 }
 
 // GetTablesForSync returns list of tables available for data sync
 func GetTablesForSync(config ConnectionConfig) ([]TableDataInfo, error) {
-	db, err := Connect(config)
+	return GetTablesForSyncContext(context.Background(), config)
+}
+
+// GetTablesForSyncContext returns list of tables available for data sync, aborting when ctx is done
+func GetTablesForSyncContext(ctx context.Context, config ConnectionConfig) ([]TableDataInfo, error) {
+	db, err := ConnectContext(ctx, config)
 	if err != nil {
 		return nil, err
 	}
@@ -119,6 +132,11 @@ func getTableNames(db *sql.DB, dbType DBType, database string) ([]string, error)
 		}
 		tableNames = append(tableNames, name)
 	}
+
+	if dbType == SQLServer {
+		// History tables of temporal tables are written by the server only
+		tableNames = filterTableNames(tableNames, getSQLServerHistoryTables(db))
+	}
 	return tableNames, nil
 }
 
@@ -138,117 +156,427 @@ func quoteIdentifier(dbType DBType, name string) string {
 	}
 }
 
+// placeholder returns the n-th (1-based) bind parameter marker for the database type
+func placeholder(dbType DBType, n int) string {
+	switch dbType {
+	case PostgreSQL:
+		return fmt.Sprintf("$%d", n)
+	case SQLServer:
+		return fmt.Sprintf("@p%d", n)
+	default:
+		return "?"
+	}
+}
+
+// DataCompareOptions tunes how table data is read and compared
+type DataCompareOptions struct {
+	// SourceReadExpressions and TargetReadExpressions replace the plain column in
+	// the SELECT list with a dialect-specific expression, e.g. "tags::text" for a
+	// Postgres array or "ST_AsText(geom)", so both sides read comparable values
+	SourceReadExpressions map[string]string `json:"sourceReadExpressions,omitempty"`
+	TargetReadExpressions map[string]string `json:"targetReadExpressions,omitempty"`
+	// MatchColumns pairs source and target rows by these "business key" columns
+	// instead of the primary key, for surrogate keys that differ between databases.
+	// UPDATE and DELETE still target rows by each side's primary key; INSERTs leave
+	// surrogate key columns to the target's auto-increment/default.
+	MatchColumns []string `json:"matchColumns,omitempty"`
+	// Strategy chooses between a row-level diff (default) and a full reload
+	Strategy SyncStrategy `json:"strategy,omitempty"`
+	// ReloadBatchSize is the number of rows per INSERT for the reload strategy
+	ReloadBatchSize int `json:"reloadBatchSize,omitempty"`
+	// PreviousFingerprints holds DiffFingerprints of an earlier run; diffs found
+	// then are left out so only changes since that run are reported. It applies
+	// to row-level diffs however rows are paired: by key range chunk by chunk, in
+	// memory, or by MatchColumns. Multiset, reload and upsert results ignore it.
+	PreviousFingerprints []string `json:"previousFingerprints,omitempty"`
+	// Multiset compares the table as a bag of whole rows, for tables without a
+	// primary key: only the net number of copies of each distinct row is reported,
+	// as inserts and deletes, never as updates
+	Multiset bool `json:"multiset,omitempty"`
+	// ChunkSize is the number of rows read per side at a time when rows are paired
+	// by primary key; 0 uses DefaultDataChunkSize
+	ChunkSize int `json:"chunkSize,omitempty"`
+	// WhereClause limits the comparison to rows matching this SQL condition on
+	// both sides, e.g. "tenant_id = 42". It is inserted verbatim after WHERE and
+	// must be valid on both databases; see ValidateWhereClause.
+	WhereClause string `json:"whereClause,omitempty"`
+	// ColumnOptions trims trailing spaces or ignores case when comparing the text
+	// of the named columns, taking precedence over DetectColumnOptions
+	ColumnOptions map[string]ColumnCompareOptions `json:"columnOptions,omitempty"`
+	// DetectColumnOptions trims CHAR columns and ignores the case of columns with a
+	// case-insensitive collation, on either side
+	DetectColumnOptions bool `json:"detectColumnOptions,omitempty"`
+	// FetchSize is the number of rows fetched per round trip when a whole table is
+	// read at once (multiset, reload and match-column comparisons), so the rows
+	// stream instead of arriving as one result; 0 lets the driver decide
+	FetchSize int `json:"fetchSize,omitempty"`
+}
+
 // CompareTableData compares data between source and target tables
 func CompareTableData(sourceConfig, targetConfig ConnectionConfig, tableName string) ([]DataDiffResult, error) {
-	sourceDB, err := Connect(sourceConfig)
+	return CompareTableDataWithOptions(sourceConfig, targetConfig, tableName, DataCompareOptions{})
+}
+
+// CompareTableDataContext compares data between source and target tables, aborting when ctx is done
+func CompareTableDataContext(ctx context.Context, sourceConfig, targetConfig ConnectionConfig, tableName string, opts DataCompareOptions) ([]DataDiffResult, error) {
+	return compareTableData(ctx, sourceConfig, targetConfig, tableName, opts)
+}
+
+// CompareTableDataWithOptions compares data between source and target tables using the given options
+func CompareTableDataWithOptions(sourceConfig, targetConfig ConnectionConfig, tableName string, opts DataCompareOptions) ([]DataDiffResult, error) {
+	return compareTableData(context.Background(), sourceConfig, targetConfig, tableName, opts)
+}
+
+func compareTableData(ctx context.Context, sourceConfig, targetConfig ConnectionConfig, tableName string, opts DataCompareOptions) ([]DataDiffResult, error) {
+	cmp, err := openDataComparison(ctx, sourceConfig, targetConfig, tableName, opts)
 	if err != nil {
-		return nil, fmt.Errorf("source connection failed: %v", err)
+		return nil, err
+	}
+	defer cmp.Close()
+
+	if opts.Multiset {
+		return cmp.compareMultiset()
 	}
-	defer sourceDB.Close()
 
-	targetDB, err := Connect(targetConfig)
+	if opts.Strategy == SyncStrategyReload {
+		sourceData, err := getTableData(cmp.sourceDB, cmp.sourceType, tableName, cmp.columns, cmp.primaryKeys, tableReadOptions{
+			bitColumns:      cmp.sourceBits,
+			readExpressions: opts.SourceReadExpressions,
+			where:           cmp.filtered(""),
+			fetchSize:       opts.FetchSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get source data: %v", err)
+		}
+		return cmp.reload(sourceData)
+	}
+
+	if opts.Strategy == SyncStrategyUpsert {
+		return cmp.upsertAll()
+	}
+
+	if len(opts.MatchColumns) == 0 && cmp.keyOrderWarning != "" {
+		return cmp.compareInMemory()
+	}
+	if len(opts.MatchColumns) == 0 {
+		var diffs []DataDiffResult
+		err := cmp.compareChunked(ctx, func(p DataCompareProgress) error {
+			diffs = append(diffs, p.Diffs...)
+			return nil
+		})
+		return diffs, err
+	}
+
+	sourceData, targetData, err := cmp.readBoth(tableReadOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("target connection failed: %v", err)
+		return nil, err
 	}
-	defer targetDB.Close()
 
-	sourceType := sourceConfig.Type
-	if sourceType == "" {
-		sourceType = MySQL
+	for _, col := range opts.MatchColumns {
+		if !containsString(cmp.columns, col) {
+			return nil, fmt.Errorf("match column %s does not exist in table %s", col, tableName)
+		}
 	}
-	targetType := targetConfig.Type
-	if targetType == "" {
-		targetType = MySQL
+	if sourceData, err = rekeyRows(sourceData, opts.MatchColumns); err != nil {
+		return nil, fmt.Errorf("source: %v", err)
+	}
+	if targetData, err = rekeyRows(targetData, opts.MatchColumns); err != nil {
+		return nil, fmt.Errorf("target: %v", err)
 	}
 
-	// Get primary keys
-	primaryKeys, err := getPrimaryKeys(sourceDB, sourceType, sourceConfig.Database, tableName)
+	diffs := cmp.diff(sourceData, targetData)
+	if len(opts.PreviousFingerprints) > 0 {
+		diffs = DeltaDataDiffs(diffs, opts.PreviousFingerprints)
+	}
+	return diffs, nil
+}
+
+// rekeyRows re-indexes rows by the given columns, which must be unique
+func rekeyRows(data map[string]map[string]interface{}, keyColumns []string) (map[string]map[string]interface{}, error) {
+	result := make(map[string]map[string]interface{}, len(data))
+	for _, row := range data {
+		key := rowKey(row, keyColumns)
+		if _, exists := result[key]; exists {
+			parts := make([]string, len(keyColumns))
+			for i, col := range keyColumns {
+				parts[i] = fmt.Sprintf("%v", row[col])
+			}
+			return nil, fmt.Errorf("match columns (%s) are not unique: duplicate value %s", strings.Join(keyColumns, ", "), strings.Join(parts, ", "))
+		}
+		result[key] = row
+	}
+	return result, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// dataComparison holds the connections and table metadata needed to read and
+// diff one table on both sides
+type dataComparison struct {
+	tableName   string
+	sourceDB    *sql.DB
+	targetDB    *sql.DB
+	sourceType  DBType
+	targetType  DBType
+	primaryKeys []string
+	columns     []string
+	sourceBits  map[string]bool
+	targetBits  map[string]bool
+	targetEnums map[string][]string
+	kinds       map[string]valueKind // from the source's column types
+	options     DataCompareOptions
+	// keyOrderWarning is set when the two sides may order the primary key
+	// differently, which rules out chunked comparison
+	keyOrderWarning string
+}
+
+// openDataComparison connects to both sides and loads the table metadata.
+// The caller must Close it.
+func openDataComparison(ctx context.Context, sourceConfig, targetConfig ConnectionConfig, tableName string, opts DataCompareOptions) (*dataComparison, error) {
+	if err := ValidateWhereClause(opts.WhereClause); err != nil {
+		return nil, err
+	}
+	if SameDatabase(sourceConfig, targetConfig) {
+		return nil, ErrSameDatabase
+	}
+	cmp := &dataComparison{
+		tableName:  tableName,
+		sourceType: sourceConfig.Type,
+		targetType: targetConfig.Type,
+		options:    opts,
+	}
+	if cmp.sourceType == "" {
+		cmp.sourceType = MySQL
+	}
+	if cmp.targetType == "" {
+		cmp.targetType = MySQL
+	}
+
+	var err error
+	cmp.sourceDB, err = ConnectContext(ctx, sourceConfig)
+	if err != nil {
+		return nil, fmt.Errorf("source connection failed: %w", err)
+	}
+
+	cmp.targetDB, err = ConnectContext(ctx, targetConfig)
 	if err != nil {
+		cmp.sourceDB.Close()
+		return nil, fmt.Errorf("target connection failed: %w", err)
+	}
+
+	if err := cmp.loadMetadata(sourceConfig.Database, targetConfig.Database); err != nil {
+		cmp.Close()
 		return nil, err
 	}
-	if len(primaryKeys) == 0 {
-		return nil, fmt.Errorf("table %s has no primary key", tableName)
+	return cmp, nil
+}
+
+func (c *dataComparison) loadMetadata(sourceDatabase, targetDatabase string) error {
+	var err error
+
+	// Get primary keys
+	c.primaryKeys, err = getPrimaryKeys(c.sourceDB, c.sourceType, sourceDatabase, c.tableName)
+	if err != nil {
+		return err
+	}
+	if len(c.primaryKeys) == 0 && !c.options.Multiset {
+		return fmt.Errorf("table %s has no primary key", c.tableName)
 	}
 
 	// Get columns
-	columns, err := getColumns(sourceDB, sourceType, sourceConfig.Database, tableName)
+	c.columns, err = getColumns(c.sourceDB, c.sourceType, sourceDatabase, c.tableName)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var results []DataDiffResult
+	// Numbers and timestamps compare by value rather than by their text form
+	c.kinds, err = getColumnKinds(c.sourceDB, c.sourceType, sourceDatabase, c.tableName)
+	if err != nil {
+		return err
+	}
+	if err := c.applyColumnOptions(sourceDatabase, targetDatabase); err != nil {
+		return err
+	}
+	if len(c.primaryKeys) > 0 {
+		if err := c.checkKeyCollations(sourceDatabase, targetDatabase); err != nil {
+			return err
+		}
+	}
 
-	// Get source data
-	sourceData, err := getTableData(sourceDB, sourceType, tableName, columns, primaryKeys)
+	// Enum columns on the target only accept their declared values
+	c.targetEnums, err = getEnumColumnValues(c.targetDB, c.targetType, targetDatabase, c.tableName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get source data: %v", err)
+		return err
 	}
 
-	// Get target data
-	targetData, err := getTableData(targetDB, targetType, tableName, columns, primaryKeys)
+	// BIT/boolean columns come back as []byte, bool or int depending on the driver
+	c.sourceBits, err = getBitColumns(c.sourceDB, c.sourceType, sourceDatabase, c.tableName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get target data: %v", err)
+		return err
 	}
+	c.targetBits, err = getBitColumns(c.targetDB, c.targetType, targetDatabase, c.tableName)
+	return err
+}
+
+// Close closes both connections
+func (c *dataComparison) Close() {
+	c.sourceDB.Close()
+	c.targetDB.Close()
+}
+
+// readBoth reads the rows of the table from source and target in parallel
+func (c *dataComparison) readBoth(opts tableReadOptions) (map[string]map[string]interface{}, map[string]map[string]interface{}, error) {
+	var sourceData map[string]map[string]interface{}
+	var sourceErr error
+	opts.where = c.filtered(opts.where)
+	opts.fetchSize = c.options.FetchSize
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sourceOpts := opts
+		sourceOpts.bitColumns = c.sourceBits
+		sourceOpts.readExpressions = c.options.SourceReadExpressions
+		sourceData, sourceErr = getTableData(c.sourceDB, c.sourceType, c.tableName, c.columns, c.primaryKeys, sourceOpts)
+	}()
+
+	targetOpts := opts
+	targetOpts.bitColumns = c.targetBits
+	targetOpts.readExpressions = c.options.TargetReadExpressions
+	targetData, err := getTableData(c.targetDB, c.targetType, c.tableName, c.columns, c.primaryKeys, targetOpts)
+	<-done
+
+	if sourceErr != nil {
+		return nil, nil, fmt.Errorf("failed to get source data: %v", sourceErr)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get target data: %v", err)
+	}
+	return sourceData, targetData, nil
+}
+
+// diff pairs rows by primary key and generates the statements that make the target match the source
+func (c *dataComparison) diff(sourceData, targetData map[string]map[string]interface{}) []DataDiffResult {
+	var results []DataDiffResult
 
 	// Find inserts and updates
 	for pkKey, sourceRow := range sourceData {
 		if targetRow, exists := targetData[pkKey]; exists {
-			// Check for updates
-			if !rowsEqual(sourceRow, targetRow) {
-				pk := extractPrimaryKey(sourceRow, primaryKeys)
-				results = append(results, DataDiffResult{
-					Type:       "update",
-					TableName:  tableName,
-					PrimaryKey: pk,
-					OldValues:  targetRow,
-					NewValues:  sourceRow,
-					SQL:        generateUpdateSQL(targetType, tableName, sourceRow, primaryKeys),
-				})
+			if update := c.updateDiff(sourceRow, targetRow); update != nil {
+				results = append(results, *update)
 			}
 		} else {
-			// Insert
-			pk := extractPrimaryKey(sourceRow, primaryKeys)
-			results = append(results, DataDiffResult{
-				Type:       "insert",
-				TableName:  tableName,
-				PrimaryKey: pk,
-				NewValues:  sourceRow,
-				SQL:        generateInsertSQL(targetType, tableName, sourceRow, columns),
-			})
+			results = append(results, c.insertDiff(sourceRow))
 		}
 	}
 
 	// Find deletes
 	for pkKey, targetRow := range targetData {
 		if _, exists := sourceData[pkKey]; !exists {
-			pk := extractPrimaryKey(targetRow, primaryKeys)
-			results = append(results, DataDiffResult{
-				Type:       "delete",
-				TableName:  tableName,
-				PrimaryKey: pk,
-				OldValues:  targetRow,
-				SQL:        generateDeleteSQL(targetType, tableName, primaryKeys, pk),
-			})
+			results = append(results, c.deleteDiff(targetRow))
 		}
 	}
 
-	return results, nil
+	return results
+}
+
+// updateDiff returns the UPDATE that makes targetRow match sourceRow, or nil if they are equal
+func (c *dataComparison) updateDiff(sourceRow, targetRow map[string]interface{}) *DataDiffResult {
+	updateRow := sourceRow
+	if len(c.options.MatchColumns) > 0 {
+		// Rows paired by business key keep their own surrogate keys on each side:
+		// compare and write everything but the key, and address the target's row
+		updateRow = make(map[string]interface{}, len(sourceRow))
+		for col, val := range sourceRow {
+			updateRow[col] = val
+		}
+		for _, key := range c.primaryKeys {
+			updateRow[key] = targetRow[key]
+		}
+	}
+
+	if rowsEqual(updateRow, targetRow, c.kinds) {
+		return nil
+	}
+	query, args := updateStatement(c.targetType, c.tableName, updateRow, c.primaryKeys)
+	return &DataDiffResult{
+		Type:       "update",
+		TableName:  c.tableName,
+		PrimaryKey: extractPrimaryKey(updateRow, c.primaryKeys),
+		OldValues:  targetRow,
+		NewValues:  sourceRow,
+		SQL:        generateUpdateSQL(c.targetType, c.tableName, updateRow, c.primaryKeys),
+		Query:      query,
+		Args:       args,
+		Warning:    checkEnumValues(sourceRow, c.targetEnums),
+	}
+}
+
+// insertDiff returns the INSERT of a source row missing from the target
+func (c *dataComparison) insertDiff(sourceRow map[string]interface{}) DataDiffResult {
+	insertColumns := c.columns
+	if len(c.options.MatchColumns) > 0 {
+		// Surrogate keys are left to the target's auto-increment/default
+		insertColumns = nil
+		for _, col := range c.columns {
+			if !containsString(c.primaryKeys, col) || containsString(c.options.MatchColumns, col) {
+				insertColumns = append(insertColumns, col)
+			}
+		}
+	}
+	query, args := insertStatement(c.targetType, c.tableName, sourceRow, insertColumns)
+	return DataDiffResult{
+		Type:       "insert",
+		TableName:  c.tableName,
+		PrimaryKey: extractPrimaryKey(sourceRow, c.primaryKeys),
+		NewValues:  sourceRow,
+		SQL:        generateInsertSQL(c.targetType, c.tableName, sourceRow, insertColumns),
+		Query:      query,
+		Args:       args,
+		Warning:    checkEnumValues(sourceRow, c.targetEnums),
+	}
+}
+
+// deleteDiff returns the DELETE of a target row missing from the source
+func (c *dataComparison) deleteDiff(targetRow map[string]interface{}) DataDiffResult {
+	pk := extractPrimaryKey(targetRow, c.primaryKeys)
+	query, args := deleteStatement(c.targetType, c.tableName, c.primaryKeys, pk)
+	return DataDiffResult{
+		Type:       "delete",
+		TableName:  c.tableName,
+		PrimaryKey: pk,
+		OldValues:  targetRow,
+		SQL:        generateDeleteSQL(c.targetType, c.tableName, c.primaryKeys, pk),
+		Query:      query,
+		Args:       args,
+	}
 }
 
 // GetDataSyncSummary returns a summary of data differences for a table
 func GetDataSyncSummary(sourceConfig, targetConfig ConnectionConfig, tableName string) (*TableDataInfo, error) {
-	diffs, err := CompareTableData(sourceConfig, targetConfig, tableName)
+	return GetDataSyncSummaryContext(context.Background(), sourceConfig, targetConfig, tableName)
+}
+
+// GetDataSyncSummaryContext is GetDataSyncSummary, aborting when ctx is done
+func GetDataSyncSummaryContext(ctx context.Context, sourceConfig, targetConfig ConnectionConfig, tableName string) (*TableDataInfo, error) {
+	diffs, err := CompareTableDataContext(ctx, sourceConfig, targetConfig, tableName, DataCompareOptions{})
 	if err != nil {
 		return nil, err
 	}
 
-	sourceDB, err := Connect(sourceConfig)
+	sourceDB, err := ConnectContext(ctx, sourceConfig)
 	if err != nil {
 		return nil, err
 	}
 	defer sourceDB.Close()
 
-	targetDB, err := Connect(targetConfig)
+	targetDB, err := ConnectContext(ctx, targetConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -270,8 +598,8 @@ func GetDataSyncSummary(sourceConfig, targetConfig ConnectionConfig, tableName s
 	info.Columns, _ = getColumns(sourceDB, sourceType, sourceConfig.Database, tableName)
 
 	// Get counts
-	sourceDB.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", quoteIdentifier(sourceType, tableName))).Scan(&info.SourceCount)
-	targetDB.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", quoteIdentifier(targetType, tableName))).Scan(&info.TargetCount)
+	sourceDB.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", quoteIdentifier(sourceType, tableName))).Scan(&info.SourceCount)
+	targetDB.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", quoteIdentifier(targetType, tableName))).Scan(&info.TargetCount)
 
 	for _, diff := range diffs {
 		switch diff.Type {
@@ -425,21 +753,91 @@ func getColumns(db *sql.DB, dbType DBType, database, tableName string) ([]string
 	return cols, nil
 }
 
-func getTableData(db *sql.DB, dbType DBType, tableName string, columns, primaryKeys []string) (map[string]map[string]interface{}, error) {
+// tableReadOptions narrows and shapes the rows read by getTableData
+type tableReadOptions struct {
+	bitColumns      map[string]bool         // columns normalized with normalizeBitValue
+	readExpressions map[string]string       // SELECT expressions replacing plain columns
+	where           string                  // optional SQL condition, without the WHERE keyword
+	args            []interface{}           // placeholder values of where
+	orderBy         []string                // columns to sort by
+	limit           int                     // maximum number of rows, 0 for all
+	fetchSize       int                     // rows fetched per round trip, 0 to let the driver decide
+	keep            func(pkKey string) bool // optional client-side filter on the primary key
+}
+
+func getTableData(db *sql.DB, dbType DBType, tableName string, columns, primaryKeys []string, opts tableReadOptions) (map[string]map[string]interface{}, error) {
+	data := make(map[string]map[string]interface{})
+	err := forEachTableRow(db, dbType, tableName, columns, opts, func(row map[string]interface{}) {
+		pkKey := rowKey(row, primaryKeys)
+		if opts.keep != nil && !opts.keep(pkKey) {
+			return
+		}
+		data[pkKey] = row
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// rowKey encodes the key values of a row into a map key. Each value is length-prefixed,
+// so values containing the separator, like ("a|b", "c") and ("a", "b|c"), can't collide.
+func rowKey(row map[string]interface{}, keyColumns []string) string {
+	var key strings.Builder
+	for _, col := range keyColumns {
+		if row[col] == nil {
+			key.WriteString("-;")
+			continue
+		}
+		value := fmt.Sprintf("%v", row[col])
+		fmt.Fprintf(&key, "%d:%s;", len(value), value)
+	}
+	return key.String()
+}
+
+// forEachTableRow reads the table and calls fn with each row, normalized for comparison
+func forEachTableRow(db *sql.DB, dbType DBType, tableName string, columns []string, opts tableReadOptions, fn func(row map[string]interface{})) error {
 	quotedCols := make([]string, len(columns))
 	for i, col := range columns {
 		quotedCols[i] = quoteIdentifier(dbType, col)
+		if expr, ok := opts.readExpressions[col]; ok && expr != "" {
+			quotedCols[i] = fmt.Sprintf("%s AS %s", expr, quoteIdentifier(dbType, col))
+		}
 	}
 
 	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(quotedCols, ", "), quoteIdentifier(dbType, tableName))
-	rows, err := db.Query(query)
+	if dbType == SQLServer && opts.limit > 0 {
+		query = fmt.Sprintf("SELECT TOP (%d) %s FROM %s", opts.limit, strings.Join(quotedCols, ", "), quoteIdentifier(dbType, tableName))
+	}
+	if opts.where != "" {
+		query += " WHERE " + opts.where
+	}
+	if len(opts.orderBy) > 0 {
+		orderParts := make([]string, len(opts.orderBy))
+		for i, col := range opts.orderBy {
+			orderParts[i] = quoteIdentifier(dbType, col)
+		}
+		query += " ORDER BY " + strings.Join(orderParts, ", ")
+	}
+	if dbType != SQLServer && opts.limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", opts.limit)
+	}
+	if opts.fetchSize > 0 && dbType == PostgreSQL {
+		return forEachCursorRow(db, dbType, query, columns, opts, fn)
+	}
+	rows, err := db.Query(query, opts.args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	data := make(map[string]map[string]interface{})
+	_, err = scanComparisonRows(rows, dbType, columns, opts, fn)
+	return err
+}
 
+// scanComparisonRows calls fn with each row of rows and returns how many there were
+func scanComparisonRows(rows *sql.Rows, dbType DBType, columns []string, opts tableReadOptions, fn func(row map[string]interface{})) (int, error) {
+	count := 0
 	for rows.Next() {
 		values := make([]interface{}, len(columns))
 		valuePtrs := make([]interface{}, len(columns))
@@ -448,37 +846,153 @@ func getTableData(db *sql.DB, dbType DBType, tableName string, columns, primaryK
 		}
 
 		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, err
+			return count, err
 		}
 
 		row := make(map[string]interface{})
-		var pkParts []string
 		for i, col := range columns {
 			val := values[i]
-			if b, ok := val.([]byte); ok {
+			if _, converted := opts.readExpressions[col]; opts.bitColumns[col] && !converted {
+				row[col] = normalizeBitValue(dbType, val)
+			} else if b, ok := val.([]byte); ok {
 				row[col] = string(b)
 			} else {
 				row[col] = val
 			}
 		}
+		fn(row)
+		count++
+	}
+
+	return count, rows.Err()
+}
 
-		// Build primary key string
-		for _, pk := range primaryKeys {
-			pkParts = append(pkParts, fmt.Sprintf("%v", row[pk]))
+// getBitColumns returns the BIT and boolean columns of a table
+func getBitColumns(db *sql.DB, dbType DBType, database, tableName string) (map[string]bool, error) {
+	var query string
+	var args []interface{}
+
+	switch dbType {
+	case MySQL, "":
+		query = `
+			SELECT COLUMN_NAME
+			FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND DATA_TYPE = 'bit'`
+		args = []interface{}{database, tableName}
+	case PostgreSQL:
+		query = `
+			SELECT column_name
+			FROM information_schema.columns
+			WHERE table_schema = 'public' AND table_name = $1
+			AND data_type IN ('bit', 'bit varying', 'boolean')`
+		args = []interface{}{tableName}
+	case SQLite:
+		// SQLite only has declared type names, match the common boolean-ish ones
+		rows, err := db.Query(fmt.Sprintf("PRAGMA table_info('%s')", tableName))
+		if err != nil {
+			return nil, err
 		}
-		pkKey := strings.Join(pkParts, "|")
-		data[pkKey] = row
+		defer rows.Close()
+
+		bits := make(map[string]bool)
+		for rows.Next() {
+			var cid int
+			var name, colType string
+			var notNull, pk int
+			var dfltValue interface{}
+			if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+				return nil, err
+			}
+			upper := strings.ToUpper(colType)
+			if strings.HasPrefix(upper, "BIT") || strings.HasPrefix(upper, "BOOL") {
+				bits[name] = true
+			}
+		}
+		return bits, nil
+	case SQLServer:
+		query = `
+			SELECT COLUMN_NAME
+			FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_NAME = @p1 AND DATA_TYPE = 'bit'`
+		args = []interface{}{tableName}
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", dbType)
 	}
 
-	return data, nil
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bits := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		bits[name] = true
+	}
+	return bits, nil
 }
 
-func rowsEqual(a, b map[string]interface{}) bool {
+// bitValue is the canonical form of a BIT/boolean column value, so that the
+// same bit reads back identically from every driver
+type bitValue int64
+
+// normalizeBitValue converts a BIT/boolean value to a canonical bitValue (0/1 for single bits).
+// MySQL returns BIT as big-endian bytes, PostgreSQL as a '0'/'1' string, SQL Server as bool.
+func normalizeBitValue(dbType DBType, val interface{}) interface{} {
+	var raw []byte
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case bool:
+		if v {
+			return bitValue(1)
+		}
+		return bitValue(0)
+	case int64:
+		return bitValue(v)
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return val
+	}
+
+	var n int64
+	if dbType == PostgreSQL || dbType == SQLite {
+		s := strings.TrimSpace(string(raw))
+		switch strings.ToLower(s) {
+		case "t", "true":
+			return bitValue(1)
+		case "f", "false":
+			return bitValue(0)
+		}
+		for _, c := range s {
+			if c != '0' && c != '1' {
+				return s
+			}
+			n = n<<1 | int64(c-'0')
+		}
+		return bitValue(n)
+	}
+
+	for _, b := range raw {
+		n = n<<8 | int64(b)
+	}
+	return bitValue(n)
+}
+
+// rowsEqual compares two rows column by column, by the kind of each column
+func rowsEqual(a, b map[string]interface{}, kinds map[string]valueKind) bool {
 	if len(a) != len(b) {
 		return false
 	}
 	for k, v := range a {
-		if fmt.Sprintf("%v", v) != fmt.Sprintf("%v", b[k]) {
+		if !valuesEqual(kinds[k], v, b[k]) {
 			return false
 		}
 	}
@@ -493,14 +1007,42 @@ func extractPrimaryKey(row map[string]interface{}, primaryKeys []string) map[str
 	return pk
 }
 
+// sqlValues renders the values of a generated statement, either inline as escaped
+// literals for display and export or as bind placeholders collected into args
+type sqlValues struct {
+	dbType DBType
+	bind   bool
+	args   []interface{}
+}
+
+func (v *sqlValues) render(val interface{}) string {
+	if !v.bind {
+		return escapeValueFor(v.dbType, val)
+	}
+	v.args = append(v.args, bindValue(v.dbType, val))
+	return placeholder(v.dbType, len(v.args))
+}
+
 func generateInsertSQL(dbType DBType, tableName string, row map[string]interface{}, columns []string) string {
+	return buildInsertSQL(&sqlValues{dbType: dbType}, tableName, row, columns)
+}
+
+// insertStatement is generateInsertSQL with bind placeholders and their ordered arguments
+func insertStatement(dbType DBType, tableName string, row map[string]interface{}, columns []string) (string, []interface{}) {
+	values := &sqlValues{dbType: dbType, bind: true}
+	query := buildInsertSQL(values, tableName, row, columns)
+	return query, values.args
+}
+
+func buildInsertSQL(values *sqlValues, tableName string, row map[string]interface{}, columns []string) string {
+	dbType := values.dbType
 	var cols []string
 	var vals []string
 
 	for _, col := range columns {
 		if val, ok := row[col]; ok {
 			cols = append(cols, quoteIdentifier(dbType, col))
-			vals = append(vals, escapeValue(val))
+			vals = append(vals, values.render(val))
 		}
 	}
 
@@ -511,24 +1053,35 @@ func generateInsertSQL(dbType DBType, tableName string, row map[string]interface
 }
 
 func generateUpdateSQL(dbType DBType, tableName string, row map[string]interface{}, primaryKeys []string) string {
+	return buildUpdateSQL(&sqlValues{dbType: dbType}, tableName, row, primaryKeys)
+}
+
+// updateStatement is generateUpdateSQL with bind placeholders and their ordered arguments
+func updateStatement(dbType DBType, tableName string, row map[string]interface{}, primaryKeys []string) (string, []interface{}) {
+	values := &sqlValues{dbType: dbType, bind: true}
+	query := buildUpdateSQL(values, tableName, row, primaryKeys)
+	return query, values.args
+}
+
+func buildUpdateSQL(values *sqlValues, tableName string, row map[string]interface{}, primaryKeys []string) string {
+	dbType := values.dbType
 	var sets []string
 	var wheres []string
 
-	for col, val := range row {
-		isPK := false
-		for _, pk := range primaryKeys {
-			if col == pk {
-				isPK = true
-				break
-			}
-		}
-		if !isPK {
-			sets = append(sets, fmt.Sprintf("%s = %s", quoteIdentifier(dbType, col), escapeValue(val)))
+	// Sorted so the placeholders and the display form list columns in the same order
+	cols := make([]string, 0, len(row))
+	for col := range row {
+		if !containsString(primaryKeys, col) {
+			cols = append(cols, col)
 		}
 	}
+	sort.Strings(cols)
+	for _, col := range cols {
+		sets = append(sets, fmt.Sprintf("%s = %s", quoteIdentifier(dbType, col), values.render(row[col])))
+	}
 
 	for _, pk := range primaryKeys {
-		wheres = append(wheres, fmt.Sprintf("%s = %s", quoteIdentifier(dbType, pk), escapeValue(row[pk])))
+		wheres = append(wheres, fmt.Sprintf("%s = %s", quoteIdentifier(dbType, pk), values.render(row[pk])))
 	}
 
 	return fmt.Sprintf("UPDATE %s SET %s WHERE %s;",
@@ -538,13 +1091,51 @@ func generateUpdateSQL(dbType DBType, tableName string, row map[string]interface
 }
 
 func generateDeleteSQL(dbType DBType, tableName string, primaryKeys []string, pk map[string]interface{}) string {
+	return buildDeleteSQL(&sqlValues{dbType: dbType}, tableName, primaryKeys, pk)
+}
+
+// deleteStatement is generateDeleteSQL with bind placeholders and their ordered arguments
+func deleteStatement(dbType DBType, tableName string, primaryKeys []string, pk map[string]interface{}) (string, []interface{}) {
+	values := &sqlValues{dbType: dbType, bind: true}
+	query := buildDeleteSQL(values, tableName, primaryKeys, pk)
+	return query, values.args
+}
+
+func buildDeleteSQL(values *sqlValues, tableName string, primaryKeys []string, pk map[string]interface{}) string {
+	dbType := values.dbType
 	var wheres []string
 	for _, key := range primaryKeys {
-		wheres = append(wheres, fmt.Sprintf("%s = %s", quoteIdentifier(dbType, key), escapeValue(pk[key])))
+		wheres = append(wheres, fmt.Sprintf("%s = %s", quoteIdentifier(dbType, key), values.render(pk[key])))
 	}
 	return fmt.Sprintf("DELETE FROM %s WHERE %s;", quoteIdentifier(dbType, tableName), strings.Join(wheres, " AND "))
 }
 
+// bindValue converts a value read by forEachTableRow into a driver argument.
+// Bit values bind as integers, except on PostgreSQL where bit and boolean
+// columns take a bit string.
+func bindValue(dbType DBType, val interface{}) interface{} {
+	if b, ok := val.(bitValue); ok {
+		if dbType == PostgreSQL {
+			return strconv.FormatInt(int64(b), 2)
+		}
+		return int64(b)
+	}
+	return val
+}
+
+// escapeValueFor renders a literal for the target database type. Bit values
+// are written as 0/1 except on PostgreSQL, where bit and boolean columns only
+// accept a quoted bit string.
+func escapeValueFor(dbType DBType, val interface{}) string {
+	if b, ok := val.(bitValue); ok {
+		if dbType == PostgreSQL {
+			return fmt.Sprintf("'%s'", strconv.FormatInt(int64(b), 2))
+		}
+		return strconv.FormatInt(int64(b), 10)
+	}
+	return escapeValue(val)
+}
+
 func escapeValue(val interface{}) string {
 	if val == nil {
 		return "NULL"
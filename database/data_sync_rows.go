@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// syncRowsBatchSize caps the keys looked up per query; SQL Server accepts at most
+// 2100 parameters per statement
+const syncRowsBatchSize = 500
+
+// SyncRowsByPK brings only the rows with the given primary keys in line with the
+// source: rows missing from the target are inserted, differing ones updated and
+// ones gone from the source deleted. Other rows aren't read or touched.
+func SyncRowsByPK(sourceConfig, targetConfig ConnectionConfig, tableName string, pks []map[string]interface{}) (SyncReport, error) {
+	return SyncRowsByPKContext(context.Background(), sourceConfig, targetConfig, tableName, pks)
+}
+
+// SyncRowsByPKContext is SyncRowsByPK, aborting when ctx is done
+func SyncRowsByPKContext(ctx context.Context, sourceConfig, targetConfig ConnectionConfig, tableName string, pks []map[string]interface{}) (SyncReport, error) {
+	diffs, err := CompareRowsByPKContext(ctx, sourceConfig, targetConfig, tableName, pks)
+	if err != nil || len(diffs) == 0 {
+		return SyncReport{}, err
+	}
+	return ApplyDataSyncContext(ctx, targetConfig, diffs, SyncOptions{SyncInsert: true, SyncUpdate: true, SyncDelete: true})
+}
+
+// CompareRowsByPK compares only the rows with the given primary keys, returning
+// the diffs SyncRowsByPK applies
+func CompareRowsByPK(sourceConfig, targetConfig ConnectionConfig, tableName string, pks []map[string]interface{}) ([]DataDiffResult, error) {
+	return CompareRowsByPKContext(context.Background(), sourceConfig, targetConfig, tableName, pks)
+}
+
+// CompareRowsByPKContext is CompareRowsByPK, aborting when ctx is done
+func CompareRowsByPKContext(ctx context.Context, sourceConfig, targetConfig ConnectionConfig, tableName string, pks []map[string]interface{}) ([]DataDiffResult, error) {
+	if len(pks) == 0 {
+		return nil, nil
+	}
+
+	cmp, err := openDataComparison(ctx, sourceConfig, targetConfig, tableName, DataCompareOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer cmp.Close()
+
+	for _, pk := range pks {
+		if err := checkKeyValues(cmp.primaryKeys, pk, tableName); err != nil {
+			return nil, err
+		}
+	}
+	return cmp.compareKeys(pks)
+}
+
+// compareKeys diffs the rows with the given primary keys, reading them in batches
+func (c *dataComparison) compareKeys(pks []map[string]interface{}) ([]DataDiffResult, error) {
+	batchSize := syncRowsBatchSize
+	if perKey := 2000 / len(c.primaryKeys); perKey < batchSize {
+		batchSize = perKey
+	}
+
+	var diffs []DataDiffResult
+	for start := 0; start < len(pks); start += batchSize {
+		end := start + batchSize
+		if end > len(pks) {
+			end = len(pks)
+		}
+		batch := pks[start:end]
+
+		sourceWhere, sourceArgs := keyListCondition(c.sourceType, c.primaryKeys, batch)
+		sourceData, err := getTableData(c.sourceDB, c.sourceType, c.tableName, c.columns, c.primaryKeys, tableReadOptions{
+			bitColumns:      c.sourceBits,
+			readExpressions: c.options.SourceReadExpressions,
+			where:           sourceWhere,
+			args:            sourceArgs,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get source data: %v", err)
+		}
+
+		targetWhere, targetArgs := keyListCondition(c.targetType, c.primaryKeys, batch)
+		targetData, err := getTableData(c.targetDB, c.targetType, c.tableName, c.columns, c.primaryKeys, tableReadOptions{
+			bitColumns:      c.targetBits,
+			readExpressions: c.options.TargetReadExpressions,
+			where:           targetWhere,
+			args:            targetArgs,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get target data: %v", err)
+		}
+
+		diffs = append(diffs, c.diff(sourceData, targetData)...)
+	}
+	return diffs, nil
+}
+
+// keyListCondition matches the rows with any of the given primary keys:
+// (a = ? AND b = ?) OR (a = ? AND b = ?) ...
+func keyListCondition(dbType DBType, primaryKeys []string, pks []map[string]interface{}) (string, []interface{}) {
+	var args []interface{}
+	alternatives := make([]string, len(pks))
+	for i, pk := range pks {
+		conds := make([]string, len(primaryKeys))
+		for j, col := range primaryKeys {
+			args = append(args, pk[col])
+			conds[j] = fmt.Sprintf("%s = %s", quoteIdentifier(dbType, col), placeholder(dbType, len(args)))
+		}
+		alternatives[i] = "(" + strings.Join(conds, " AND ") + ")"
+	}
+	return strings.Join(alternatives, " OR "), args
+}
@@ -0,0 +1,27 @@
+package database
+
+import "testing"
+
+func TestRowKey(t *testing.T) {
+	keys := []string{"a", "b"}
+	tests := []struct {
+		name       string
+		row1, row2 map[string]interface{}
+		same       bool
+	}{
+		{"separator in values", map[string]interface{}{"a": "a|b", "b": "c"}, map[string]interface{}{"a": "a", "b": "b|c"}, false},
+		{"semicolon in values", map[string]interface{}{"a": "1;", "b": "2"}, map[string]interface{}{"a": "1", "b": ";2"}, false},
+		{"null vs text", map[string]interface{}{"a": nil, "b": "x"}, map[string]interface{}{"a": "<nil>", "b": "x"}, false},
+		{"null vs empty", map[string]interface{}{"a": nil, "b": "x"}, map[string]interface{}{"a": "", "b": "x"}, false},
+		{"equal values", map[string]interface{}{"a": int64(1), "b": "x"}, map[string]interface{}{"a": int64(1), "b": "x"}, true},
+		{"ignores other columns", map[string]interface{}{"a": 1, "b": 2, "c": 3}, map[string]interface{}{"a": 1, "b": 2, "c": 4}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k1, k2 := rowKey(tt.row1, keys), rowKey(tt.row2, keys)
+			if (k1 == k2) != tt.same {
+				t.Errorf("rowKey = %q and %q, same = %v, want %v", k1, k2, k1 == k2, tt.same)
+			}
+		})
+	}
+}
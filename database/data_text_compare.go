@@ -0,0 +1,118 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// ColumnCompareOptions loosens how the text values of a column compare
+type ColumnCompareOptions struct {
+	// TrimTrailingSpace ignores trailing spaces, which CHAR columns pad values with
+	TrimTrailingSpace bool `json:"trimTrailingSpace,omitempty"`
+	// IgnoreCase compares values case-insensitively, as case-insensitive collations do
+	IgnoreCase bool `json:"ignoreCase,omitempty"`
+}
+
+// kind adds the option's flags to a column's value kind
+func (o ColumnCompareOptions) kind(base valueKind) valueKind {
+	kind := base &^ (valueTrimRight | valueFoldCase)
+	if o.TrimTrailingSpace {
+		kind |= valueTrimRight
+	}
+	if o.IgnoreCase {
+		kind |= valueFoldCase
+	}
+	return kind
+}
+
+// applyColumnOptions adds the per-column text options to c.kinds: those detected
+// from either side's column metadata when asked to, overridden by explicit ones
+func (c *dataComparison) applyColumnOptions(sourceDatabase, targetDatabase string) error {
+	options := make(map[string]ColumnCompareOptions)
+	if c.options.DetectColumnOptions {
+		sourceTraits, err := detectColumnCompareOptions(c.sourceDB, c.sourceType, sourceDatabase, c.tableName)
+		if err != nil {
+			return err
+		}
+		targetTraits, err := detectColumnCompareOptions(c.targetDB, c.targetType, targetDatabase, c.tableName)
+		if err != nil {
+			return err
+		}
+		for _, col := range c.columns {
+			s, t := sourceTraits[col], targetTraits[col]
+			options[col] = ColumnCompareOptions{
+				TrimTrailingSpace: s.TrimTrailingSpace || t.TrimTrailingSpace,
+				IgnoreCase:        s.IgnoreCase || t.IgnoreCase,
+			}
+		}
+	}
+	for col, opts := range c.options.ColumnOptions {
+		options[col] = opts
+	}
+
+	for col, opts := range options {
+		c.kinds[col] = opts.kind(c.kinds[col])
+	}
+	return nil
+}
+
+// detectColumnCompareOptions trims fixed-length character columns and ignores the
+// case of columns with a case-insensitive collation. SQLite reports no collations.
+func detectColumnCompareOptions(db *sql.DB, dbType DBType, database, tableName string) (map[string]ColumnCompareOptions, error) {
+	types, err := getColumnDataTypes(db, dbType, database, tableName)
+	if err != nil {
+		return nil, err
+	}
+	options := make(map[string]ColumnCompareOptions, len(types))
+	for col, dataType := range types {
+		options[col] = ColumnCompareOptions{TrimTrailingSpace: isFixedCharType(dataType)}
+	}
+
+	var rows *sql.Rows
+	switch dbType {
+	case MySQL, "":
+		rows, err = db.Query(`
+			SELECT COLUMN_NAME, COLLATION_NAME
+			FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND COLLATION_NAME IS NOT NULL`, database, tableName)
+	case SQLServer:
+		rows, err = db.Query(`
+			SELECT COLUMN_NAME, COLLATION_NAME
+			FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_NAME = @p1 AND COLLATION_NAME IS NOT NULL`, tableName)
+	default:
+		// PostgreSQL collations are case-sensitive unless nondeterministic
+		return options, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var col, collation string
+		if err := rows.Scan(&col, &collation); err != nil {
+			return nil, err
+		}
+		opts := options[col]
+		opts.IgnoreCase = isCaseInsensitiveCollation(collation)
+		options[col] = opts
+	}
+	return options, rows.Err()
+}
+
+// isFixedCharType reports whether a data type pads values to its length
+func isFixedCharType(dataType string) bool {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(dataType)), "(")
+	switch strings.TrimSpace(base) {
+	case "char", "nchar", "character", "bpchar", "national character":
+		return true
+	}
+	return false
+}
+
+// isCaseInsensitiveCollation recognizes MySQL's ..._ci and SQL Server's ..._CI_...
+func isCaseInsensitiveCollation(collation string) bool {
+	c := strings.ToLower(collation)
+	return strings.HasSuffix(c, "_ci") || strings.Contains(c, "_ci_")
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// valueKind tells how values of a column are normalized before rows are compared:
+// a base kind, plus flags that loosen how text compares
+type valueKind int
+
+const (
+	valueText valueKind = iota
+	valueNumeric
+	valueTemporal
+)
+
+const (
+	valueTrimRight valueKind = 1 << (iota + 4) // ignore trailing spaces, as CHAR pads them
+	valueFoldCase                              // ignore case, as case-insensitive collations do
+
+	valueBaseMask valueKind = valueTrimRight - 1
+)
+
+var temporalTypePattern = regexp.MustCompile(`^(date|datetime2?|smalldatetime|datetimeoffset|timestamp)\b`)
+
+// kindOfType classifies a column by its data type name
+func kindOfType(dataType string) valueKind {
+	t := strings.ToLower(strings.TrimSpace(dataType))
+	switch {
+	case numericTypePattern.MatchString(t):
+		return valueNumeric
+	case temporalTypePattern.MatchString(t):
+		return valueTemporal
+	default:
+		return valueText
+	}
+}
+
+// temporalLayouts are the text forms drivers return dates and timestamps in when
+// they don't parse them into time.Time; values without a zone are taken as UTC
+var temporalLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// comparableValue returns the form a non-nil value is compared in: numbers by
+// exact value, so 10.00, 10.0 and 10 match, dates and timestamps by instant, and
+// everything else, []byte included, as text, loosened by the kind's text flags
+func comparableValue(kind valueKind, val interface{}) string {
+	if b, ok := val.([]byte); ok {
+		val = string(b)
+	}
+
+	switch kind & valueBaseMask {
+	case valueNumeric:
+		if r, ok := new(big.Rat).SetString(strings.TrimSpace(fmt.Sprintf("%v", val))); ok {
+			return r.RatString()
+		}
+	case valueTemporal:
+		switch v := val.(type) {
+		case time.Time:
+			return v.UTC().Format(time.RFC3339Nano)
+		case string:
+			s := strings.TrimSpace(v)
+			for _, layout := range temporalLayouts {
+				if t, err := time.Parse(layout, s); err == nil {
+					return t.UTC().Format(time.RFC3339Nano)
+				}
+			}
+		}
+	case valueText:
+		s := fmt.Sprintf("%v", val)
+		if kind&valueTrimRight != 0 {
+			s = strings.TrimRight(s, " ")
+		}
+		if kind&valueFoldCase != 0 {
+			s = strings.ToLower(s)
+		}
+		return s
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// valuesEqual compares two column values of the given kind; NULL only equals NULL
+func valuesEqual(kind valueKind, a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return comparableValue(kind, a) == comparableValue(kind, b)
+}
+
+// getColumnKinds classifies the columns of a table by data type
+func getColumnKinds(db *sql.DB, dbType DBType, database, tableName string) (map[string]valueKind, error) {
+	types, err := getColumnDataTypes(db, dbType, database, tableName)
+	if err != nil {
+		return nil, err
+	}
+	kinds := make(map[string]valueKind, len(types))
+	for name, dataType := range types {
+		kinds[name] = kindOfType(dataType)
+	}
+	return kinds, nil
+}
+
+// getColumnDataTypes returns the data type name of each column of a table
+func getColumnDataTypes(db *sql.DB, dbType DBType, database, tableName string) (map[string]string, error) {
+	var query string
+	var args []interface{}
+
+	switch dbType {
+	case MySQL, "":
+		query = `
+			SELECT COLUMN_NAME, DATA_TYPE
+			FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?`
+		args = []interface{}{database, tableName}
+	case PostgreSQL:
+		query = `
+			SELECT column_name, data_type
+			FROM information_schema.columns
+			WHERE table_schema = 'public' AND table_name = $1`
+		args = []interface{}{tableName}
+	case SQLite:
+		query = fmt.Sprintf("SELECT name, type FROM pragma_table_info('%s')", tableName)
+	case SQLServer:
+		query = `
+			SELECT COLUMN_NAME, DATA_TYPE
+			FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_NAME = @p1`
+		args = []interface{}{tableName}
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", dbType)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := make(map[string]string)
+	for rows.Next() {
+		var name, dataType string
+		if err := rows.Scan(&name, &dataType); err != nil {
+			return nil, err
+		}
+		types[name] = dataType
+	}
+	return types, rows.Err()
+}
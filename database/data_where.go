@@ -0,0 +1,80 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateWhereClause checks a user-supplied row filter. The clause is raw SQL
+// inserted verbatim after WHERE, so it must only come from the user running the
+// comparison; this only rejects the obvious ways to run another statement or to
+// comment out the rest of the query. Those tokens are allowed inside quoted
+// literals, e.g. note LIKE '%--%'.
+func ValidateWhereClause(where string) error {
+	// A backslash escapes a quote in MySQL literals but not in standard SQL ones,
+	// so the clause has to be safe read either way
+	for _, backslashEscapes := range []bool{false, true} {
+		if err := checkWhereTokens(where, backslashEscapes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkWhereTokens scans a WHERE clause for statement separators and comment
+// starters outside single- or double-quoted text
+func checkWhereTokens(where string, backslashEscapes bool) error {
+	var quote byte
+	for i := 0; i < len(where); i++ {
+		c := where[i]
+		if quote != 0 {
+			switch {
+			case backslashEscapes && c == '\\':
+				i++
+			case c == quote && i+1 < len(where) && where[i+1] == quote:
+				// A doubled quote stands for itself
+				i++
+			case c == quote:
+				quote = 0
+			}
+			continue
+		}
+		if c == '\'' || c == '"' {
+			quote = c
+			continue
+		}
+		for _, token := range []string{";", "--", "/*", "#"} {
+			if strings.HasPrefix(where[i:], token) {
+				return fmt.Errorf("WHERE clause must not contain %q outside a quoted literal", token)
+			}
+		}
+	}
+	if quote != 0 {
+		return fmt.Errorf("WHERE clause has an unterminated %c quote", quote)
+	}
+	return nil
+}
+
+// andWhere joins the non-empty conditions with AND, parenthesizing each
+func andWhere(conds ...string) string {
+	var parts []string
+	for _, cond := range conds {
+		if strings.TrimSpace(cond) != "" {
+			parts = append(parts, "("+cond+")")
+		}
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// whereSQL returns " WHERE cond", or nothing for an empty condition
+func whereSQL(cond string) string {
+	if strings.TrimSpace(cond) == "" {
+		return ""
+	}
+	return " WHERE " + cond
+}
+
+// filtered adds the comparison's WhereClause to a read condition
+func (c *dataComparison) filtered(cond string) string {
+	return andWhere(c.options.WhereClause, cond)
+}
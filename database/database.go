@@ -3,10 +3,13 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
+	"unicode"
 
 	_ "github.com/denisenkom/go-mssqldb"
 	_ "github.com/go-sql-driver/mysql"
@@ -31,63 +34,181 @@ type ConnectionConfig struct {
 	Port     int    `json:"port"`
 	User     string `json:"user"`
 	Password string `json:"password"`
-	Database string `json:"database"`
+	// PasswordRef points to the password in a credential provider, e.g.
+	// "env:PROD_DB_PASSWORD" or "keychain:syncforge/prod"; it is resolved when
+	// connecting and takes the place of Password, which is then never saved
+	PasswordRef string `json:"passwordRef,omitempty"`
+	Database    string `json:"database"`
 	// SQLite specific
 	FilePath string `json:"filePath,omitempty"`
+	// TLS: SSLMode is disable, prefer, require, verify-ca or verify-full; empty keeps
+	// each driver's previous default. Certificate paths are checked before connecting.
+	SSLMode     string `json:"sslMode,omitempty"`
+	SSLRootCert string `json:"sslRootCert,omitempty"`
+	SSLCert     string `json:"sslCert,omitempty"`
+	SSLKey      string `json:"sslKey,omitempty"`
+	// TrustServerCertificate encrypts without verifying the server certificate (self-signed certs)
+	TrustServerCertificate bool `json:"trustServerCertificate,omitempty"`
+	// MaxConcurrentQueries caps the queries running at once against this database
+	// as this user, counting open result sets and transactions; 0 uses the pool's
+	// MaxOpenConns. Further queries wait for a free slot.
+	MaxConcurrentQueries int `json:"maxConcurrentQueries,omitempty"`
+	// ConnectTimeout bounds the initial ping in seconds, 0 uses DefaultConnectTimeout
+	ConnectTimeout int `json:"connectTimeout,omitempty"`
+	// Pool tuning, 0 uses DefaultMaxOpenConns, DefaultMaxIdleConns and DefaultConnMaxLifetime.
+	// SQLite always uses a single connection to avoid "database is locked" errors.
+	MaxOpenConns           int `json:"maxOpenConns,omitempty"`
+	MaxIdleConns           int `json:"maxIdleConns,omitempty"`
+	ConnMaxLifetimeSeconds int `json:"connMaxLifetimeSeconds,omitempty"`
+	// SSHTunnel reaches the server through a bastion host; Host and Port are then
+	// resolved from the bastion
+	SSHTunnel *SSHTunnelConfig `json:"sshTunnel,omitempty"`
+	// SchemaFetchConcurrency is how many tables are read at once when loading a
+	// schema, 0 uses the number of CPUs. SQLite always reads one table at a time.
+	SchemaFetchConcurrency int `json:"schemaFetchConcurrency,omitempty"`
+	// TableFilter limits GetSchema to the matching tables; the others aren't read at all
+	TableFilter *TableFilter `json:"tableFilter,omitempty"`
+}
+
+// DefaultConnectTimeout is how long Connect waits for the server to answer
+const DefaultConnectTimeout = 10 * time.Second
+
+// Connection pool defaults used when the config leaves a setting at 0
+const (
+	DefaultMaxOpenConns    = 10
+	DefaultMaxIdleConns    = 5
+	DefaultConnMaxLifetime = 300 * time.Second
+)
+
+// applyPoolSettings sizes the pool from the config
+func applyPoolSettings(db *sql.DB, config ConnectionConfig) {
+	maxOpen, maxIdle, lifetime := config.MaxOpenConns, config.MaxIdleConns, DefaultConnMaxLifetime
+	if maxOpen <= 0 {
+		maxOpen = DefaultMaxOpenConns
+	}
+	if maxIdle <= 0 {
+		maxIdle = DefaultMaxIdleConns
+	}
+	if config.ConnMaxLifetimeSeconds > 0 {
+		lifetime = time.Duration(config.ConnMaxLifetimeSeconds) * time.Second
+	}
+	if config.Type == SQLite {
+		maxOpen, maxIdle = 1, 1
+	}
+	db.SetMaxOpenConns(maxOpen)
+	db.SetMaxIdleConns(maxIdle)
+	db.SetConnMaxLifetime(lifetime)
+}
+
+// connectTimeout returns the ping deadline for the config
+func (c ConnectionConfig) connectTimeout() time.Duration {
+	if c.ConnectTimeout > 0 {
+		return time.Duration(c.ConnectTimeout) * time.Second
+	}
+	return DefaultConnectTimeout
 }
 
 // TableInfo holds table structure information
 type TableInfo struct {
-	Name      string       `json:"name"`
-	CreateSQL string       `json:"createSql"`
-	Columns   []ColumnInfo `json:"columns"`
-	Indexes   []IndexInfo  `json:"indexes"`
+	Name      string        `json:"name"`
+	CreateSQL string        `json:"createSql"`
+	Columns   []ColumnInfo  `json:"columns"`
+	Indexes   []IndexInfo   `json:"indexes"`
+	Temporal  *TemporalInfo `json:"temporal,omitempty"` // set for system-versioned tables
+
+	RowFormat   string `json:"rowFormat,omitempty"`   // MySQL ROW_FORMAT, e.g. DYNAMIC or COMPRESSED
+	Compression string `json:"compression,omitempty"` // SQL Server data compression: NONE, ROW or PAGE
+
+	PrimaryKey  *PrimaryKeyInfo  `json:"primaryKey,omitempty"`
+	ForeignKeys []ForeignKeyInfo `json:"foreignKeys,omitempty"`
+	Triggers    []TriggerInfo    `json:"triggers,omitempty"`
 }
 
 // ColumnInfo holds column details
 type ColumnInfo struct {
-	Name     string  `json:"name"`
-	Type     string  `json:"type"`
-	Nullable string  `json:"nullable"`
-	Key      string  `json:"key"`
-	Default  *string `json:"default"`
-	Extra    string  `json:"extra"`
-	Position int     `json:"position"`
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	Nullable  string  `json:"nullable"`
+	Key       string  `json:"key"`
+	Default   *string `json:"default"`
+	Extra     string  `json:"extra"`
+	Position  int     `json:"position"`
+	Invisible bool    `json:"invisible"` // MySQL 8 INVISIBLE column
+	SRID      *int    `json:"srid"`      // spatial reference system of geometry columns
+	Collation string  `json:"collation,omitempty"`
+	OnUpdate  string  `json:"onUpdate,omitempty"` // MySQL ON UPDATE value, e.g. CURRENT_TIMESTAMP
+	Computed  string  `json:"computed,omitempty"` // SQL Server computed column expression
+	Persisted bool    `json:"persisted,omitempty"`
+	// Identity holds the options of PostgreSQL and SQL Server identity columns
+	Identity *IdentityInfo `json:"identity,omitempty"`
 }
 
 // IndexInfo holds index details
 type IndexInfo struct {
-	Name      string `json:"name"`
-	NonUnique int    `json:"nonUnique"`
-	Column    string `json:"column"`
-	SeqInIdx  int    `json:"seqInIndex"`
+	Name       string `json:"name"`
+	NonUnique  int    `json:"nonUnique"`
+	Column     string `json:"column"`
+	SeqInIdx   int    `json:"seqInIndex"`
+	Invisible  bool   `json:"invisible"`            // MySQL 8 INVISIBLE index
+	Expression string `json:"expression,omitempty"` // key expression of functional/expression indexes
+	// Definition is the whole CREATE INDEX statement where the dialect reports it
+	// (PostgreSQL), carrying the method, predicate and included columns too
+	Definition string `json:"definition,omitempty"`
 }
 
 // SchemaInfo holds complete database schema
 type SchemaInfo struct {
 	Database string               `json:"database"`
+	DBType   DBType               `json:"dbType,omitempty"` // dialect the schema was read from
 	Tables   map[string]TableInfo `json:"tables"`
+	Enums    map[string]EnumInfo  `json:"enums,omitempty"` // PostgreSQL enum types
+
+	Views             map[string]ViewInfo             `json:"views,omitempty"`
+	Routines          map[string]RoutineInfo          `json:"routines,omitempty"`          // none for SQLite
+	MaterializedViews map[string]MaterializedViewInfo `json:"materializedViews,omitempty"` // PostgreSQL only
+	Sequences         map[string]SequenceInfo         `json:"sequences,omitempty"`         // PostgreSQL only
 }
 
 // DiffResult holds comparison result
 type DiffResult struct {
-	Type      string `json:"type"` // "added", "removed", "modified"
-	TableName string `json:"tableName"`
-	Detail    string `json:"detail"`
-	SQL       string `json:"sql"`
+	Type       string `json:"type"` // "added", "removed", "modified"
+	TableName  string `json:"tableName"`
+	Detail     string `json:"detail"`
+	SQL        string `json:"sql"`
+	ObjectType string `json:"objectType,omitempty"` // "enum", "sequence" etc., empty for tables
+	// TypeChange describes a column whose type changes; SampleTypeChanges fills in
+	// how the target's existing values would convert
+	TypeChange *ColumnTypeChange `json:"typeChange,omitempty"`
 }
 
 // buildDSN builds the connection string for the given database type
 func buildDSN(config ConnectionConfig) (string, string, error) {
+	mode, err := sslMode(config)
+	if err != nil {
+		return "", "", err
+	}
+	if err := validateSSLFiles(config); err != nil {
+		return "", "", err
+	}
+
 	switch config.Type {
 	case MySQL, "":
-		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&multiStatements=true",
+		// clientFoundRows makes UPDATE report the rows it matched, as the other
+		// databases do, rather than only those whose values changed
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&multiStatements=true&clientFoundRows=true",
 			config.User, config.Password, config.Host, config.Port, config.Database)
+		tlsParam, err := mysqlTLSParam(config, mode)
+		if err != nil {
+			return "", "", err
+		}
+		if tlsParam != "" {
+			dsn += "&tls=" + tlsParam
+		}
 		return "mysql", dsn, nil
 
 	case PostgreSQL:
-		dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-			config.Host, config.Port, config.User, config.Password, config.Database)
+		dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s %s",
+			config.Host, config.Port, config.User, config.Password, config.Database, postgresSSLParams(config, mode))
 		return "postgres", dsn, nil
 
 	case SQLite:
@@ -97,8 +218,8 @@ func buildDSN(config ConnectionConfig) (string, string, error) {
 		return "sqlite3", config.FilePath, nil
 
 	case SQLServer:
-		dsn := fmt.Sprintf("server=%s;port=%d;user id=%s;password=%s;database=%s",
-			config.Host, config.Port, config.User, config.Password, config.Database)
+		dsn := fmt.Sprintf("server=%s;port=%d;user id=%s;password=%s;database=%s%s",
+			config.Host, config.Port, config.User, config.Password, config.Database, sqlServerSSLParams(config, mode))
 		return "sqlserver", dsn, nil
 
 	default:
@@ -108,6 +229,19 @@ func buildDSN(config ConnectionConfig) (string, string, error) {
 
 // Connect creates a database connection
 func Connect(config ConnectionConfig) (*sql.DB, error) {
+	return ConnectContext(context.Background(), config)
+}
+
+// ConnectContext creates a database connection, giving up when ctx is done before
+// the server answers. ctx also bounds the pool: once it is done, every query is
+// cancelled, including those issued without a context of their own, and dropped
+// connections are no longer replaced.
+func ConnectContext(ctx context.Context, config ConnectionConfig) (*sql.DB, error) {
+	config, err := resolveCredentials(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
 	driver, dsn, err := buildDSN(config)
 	if err != nil {
 		return nil, err
@@ -118,19 +252,60 @@ func Connect(config ConnectionConfig) (*sql.DB, error) {
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	drv := db.Driver()
+	db.Close()
 
-	if err := db.PingContext(ctx); err != nil {
-		return nil, err
+	var tunnel *sshTunnel
+	if config.SSHTunnel != nil {
+		tunnelCtx, cancel := context.WithTimeout(ctx, config.connectTimeout())
+		tunnel, err = openSSHTunnel(tunnelCtx, config.SSHTunnel)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return db, nil
+	// Reopen through a connector that shares the database's concurrency limit
+	connector, err := newLimitedConnector(ctx, drv, dsn, config, tunnel)
+	if err != nil {
+		if tunnel != nil {
+			tunnel.Close()
+		}
+		return nil, err
+	}
+	db = sql.OpenDB(connector)
+	applyPoolSettings(db, config)
+
+	_, hasDeadline := ctx.Deadline()
+	start := time.Now()
+	for {
+		pingCtx, cancel := context.WithTimeout(ctx, config.connectTimeout())
+		err = db.PingContext(pingCtx)
+		cancel()
+		// Queued behind the database's concurrency limit; the timeout is for the server,
+		// keep waiting until ctx is done or, without a deadline, slotWaitTimeout passed
+		if errors.Is(err, errNoFreeSlot) && ctx.Err() == nil && (hasDeadline || time.Since(start) < slotWaitTimeout) {
+			continue
+		}
+		if err != nil {
+			db.Close()
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		return db, nil
+	}
 }
 
 // TestConnection tests if the connection works
 func TestConnection(config ConnectionConfig) error {
-	db, err := Connect(config)
+	return TestConnectionContext(context.Background(), config)
+}
+
+// TestConnectionContext tests the connection, giving up when ctx is done
+func TestConnectionContext(ctx context.Context, config ConnectionConfig) error {
+	db, err := ConnectContext(ctx, config)
 	if err != nil {
 		return err
 	}
@@ -140,67 +315,40 @@ func TestConnection(config ConnectionConfig) error {
 
 // GetDatabases returns list of databases
 func GetDatabases(config ConnectionConfig) ([]string, error) {
+	return GetDatabasesContext(context.Background(), config)
+}
+
+// GetDatabasesContext is GetDatabases, aborting when ctx is done
+func GetDatabasesContext(ctx context.Context, config ConnectionConfig) ([]string, error) {
+	var query string
+	var skip map[string]bool
+	cfg := config
 	switch config.Type {
 	case MySQL, "":
-		return getMySQLDatabases(config)
+		cfg.Database = ""
+		query = "SHOW DATABASES"
+		// SHOW DATABASES lists the system schemas too
+		skip = map[string]bool{"information_schema": true, "mysql": true, "performance_schema": true, "sys": true}
 	case PostgreSQL:
-		return getPostgreSQLDatabases(config)
+		cfg.Database = "postgres"
+		query = "SELECT datname FROM pg_database WHERE datistemplate = false AND datname NOT IN ('postgres')"
 	case SQLite:
 		// SQLite doesn't have multiple databases
 		return []string{"main"}, nil
 	case SQLServer:
-		return getSQLServerDatabases(config)
+		cfg.Database = "master"
+		query = "SELECT name FROM sys.databases WHERE name NOT IN ('master', 'tempdb', 'model', 'msdb')"
 	default:
 		return nil, fmt.Errorf("unsupported database type: %s", config.Type)
 	}
-}
-
-func getMySQLDatabases(config ConnectionConfig) ([]string, error) {
-	cfg := config
-	cfg.Database = ""
-
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/",
-		cfg.User, cfg.Password, cfg.Host, cfg.Port)
-
-	db, err := sql.Open("mysql", dsn)
-	if err != nil {
-		return nil, err
-	}
-	defer db.Close()
-
-	rows, err := db.Query("SHOW DATABASES")
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var databases []string
-	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
-			return nil, err
-		}
-		// Skip system databases
-		if name != "information_schema" && name != "mysql" &&
-			name != "performance_schema" && name != "sys" {
-			databases = append(databases, name)
-		}
-	}
-
-	return databases, nil
-}
-
-func getPostgreSQLDatabases(config ConnectionConfig) ([]string, error) {
-	cfg := config
-	cfg.Database = "postgres"
 
-	db, err := Connect(cfg)
+	db, err := ConnectContext(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
 	defer db.Close()
 
-	rows, err := db.Query("SELECT datname FROM pg_database WHERE datistemplate = false AND datname NOT IN ('postgres')")
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -212,58 +360,47 @@ func getPostgreSQLDatabases(config ConnectionConfig) ([]string, error) {
 		if err := rows.Scan(&name); err != nil {
 			return nil, err
 		}
-		databases = append(databases, name)
-	}
-
-	return databases, nil
-}
-
-func getSQLServerDatabases(config ConnectionConfig) ([]string, error) {
-	cfg := config
-	cfg.Database = "master"
-
-	db, err := Connect(cfg)
-	if err != nil {
-		return nil, err
-	}
-	defer db.Close()
-
-	rows, err := db.Query("SELECT name FROM sys.databases WHERE name NOT IN ('master', 'tempdb', 'model', 'msdb')")
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var databases []string
-	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
-			return nil, err
+		if skip[name] {
+			continue
 		}
 		databases = append(databases, name)
 	}
-
-	return databases, nil
+	return databases, rows.Err()
 }
 
 // GetSchema retrieves complete schema information
 func GetSchema(config ConnectionConfig) (*SchemaInfo, error) {
+	return GetSchemaContext(context.Background(), config)
+}
+
+// GetSchemaContext retrieves complete schema information, aborting when ctx is done
+func GetSchemaContext(ctx context.Context, config ConnectionConfig) (*SchemaInfo, error) {
+	var schema *SchemaInfo
+	var err error
 	switch config.Type {
 	case MySQL, "":
-		return getMySQLSchema(config)
+		schema, err = getMySQLSchema(ctx, config)
 	case PostgreSQL:
-		return getPostgreSQLSchema(config)
+		schema, err = getPostgreSQLSchema(ctx, config)
 	case SQLite:
-		return getSQLiteSchema(config)
+		schema, err = getSQLiteSchema(ctx, config)
 	case SQLServer:
-		return getSQLServerSchema(config)
+		schema, err = getSQLServerSchema(ctx, config)
 	default:
 		return nil, fmt.Errorf("unsupported database type: %s", config.Type)
 	}
+	if err != nil {
+		return nil, err
+	}
+	schema.DBType = config.Type
+	if schema.DBType == "" {
+		schema.DBType = MySQL
+	}
+	return schema, nil
 }
 
-func getMySQLSchema(config ConnectionConfig) (*SchemaInfo, error) {
-	db, err := Connect(config)
+func getMySQLSchema(ctx context.Context, config ConnectionConfig) (*SchemaInfo, error) {
+	db, err := ConnectContext(ctx, config)
 	if err != nil {
 		return nil, err
 	}
@@ -274,7 +411,8 @@ func getMySQLSchema(config ConnectionConfig) (*SchemaInfo, error) {
 		Tables:   make(map[string]TableInfo),
 	}
 
-	rows, err := db.Query("SHOW TABLES")
+	// Views are listed by SHOW TABLES too but read separately
+	rows, err := db.Query("SHOW FULL TABLES WHERE Table_type = 'BASE TABLE'")
 	if err != nil {
 		return nil, err
 	}
@@ -282,19 +420,31 @@ func getMySQLSchema(config ConnectionConfig) (*SchemaInfo, error) {
 
 	var tableNames []string
 	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
+		var name, tableType string
+		if err := rows.Scan(&name, &tableType); err != nil {
 			return nil, err
 		}
 		tableNames = append(tableNames, name)
 	}
 
-	for _, tableName := range tableNames {
-		tableInfo, err := getMySQLTableInfo(db, tableName)
-		if err != nil {
-			return nil, err
-		}
-		schema.Tables[tableName] = *tableInfo
+	tableNames, err = config.TableFilter.Apply(tableNames)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.Tables, err = fetchTableInfos(ctx, db, tableNames, config.schemaFetchConcurrency(), getMySQLTableInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.Views, err = getMySQLViews(db)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.Routines, err = getMySQLRoutines(db)
+	if err != nil {
+		return nil, err
 	}
 
 	return schema, nil
@@ -311,9 +461,14 @@ func getMySQLTableInfo(db *sql.DB, tableName string) (*TableInfo, error) {
 		return nil, err
 	}
 	info.CreateSQL = createSQL
+	info.Temporal = getMariaDBTemporal(db, tableName)
+	info.RowFormat, err = getMySQLRowFormat(db, tableName)
+	if err != nil {
+		return nil, err
+	}
 
 	colRows, err := db.Query(`
-		SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_KEY, COLUMN_DEFAULT, EXTRA, ORDINAL_POSITION
+		SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_KEY, COLUMN_DEFAULT, EXTRA, ORDINAL_POSITION, COALESCE(COLLATION_NAME, '')
 		FROM INFORMATION_SCHEMA.COLUMNS
 		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
 		ORDER BY ORDINAL_POSITION`, tableName)
@@ -324,12 +479,38 @@ func getMySQLTableInfo(db *sql.DB, tableName string) (*TableInfo, error) {
 
 	for colRows.Next() {
 		var col ColumnInfo
-		if err := colRows.Scan(&col.Name, &col.Type, &col.Nullable, &col.Key, &col.Default, &col.Extra, &col.Position); err != nil {
+		if err := colRows.Scan(&col.Name, &col.Type, &col.Nullable, &col.Key, &col.Default, &col.Extra, &col.Position, &col.Collation); err != nil {
 			return nil, err
 		}
+		// MySQL reports column invisibility in EXTRA; keep it as a separate attribute
+		col.Extra, col.Invisible = stripExtraToken(col.Extra, "INVISIBLE")
+		// ON UPDATE belongs right after DEFAULT; DEFAULT_GENERATED is informational only
+		col.Extra, col.OnUpdate = splitOnUpdate(col.Extra)
+		col.Extra, _ = stripExtraToken(col.Extra, "DEFAULT_GENERATED")
 		info.Columns = append(info.Columns, col)
 	}
 
+	// SRS_ID only exists on MySQL 8.0+, so a failing query just means no SRID metadata
+	sridRows, err := db.Query(`
+		SELECT COLUMN_NAME, SRS_ID
+		FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND SRS_ID IS NOT NULL`, tableName)
+	if err == nil {
+		defer sridRows.Close()
+		for sridRows.Next() {
+			var colName string
+			var srid int
+			if err := sridRows.Scan(&colName, &srid); err != nil {
+				return nil, err
+			}
+			for i := range info.Columns {
+				if info.Columns[i].Name == colName {
+					info.Columns[i].SRID = &srid
+				}
+			}
+		}
+	}
+
 	idxRows, err := db.Query(fmt.Sprintf("SHOW INDEX FROM `%s`", tableName))
 	if err != nil {
 		return nil, err
@@ -370,16 +551,41 @@ func getMySQLTableInfo(db *sql.DB, tableName string) (*TableInfo, error) {
 				if v, ok := val.(int64); ok {
 					idx.SeqInIdx = int(v)
 				}
+			case "Expression":
+				// Functional key parts (MySQL 8.0.13+) have no Column_name
+				if v, ok := val.([]byte); ok {
+					idx.Expression = string(v)
+				}
+			case "Visible":
+				// Only present on MySQL 8.0+
+				if v, ok := val.([]byte); ok {
+					idx.Invisible = strings.EqualFold(string(v), "NO")
+				}
 			}
 		}
 		info.Indexes = append(info.Indexes, idx)
 	}
 
+	info.PrimaryKey, err = getMySQLPrimaryKey(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	info.ForeignKeys, err = getMySQLForeignKeys(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	info.Triggers, err = getMySQLTriggers(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
 	return info, nil
 }
 
-func getPostgreSQLSchema(config ConnectionConfig) (*SchemaInfo, error) {
-	db, err := Connect(config)
+func getPostgreSQLSchema(ctx context.Context, config ConnectionConfig) (*SchemaInfo, error) {
+	db, err := ConnectContext(ctx, config)
 	if err != nil {
 		return nil, err
 	}
@@ -407,12 +613,39 @@ func getPostgreSQLSchema(config ConnectionConfig) (*SchemaInfo, error) {
 		tableNames = append(tableNames, name)
 	}
 
-	for _, tableName := range tableNames {
-		tableInfo, err := getPostgreSQLTableInfo(db, tableName)
-		if err != nil {
-			return nil, err
-		}
-		schema.Tables[tableName] = *tableInfo
+	tableNames, err = config.TableFilter.Apply(tableNames)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.Tables, err = fetchTableInfos(ctx, db, tableNames, config.schemaFetchConcurrency(), getPostgreSQLTableInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.Enums, err = getPostgreSQLEnums(db)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.MaterializedViews, err = getPostgreSQLMaterializedViews(db)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.Sequences, err = getPostgreSQLSequences(db)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.Views, err = getPostgreSQLViews(db)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.Routines, err = getPostgreSQLRoutines(db)
+	if err != nil {
+		return nil, err
 	}
 
 	return schema, nil
@@ -423,9 +656,15 @@ func getPostgreSQLTableInfo(db *sql.DB, tableName string) (*TableInfo, error) {
 		Name: tableName,
 	}
 
+	spatial, err := getPostGISColumns(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
 	// PostgreSQL doesn't have SHOW CREATE TABLE, we need to build it
 	colRows, err := db.Query(`
-		SELECT column_name, data_type, is_nullable, column_default, ordinal_position
+		SELECT column_name, data_type, udt_name, is_nullable, column_default, ordinal_position, COALESCE(collation_name, ''),
+			is_identity, identity_generation, identity_start, identity_increment
 		FROM information_schema.columns
 		WHERE table_schema = 'public' AND table_name = $1
 		ORDER BY ordinal_position`, tableName)
@@ -437,54 +676,133 @@ func getPostgreSQLTableInfo(db *sql.DB, tableName string) (*TableInfo, error) {
 	var createParts []string
 	for colRows.Next() {
 		var col ColumnInfo
-		var colDefault sql.NullString
-		if err := colRows.Scan(&col.Name, &col.Type, &col.Nullable, &colDefault, &col.Position); err != nil {
+		var udtName string
+		var colDefault, isIdentity, generation, start, increment sql.NullString
+		if err := colRows.Scan(&col.Name, &col.Type, &udtName, &col.Nullable, &colDefault, &col.Position, &col.Collation,
+			&isIdentity, &generation, &start, &increment); err != nil {
 			return nil, err
 		}
 		if colDefault.Valid {
 			col.Default = &colDefault.String
 		}
+		col.Identity = postgreSQLIdentity(isIdentity, generation, start, increment)
+		if col.Type == "USER-DEFINED" {
+			// Enums and other custom types are only identifiable by their type name
+			col.Type = udtName
+		}
+		if g, ok := spatial[col.Name]; ok {
+			// PostGIS carries the SRID in the type modifier
+			srid := g.srid
+			col.Type = fmt.Sprintf("geometry(%s,%d)", g.geomType, srid)
+			col.SRID = &srid
+		}
 		info.Columns = append(info.Columns, col)
 
 		// Build column definition
 		colDef := fmt.Sprintf("%s %s", col.Name, col.Type)
+		if col.Collation != "" {
+			colDef += collateClause(PostgreSQL, col.Collation)
+		}
 		if col.Nullable == "NO" {
 			colDef += " NOT NULL"
 		}
 		if col.Default != nil {
 			colDef += fmt.Sprintf(" DEFAULT %s", *col.Default)
 		}
+		if col.Identity != nil {
+			colDef += " " + col.Identity.definition()
+		}
 		createParts = append(createParts, colDef)
 	}
 
 	info.CreateSQL = fmt.Sprintf("CREATE TABLE %s (\n  %s\n);", tableName, strings.Join(createParts, ",\n  "))
 
-	// Get indexes
+	// Get indexes, one row per key column; attnum 0 marks an expression
 	idxRows, err := db.Query(`
-		SELECT indexname, indexdef
-		FROM pg_indexes
-		WHERE schemaname = 'public' AND tablename = $1`, tableName)
+		SELECT c.relname, pg_get_indexdef(x.indexrelid), x.indisunique, k.ord,
+			COALESCE(a.attname, ''), CASE WHEN k.attnum = 0 THEN pg_get_indexdef(x.indexrelid, k.ord::int, true) ELSE '' END
+		FROM pg_index x
+		JOIN pg_class c ON c.oid = x.indexrelid
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		CROSS JOIN LATERAL unnest(x.indkey::int2[]) WITH ORDINALITY AS k(attnum, ord)
+		LEFT JOIN pg_attribute a ON a.attrelid = x.indrelid AND a.attnum = k.attnum AND k.attnum > 0
+		WHERE n.nspname = 'public' AND t.relname = $1 AND k.ord <= x.indnkeyatts
+		ORDER BY c.relname, k.ord`, tableName)
 	if err != nil {
 		return nil, err
 	}
 	defer idxRows.Close()
 
 	for idxRows.Next() {
-		var idxName, idxDef string
-		if err := idxRows.Scan(&idxName, &idxDef); err != nil {
+		var idx IndexInfo
+		var unique bool
+		if err := idxRows.Scan(&idx.Name, &idx.Definition, &unique, &idx.SeqInIdx, &idx.Column, &idx.Expression); err != nil {
 			return nil, err
 		}
-		info.Indexes = append(info.Indexes, IndexInfo{
-			Name:   idxName,
-			Column: idxDef,
-		})
+		if !unique {
+			idx.NonUnique = 1
+		}
+		info.Indexes = append(info.Indexes, idx)
+	}
+
+	info.PrimaryKey, err = getPostgreSQLPrimaryKey(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	info.ForeignKeys, err = getPostgreSQLForeignKeys(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	info.Triggers, err = getPostgreSQLTriggers(db, tableName)
+	if err != nil {
+		return nil, err
 	}
 
 	return info, nil
 }
 
-func getSQLiteSchema(config ConnectionConfig) (*SchemaInfo, error) {
-	db, err := Connect(config)
+type postGISColumn struct {
+	geomType string
+	srid     int
+}
+
+// getPostGISColumns returns geometry column metadata, or nothing when PostGIS isn't installed
+func getPostGISColumns(db *sql.DB, tableName string) (map[string]postGISColumn, error) {
+	result := make(map[string]postGISColumn)
+
+	var hasPostGIS bool
+	if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'postgis')").Scan(&hasPostGIS); err != nil {
+		return nil, err
+	}
+	if !hasPostGIS {
+		return result, nil
+	}
+
+	rows, err := db.Query(`
+		SELECT f_geometry_column, type, srid
+		FROM geometry_columns
+		WHERE f_table_schema = 'public' AND f_table_name = $1`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var g postGISColumn
+		if err := rows.Scan(&name, &g.geomType, &g.srid); err != nil {
+			return nil, err
+		}
+		result[name] = g
+	}
+	return result, nil
+}
+
+func getSQLiteSchema(ctx context.Context, config ConnectionConfig) (*SchemaInfo, error) {
+	db, err := ConnectContext(ctx, config)
 	if err != nil {
 		return nil, err
 	}
@@ -510,12 +828,19 @@ func getSQLiteSchema(config ConnectionConfig) (*SchemaInfo, error) {
 		tableNames = append(tableNames, name)
 	}
 
-	for _, tableName := range tableNames {
-		tableInfo, err := getSQLiteTableInfo(db, tableName)
-		if err != nil {
-			return nil, err
-		}
-		schema.Tables[tableName] = *tableInfo
+	tableNames, err = config.TableFilter.Apply(tableNames)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.Tables, err = fetchTableInfos(ctx, db, tableNames, config.schemaFetchConcurrency(), getSQLiteTableInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.Views, err = getSQLiteViews(db)
+	if err != nil {
+		return nil, err
 	}
 
 	return schema, nil
@@ -564,7 +889,7 @@ func getSQLiteTableInfo(db *sql.DB, tableName string) (*TableInfo, error) {
 		if dfltValue.Valid {
 			col.Default = &dfltValue.String
 		}
-		if pk == 1 {
+		if pk > 0 {
 			col.Key = "PRI"
 		}
 		info.Columns = append(info.Columns, col)
@@ -591,26 +916,41 @@ func getSQLiteTableInfo(db *sql.DB, tableName string) (*TableInfo, error) {
 		})
 	}
 
-	return info, nil
-}
-
-func getSQLServerSchema(config ConnectionConfig) (*SchemaInfo, error) {
-	db, err := Connect(config)
+	info.PrimaryKey, err = getSQLitePrimaryKey(db, tableName)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
-	schema := &SchemaInfo{
-		Database: config.Database,
-		Tables:   make(map[string]TableInfo),
+	info.ForeignKeys, err = getSQLiteForeignKeys(db, tableName)
+	if err != nil {
+		return nil, err
 	}
 
-	rows, err := db.Query("SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_TYPE = 'BASE TABLE'")
+	info.Triggers, err = getSQLiteTriggers(db, tableName)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+
+	return info, nil
+}
+
+func getSQLServerSchema(ctx context.Context, config ConnectionConfig) (*SchemaInfo, error) {
+	db, err := ConnectContext(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema := &SchemaInfo{
+		Database: config.Database,
+		Tables:   make(map[string]TableInfo),
+	}
+
+	rows, err := db.Query("SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_TYPE = 'BASE TABLE'")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
 	var tableNames []string
 	for rows.Next() {
@@ -621,12 +961,27 @@ func getSQLServerSchema(config ConnectionConfig) (*SchemaInfo, error) {
 		tableNames = append(tableNames, name)
 	}
 
-	for _, tableName := range tableNames {
-		tableInfo, err := getSQLServerTableInfo(db, tableName)
-		if err != nil {
-			return nil, err
-		}
-		schema.Tables[tableName] = *tableInfo
+	// History tables of temporal tables are compared as part of their parent table
+	tableNames = filterTableNames(tableNames, getSQLServerHistoryTables(db))
+
+	tableNames, err = config.TableFilter.Apply(tableNames)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.Tables, err = fetchTableInfos(ctx, db, tableNames, config.schemaFetchConcurrency(), getSQLServerTableInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.Views, err = getSQLServerViews(db)
+	if err != nil {
+		return nil, err
+	}
+
+	schema.Routines, err = getSQLServerRoutines(db)
+	if err != nil {
+		return nil, err
 	}
 
 	return schema, nil
@@ -634,15 +989,26 @@ func getSQLServerSchema(config ConnectionConfig) (*SchemaInfo, error) {
 
 func getSQLServerTableInfo(db *sql.DB, tableName string) (*TableInfo, error) {
 	info := &TableInfo{
-		Name: tableName,
+		Name:     tableName,
+		Temporal: getSQLServerTemporal(db, tableName),
+	}
+
+	compression, err := getSQLServerCompression(db, tableName)
+	if err != nil {
+		return nil, err
 	}
+	info.Compression = compression
 
 	// Get columns
 	colRows, err := db.Query(`
-		SELECT COLUMN_NAME, DATA_TYPE, IS_NULLABLE, COLUMN_DEFAULT, ORDINAL_POSITION
-		FROM INFORMATION_SCHEMA.COLUMNS
-		WHERE TABLE_NAME = @p1
-		ORDER BY ORDINAL_POSITION`, tableName)
+		SELECT c.COLUMN_NAME, c.DATA_TYPE, c.IS_NULLABLE, c.COLUMN_DEFAULT, c.ORDINAL_POSITION, COALESCE(c.COLLATION_NAME, ''),
+			COALESCE(cc.definition, ''), COALESCE(cc.is_persisted, 0),
+			CAST(ic.seed_value AS bigint), CAST(ic.increment_value AS bigint)
+		FROM INFORMATION_SCHEMA.COLUMNS c
+		LEFT JOIN sys.computed_columns cc ON cc.object_id = OBJECT_ID(@p1) AND cc.name = c.COLUMN_NAME
+		LEFT JOIN sys.identity_columns ic ON ic.object_id = OBJECT_ID(@p1) AND ic.name = c.COLUMN_NAME
+		WHERE c.TABLE_NAME = @p1
+		ORDER BY c.ORDINAL_POSITION`, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -652,15 +1018,38 @@ func getSQLServerTableInfo(db *sql.DB, tableName string) (*TableInfo, error) {
 	for colRows.Next() {
 		var col ColumnInfo
 		var colDefault sql.NullString
-		if err := colRows.Scan(&col.Name, &col.Type, &col.Nullable, &colDefault, &col.Position); err != nil {
+		var seed, increment sql.NullInt64
+		if err := colRows.Scan(&col.Name, &col.Type, &col.Nullable, &colDefault, &col.Position, &col.Collation, &col.Computed, &col.Persisted,
+			&seed, &increment); err != nil {
 			return nil, err
 		}
 		if colDefault.Valid {
 			col.Default = &colDefault.String
 		}
+		if seed.Valid {
+			col.Identity = &IdentityInfo{Start: seed.Int64, Increment: increment.Int64}
+		}
+		if t := info.Temporal; t != nil {
+			// Period columns are maintained by the server, not written by users
+			if col.Name == t.PeriodStart {
+				col.Extra = "GENERATED ALWAYS AS ROW START"
+			} else if col.Name == t.PeriodEnd {
+				col.Extra = "GENERATED ALWAYS AS ROW END"
+			}
+		}
 		info.Columns = append(info.Columns, col)
 
+		if col.Computed != "" {
+			createParts = append(createParts, fmt.Sprintf("[%s] %s", col.Name, buildColumnDef(SQLServer, col)))
+			continue
+		}
 		colDef := fmt.Sprintf("[%s] %s", col.Name, col.Type)
+		if col.Identity != nil {
+			colDef += " " + col.Identity.definition()
+		}
+		if col.Extra != "" {
+			colDef += " " + col.Extra
+		}
 		if col.Nullable == "NO" {
 			colDef += " NOT NULL"
 		}
@@ -670,7 +1059,13 @@ func getSQLServerTableInfo(db *sql.DB, tableName string) (*TableInfo, error) {
 		createParts = append(createParts, colDef)
 	}
 
-	info.CreateSQL = fmt.Sprintf("CREATE TABLE [%s] (\n  %s\n);", tableName, strings.Join(createParts, ",\n  "))
+	if t := info.Temporal; t != nil {
+		createParts = append(createParts, fmt.Sprintf("PERIOD FOR SYSTEM_TIME ([%s], [%s])", t.PeriodStart, t.PeriodEnd))
+		info.CreateSQL = fmt.Sprintf("CREATE TABLE [%s] (\n  %s\n) WITH (SYSTEM_VERSIONING = ON (HISTORY_TABLE = %s));",
+			tableName, strings.Join(createParts, ",\n  "), quoteSQLServerQualified(t.HistoryTable))
+	} else {
+		info.CreateSQL = fmt.Sprintf("CREATE TABLE [%s] (\n  %s\n);", tableName, strings.Join(createParts, ",\n  "))
+	}
 
 	// Get indexes
 	idxRows, err := db.Query(`
@@ -701,12 +1096,139 @@ func getSQLServerTableInfo(db *sql.DB, tableName string) (*TableInfo, error) {
 		})
 	}
 
+	info.PrimaryKey, err = getSQLServerPrimaryKey(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	info.ForeignKeys, err = getSQLServerForeignKeys(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	info.Triggers, err = getSQLServerTriggers(db, tableName)
+	if err != nil {
+		return nil, err
+	}
+
 	return info, nil
 }
 
+// IdentifierCase is a case-folding policy for identifiers in generated SQL
+type IdentifierCase string
+
+const (
+	IdentifierCasePreserve IdentifierCase = "preserve"
+	IdentifierCaseLower    IdentifierCase = "lower"
+	IdentifierCaseUpper    IdentifierCase = "upper"
+)
+
+// Apply folds an identifier according to the policy; an empty policy preserves case
+func (c IdentifierCase) Apply(name string) string {
+	switch c {
+	case IdentifierCaseLower:
+		return strings.ToLower(name)
+	case IdentifierCaseUpper:
+		return strings.ToUpper(name)
+	default:
+		return name
+	}
+}
+
+// CompareOptions tunes how schemas are compared and how the migration SQL is generated
+type CompareOptions struct {
+	// CombineAlters merges all column and index changes of a table into a single
+	// ALTER TABLE statement where the dialect supports it, so the table is rebuilt once
+	CombineAlters bool `json:"combineAlters"`
+	// IdentifierCase folds table, column and index names in generated ALTER/DROP
+	// statements, e.g. "lower" so a MySQL `Orders` matches a PostgreSQL orders.
+	// CREATE TABLE statements are copied from the source as-is.
+	IdentifierCase IdentifierCase `json:"identifierCase"`
+	// UniqueKeyAnyOrder compares the columns of unique keys as a set, since
+	// uniqueness doesn't depend on column order. Other indexes still compare in order.
+	UniqueKeyAnyOrder bool `json:"uniqueKeyAnyOrder"`
+	// Dialect is the database type the generated SQL runs on. Empty uses the
+	// target schema's type, and MySQL when that is unknown too.
+	Dialect DBType `json:"dialect,omitempty"`
+	// TableFilter limits the comparison to the matching tables of both schemas
+	TableFilter *TableFilter `json:"tableFilter,omitempty"`
+	// StrictColumnOrder also reports columns that sit at a different position on
+	// MySQL, moving them with MODIFY COLUMN ... AFTER. A column whose definition
+	// changes too is moved by the same statement.
+	StrictColumnOrder bool `json:"strictColumnOrder,omitempty"`
+	// CompareStorage also compares MySQL row formats and SQL Server data compression,
+	// which change how a table is stored but not what it holds
+	CompareStorage bool `json:"compareStorage,omitempty"`
+	// SampleSize is the number of existing target values to attach to each column
+	// type change, together with a count of values that wouldn't convert. Sampling
+	// needs a connection, so it is done by SampleTypeChanges; 0 skips it.
+	SampleSize int `json:"sampleSize,omitempty"`
+}
+
+// quote folds and quotes an identifier for generated SQL
+func (o CompareOptions) quote(name string) string {
+	return quoteIdentifier(o.Dialect, o.IdentifierCase.Apply(name))
+}
+
+// addIndexSQL creates an index. MySQL adds it through ALTER TABLE, which also
+// takes the visibility; the other dialects use CREATE INDEX. A captured PostgreSQL
+// index definition is replayed for its method, key expressions and predicate,
+// with the index and table names folded and the source schema dropped like in
+// other generated statements.
+func (o CompareOptions) addIndexSQL(tableName, indexName string, columns []string, invisible bool, definition string) string {
+	if o.Dialect == PostgreSQL && definition != "" {
+		if m := pgIndexDefinitionPattern.FindStringSubmatch(strings.TrimSpace(definition)); m != nil {
+			unique := ""
+			if m[1] != "" {
+				unique = "UNIQUE "
+			}
+			only := ""
+			if m[2] != "" {
+				only = "ONLY "
+			}
+			return fmt.Sprintf("CREATE %sINDEX %s ON %s%s %s;", unique, o.quote(indexName), only, o.quote(tableName), m[3])
+		}
+	}
+	if o.Dialect == MySQL || o.Dialect == "" {
+		return fmt.Sprintf("ALTER TABLE %s ADD INDEX %s (%s)%s;", o.quote(tableName), o.quote(indexName), strings.Join(columns, ", "), indexVisibilitySuffix(invisible))
+	}
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s);", o.quote(indexName), o.quote(tableName), strings.Join(columns, ", "))
+}
+
+// pgIdentifier matches a possibly quoted PostgreSQL identifier
+const pgIdentifier = `(?:"(?:[^"]|"")+"|[^\s."]+)`
+
+// pgIndexDefinitionPattern splits what pg_get_indexdef returns, e.g.
+// CREATE UNIQUE INDEX idx ON public.t USING btree (lower(email)) WHERE active,
+// into UNIQUE, ONLY and everything from USING on
+var pgIndexDefinitionPattern = regexp.MustCompile(`(?is)^CREATE\s+(UNIQUE\s+)?INDEX\s+` + pgIdentifier +
+	`\s+ON\s+(ONLY\s+)?(?:` + pgIdentifier + `\.)?` + pgIdentifier + `\s+(USING\s.*?);?$`)
+
+// dropIndexSQL drops an index; SQL Server and MySQL scope index names to their table
+func (o CompareOptions) dropIndexSQL(tableName, indexName string) string {
+	switch o.Dialect {
+	case MySQL, "":
+		return fmt.Sprintf("ALTER TABLE %s DROP INDEX %s;", o.quote(tableName), o.quote(indexName))
+	case SQLServer:
+		return fmt.Sprintf("DROP INDEX %s ON %s;", o.quote(indexName), o.quote(tableName))
+	default:
+		return fmt.Sprintf("DROP INDEX %s;", o.quote(indexName))
+	}
+}
+
 // CompareSchemas compares two schemas and returns differences
 func CompareSchemas(source, target *SchemaInfo) []DiffResult {
+	return CompareSchemasWithOptions(source, target, CompareOptions{})
+}
+
+// CompareSchemasWithOptions compares two schemas using the given options
+func CompareSchemasWithOptions(source, target *SchemaInfo, opts CompareOptions) []DiffResult {
 	var results []DiffResult
+	if opts.Dialect == "" {
+		opts.Dialect = target.DBType
+	}
+	source = opts.TableFilter.filterSchema(source)
+	target = opts.TableFilter.filterSchema(target)
 
 	// Find tables only in source (need to add to target)
 	for tableName, sourceTable := range source.Tables {
@@ -717,6 +1239,8 @@ func CompareSchemas(source, target *SchemaInfo) []DiffResult {
 				Detail:    "Table exists in source but not in target",
 				SQL:       sourceTable.CreateSQL + ";",
 			})
+			// CREATE TABLE doesn't carry the table's triggers
+			results = append(results, compareTriggers(tableName, sourceTable.Triggers, nil, opts.Dialect, opts.quote)...)
 		}
 	}
 
@@ -727,7 +1251,7 @@ func CompareSchemas(source, target *SchemaInfo) []DiffResult {
 				Type:      "removed",
 				TableName: tableName,
 				Detail:    "Table exists in target but not in source",
-				SQL:       fmt.Sprintf("DROP TABLE `%s`;", tableName),
+				SQL:       fmt.Sprintf("DROP TABLE %s;", opts.quote(tableName)),
 			})
 		}
 	}
@@ -735,25 +1259,105 @@ func CompareSchemas(source, target *SchemaInfo) []DiffResult {
 	// Compare existing tables
 	for tableName, sourceTable := range source.Tables {
 		if targetTable, exists := target.Tables[tableName]; exists {
-			tableDiffs := compareTableStructure(tableName, sourceTable, targetTable)
+			tableDiffs := compareTableStructure(tableName, sourceTable, targetTable, opts)
 			results = append(results, tableDiffs...)
 		}
 	}
 
-	// Sort results by type and table name
-	sort.Slice(results, func(i, j int) bool {
+	results = append(results, compareEnums(source.Enums, target.Enums)...)
+	results = append(results, compareViews(source.Views, target.Views, opts.Dialect, opts.quote)...)
+	results = append(results, compareRoutines(source.Routines, target.Routines, opts.quote)...)
+	results = append(results, compareMaterializedViews(source.MaterializedViews, target.MaterializedViews)...)
+	results = append(results, compareSequences(source.Sequences, target.Sequences)...)
+
+	// Sort results by type and table name. Tables are created after the tables they
+	// reference and dropped before them, so foreign keys never point at a missing table.
+	sourceDepth := foreignKeyDepth(source.Tables)
+	targetDepth := foreignKeyDepth(target.Tables)
+	sort.SliceStable(results, func(i, j int) bool {
 		if results[i].Type != results[j].Type {
 			order := map[string]int{"added": 0, "modified": 1, "removed": 2}
 			return order[results[i].Type] < order[results[j].Type]
 		}
+		if ri, rj := objectRank(results[i]), objectRank(results[j]); ri != rj {
+			return ri < rj
+		}
+		if results[i].ObjectType == "" {
+			if di, dj := sourceDepth[results[i].TableName], sourceDepth[results[j].TableName]; results[i].Type == "added" && di != dj {
+				return di < dj
+			}
+			if di, dj := targetDepth[results[i].TableName], targetDepth[results[j].TableName]; results[i].Type == "removed" && di != dj {
+				return di > dj
+			}
+		}
 		return results[i].TableName < results[j].TableName
 	})
 
+	// PostgreSQL accepts comma-separated ALTER TABLE actions too; SQL Server and SQLite don't
+	if opts.CombineAlters && (opts.Dialect == MySQL || opts.Dialect == "" || opts.Dialect == PostgreSQL) {
+		results = combineAlterStatements(results, opts)
+	}
+
 	return results
 }
 
-func compareTableStructure(tableName string, source, target TableInfo) []DiffResult {
+// combineAlterStatements merges the single-statement ALTER TABLE changes of each
+// table into one ALTER TABLE with comma-separated clauses (MySQL syntax). The merged
+// statement takes the position of the table's first change.
+func combineAlterStatements(diffs []DiffResult, opts CompareOptions) []DiffResult {
+	type alterGroup struct {
+		index   int
+		details []string
+		clauses []string
+	}
+	groups := make(map[string]*alterGroup)
+	var combined []DiffResult
+
+	for _, diff := range diffs {
+		prefix := fmt.Sprintf("ALTER TABLE %s ", opts.quote(diff.TableName))
+		stmt := strings.TrimSpace(diff.SQL)
+		if diff.Type != "modified" || diff.ObjectType != "" ||
+			!strings.HasPrefix(stmt, prefix) || strings.Count(stmt, ";") != 1 || !strings.HasSuffix(stmt, ";") {
+			combined = append(combined, diff)
+			continue
+		}
+
+		clause := strings.TrimSuffix(strings.TrimPrefix(stmt, prefix), ";")
+		group, exists := groups[diff.TableName]
+		if !exists {
+			group = &alterGroup{index: len(combined)}
+			groups[diff.TableName] = group
+			combined = append(combined, diff)
+		}
+		group.details = append(group.details, diff.Detail)
+		group.clauses = append(group.clauses, clause)
+	}
+
+	for tableName, group := range groups {
+		if len(group.clauses) < 2 {
+			continue
+		}
+		combined[group.index].Detail = strings.Join(group.details, "; ")
+		combined[group.index].SQL = fmt.Sprintf("ALTER TABLE %s\n  %s;", opts.quote(tableName), strings.Join(group.clauses, ",\n  "))
+	}
+
+	return combined
+}
+
+// objectRank orders diffs of different object kinds so dependencies are satisfied:
+// types and sequences are created before the tables that use them and dropped
+// after them, views are created after their base tables and dropped before them
+func objectRank(diff DiffResult) int {
+	rank := map[string]int{"enum": 0, "sequence": 0, "": 1, "view": 2, "materialized_view": 2, "routine": 2, "trigger": 2, "grant": 3}[diff.ObjectType]
+	if diff.Type == "removed" {
+		return -rank
+	}
+	return rank
+}
+
+func compareTableStructure(tableName string, source, target TableInfo, opts CompareOptions) []DiffResult {
 	var results []DiffResult
+	q := opts.quote
 
 	sourceColMap := make(map[string]ColumnInfo)
 	targetColMap := make(map[string]ColumnInfo)
@@ -768,23 +1372,18 @@ func compareTableStructure(tableName string, source, target TableInfo) []DiffRes
 	// Find added columns
 	for colName, sourceCol := range sourceColMap {
 		if _, exists := targetColMap[colName]; !exists {
-			afterClause := ""
-			if sourceCol.Position > 1 {
-				for _, c := range source.Columns {
-					if c.Position == sourceCol.Position-1 {
-						afterClause = fmt.Sprintf(" AFTER `%s`", c.Name)
-						break
-					}
-				}
-			} else {
-				afterClause = " FIRST"
+			afterClause := columnAfterClause(source.Columns, sourceCol, q)
+
+			if requiresBackfill(sourceCol) {
+				results = append(results, buildSafeNotNullAdd(tableName, sourceCol, afterClause, opts))
+				continue
 			}
 
 			results = append(results, DiffResult{
 				Type:      "modified",
 				TableName: tableName,
 				Detail:    fmt.Sprintf("Add column: %s", colName),
-				SQL:       fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s%s;", tableName, colName, buildColumnDef(sourceCol), afterClause),
+				SQL:       opts.addColumnSQL(tableName, sourceCol, afterClause),
 			})
 		}
 	}
@@ -796,52 +1395,116 @@ func compareTableStructure(tableName string, source, target TableInfo) []DiffRes
 				Type:      "modified",
 				TableName: tableName,
 				Detail:    fmt.Sprintf("Drop column: %s", colName),
-				SQL:       fmt.Sprintf("ALTER TABLE `%s` DROP COLUMN `%s`;", tableName, colName),
+				SQL:       fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", q(tableName), q(colName)),
 			})
 		}
 	}
 
-	// Find modified columns
-	for colName, sourceCol := range sourceColMap {
+	// Find modified columns, in source order so moves build on each other.
+	// MySQL only allows AUTO_INCREMENT on a key column, so a column gaining it
+	// while the primary key changes waits until the new key is in place.
+	pkChanges := comparePrimaryKeys(tableName, source.PrimaryKey, target.PrimaryKey, opts.Dialect, q)
+	var afterPrimaryKey []DiffResult
+	strictOrder := opts.StrictColumnOrder && (opts.Dialect == MySQL || opts.Dialect == "")
+	for _, sourceCol := range source.Columns {
+		colName := sourceCol.Name
 		if targetCol, exists := targetColMap[colName]; exists {
-			if !columnsEqual(sourceCol, targetCol) {
+			afterClause := ""
+			if strictOrder && sharedPredecessor(source.Columns, colName, targetColMap) != sharedPredecessor(target.Columns, colName, sourceColMap) {
+				afterClause = columnAfterClause(source.Columns, sourceCol, q)
+			}
+			if columnsEqual(sourceCol, targetCol) && afterClause != "" {
 				results = append(results, DiffResult{
 					Type:      "modified",
 					TableName: tableName,
-					Detail:    fmt.Sprintf("Modify column: %s (%s -> %s)", colName, targetCol.Type, sourceCol.Type),
-					SQL:       fmt.Sprintf("ALTER TABLE `%s` MODIFY COLUMN `%s` %s;", tableName, colName, buildColumnDef(sourceCol)),
+					Detail:    fmt.Sprintf("Move column: %s%s", colName, afterClause),
+					SQL:       opts.modifyColumnSQL(tableName, sourceCol, targetCol, afterClause),
 				})
+			} else if !columnsEqual(sourceCol, targetCol) {
+				detail := fmt.Sprintf("Modify column: %s (%s -> %s)", colName, targetCol.Type, sourceCol.Type)
+				if sourceCol.Type == targetCol.Type && !intPtrsEqual(sourceCol.SRID, targetCol.SRID) {
+					detail = fmt.Sprintf("Modify column SRID: %s (%s -> %s)", colName, formatSRID(targetCol.SRID), formatSRID(sourceCol.SRID))
+				} else if sourceCol.Type == targetCol.Type && !collationsEqual(sourceCol.Collation, targetCol.Collation) {
+					detail = fmt.Sprintf("Modify column collation: %s (%s -> %s)", colName, targetCol.Collation, sourceCol.Collation)
+				} else if !computedEqual(sourceCol, targetCol) {
+					detail = fmt.Sprintf("Modify computed column: %s (%s -> %s)", colName, formatComputed(targetCol), formatComputed(sourceCol))
+				} else if sourceCol.Type == targetCol.Type && !onUpdatesEqual(sourceCol.OnUpdate, targetCol.OnUpdate) {
+					detail = fmt.Sprintf("Modify column ON UPDATE: %s (%s -> %s)", colName, formatOnUpdate(targetCol.OnUpdate), formatOnUpdate(sourceCol.OnUpdate))
+				} else if sourceCol.Type == targetCol.Type && isAutoIncrement(sourceCol) != isAutoIncrement(targetCol) {
+					detail = fmt.Sprintf("Modify column AUTO_INCREMENT: %s (%s -> %s)", colName, formatAutoIncrement(targetCol), formatAutoIncrement(sourceCol))
+				} else if sourceCol.Type == targetCol.Type && !identitiesEqual(sourceCol.Identity, targetCol.Identity) {
+					detail = fmt.Sprintf("Modify column identity: %s (%s -> %s)", colName, formatIdentity(targetCol.Identity), formatIdentity(sourceCol.Identity))
+				}
+				if afterClause != "" {
+					detail += ", moved" + afterClause
+				}
+				diff := DiffResult{
+					Type:      "modified",
+					TableName: tableName,
+					Detail:    detail,
+					SQL:       opts.modifyColumnSQL(tableName, sourceCol, targetCol, afterClause),
+				}
+				if !strings.EqualFold(sourceCol.Type, targetCol.Type) {
+					diff.TypeChange = &ColumnTypeChange{Column: colName, FromType: targetCol.Type, ToType: sourceCol.Type}
+				}
+				if len(pkChanges) > 0 && isAutoIncrement(sourceCol) && !isAutoIncrement(targetCol) {
+					afterPrimaryKey = append(afterPrimaryKey, diff)
+				} else {
+					results = append(results, diff)
+				}
 			}
 		}
 	}
 
+	results = append(results, pkChanges...)
+	results = append(results, afterPrimaryKey...)
+
 	// Compare indexes
-	sourceIdxMap := buildIndexMap(source.Indexes)
-	targetIdxMap := buildIndexMap(target.Indexes)
+	sourceIdxMap := buildIndexMap(source.Indexes, q)
+	targetIdxMap := buildIndexMap(target.Indexes, q)
+	sourceInvisible := buildIndexVisibility(source.Indexes)
+	targetInvisible := buildIndexVisibility(target.Indexes)
+	sourceUnique := buildIndexUniqueness(source.Indexes)
+	targetUnique := buildIndexUniqueness(target.Indexes)
+	sourceDefinitions := buildIndexDefinitions(source.Indexes)
+	targetDefinitions := buildIndexDefinitions(target.Indexes)
 
 	for idxName, sourceCols := range sourceIdxMap {
-		if idxName == "PRIMARY" {
-			continue // Skip primary key for now
+		if isPrimaryKeyIndex(idxName, source.PrimaryKey) {
+			continue // compared by comparePrimaryKeys
 		}
 		if targetCols, exists := targetIdxMap[idxName]; !exists {
 			results = append(results, DiffResult{
 				Type:      "modified",
 				TableName: tableName,
 				Detail:    fmt.Sprintf("Add index: %s", idxName),
-				SQL:       fmt.Sprintf("ALTER TABLE `%s` ADD INDEX `%s` (%s);", tableName, idxName, strings.Join(sourceCols, ", ")),
+				SQL:       opts.addIndexSQL(tableName, idxName, sourceCols, sourceInvisible[idxName], sourceDefinitions[idxName]),
 			})
-		} else if !stringSlicesEqual(sourceCols, targetCols) {
+		} else if (!indexPartsEqual(sourceCols, targetCols) &&
+			!(opts.UniqueKeyAnyOrder && sourceUnique[idxName] && targetUnique[idxName] && indexPartSetsEqual(sourceCols, targetCols))) ||
+			!indexDefinitionsEqual(sourceDefinitions[idxName], targetDefinitions[idxName]) {
 			results = append(results, DiffResult{
 				Type:      "modified",
 				TableName: tableName,
 				Detail:    fmt.Sprintf("Recreate index: %s", idxName),
-				SQL:       fmt.Sprintf("ALTER TABLE `%s` DROP INDEX `%s`, ADD INDEX `%s` (%s);", tableName, idxName, idxName, strings.Join(sourceCols, ", ")),
+				SQL:       recreateIndexSQL(tableName, idxName, sourceCols, sourceInvisible[idxName], sourceDefinitions[idxName], opts),
+			})
+		} else if sourceInvisible[idxName] != targetInvisible[idxName] {
+			visibility := "VISIBLE"
+			if sourceInvisible[idxName] {
+				visibility = "INVISIBLE"
+			}
+			results = append(results, DiffResult{
+				Type:      "modified",
+				TableName: tableName,
+				Detail:    fmt.Sprintf("Change index visibility: %s (%s)", idxName, visibility),
+				SQL:       fmt.Sprintf("ALTER TABLE %s ALTER INDEX %s %s;", q(tableName), q(idxName), visibility),
 			})
 		}
 	}
 
 	for idxName := range targetIdxMap {
-		if idxName == "PRIMARY" {
+		if isPrimaryKeyIndex(idxName, target.PrimaryKey) {
 			continue
 		}
 		if _, exists := sourceIdxMap[idxName]; !exists {
@@ -849,34 +1512,320 @@ func compareTableStructure(tableName string, source, target TableInfo) []DiffRes
 				Type:      "modified",
 				TableName: tableName,
 				Detail:    fmt.Sprintf("Drop index: %s", idxName),
-				SQL:       fmt.Sprintf("ALTER TABLE `%s` DROP INDEX `%s`;", tableName, idxName),
+				SQL:       opts.dropIndexSQL(tableName, idxName),
 			})
 		}
 	}
 
+	results = append(results, compareForeignKeys(tableName, source.ForeignKeys, target.ForeignKeys, opts.Dialect, q)...)
+	results = append(results, compareTriggers(tableName, source.Triggers, target.Triggers, opts.Dialect, q)...)
+	if opts.CompareStorage {
+		results = append(results, compareStorageOptions(tableName, source, target, q)...)
+	}
+	results = append(results, compareTemporal(tableName, source.Temporal, target.Temporal)...)
+
 	return results
 }
 
-func buildColumnDef(col ColumnInfo) string {
+// recreateIndexSQL replaces an index whose key parts changed; MySQL does both in one statement
+func recreateIndexSQL(tableName, indexName string, columns []string, invisible bool, definition string, opts CompareOptions) string {
+	if opts.Dialect == MySQL || opts.Dialect == "" {
+		return fmt.Sprintf("ALTER TABLE %s DROP INDEX %s, ADD INDEX %s (%s)%s;", opts.quote(tableName), opts.quote(indexName), opts.quote(indexName), strings.Join(columns, ", "), indexVisibilitySuffix(invisible))
+	}
+	return opts.dropIndexSQL(tableName, indexName) + "\n" + opts.addIndexSQL(tableName, indexName, columns, invisible, definition)
+}
+
+// dropSQLServerDefaultSQL drops the default constraint of a column whatever it is
+// named: defaults declared inline get system names such as DF__t__c__1A2B3C. The
+// lookup runs in its own batch so the statement can repeat within a script.
+func dropSQLServerDefaultSQL(quotedTable, column string) string {
+	batch := fmt.Sprintf("DECLARE @df sysname; "+
+		"SELECT @df = name FROM sys.default_constraints WHERE parent_object_id = OBJECT_ID(%s) "+
+		"AND parent_column_id = COLUMNPROPERTY(OBJECT_ID(%s), %s, 'ColumnId'); "+
+		"IF @df IS NOT NULL EXEC('ALTER TABLE ' + %s + ' DROP CONSTRAINT ' + QUOTENAME(@df));",
+		sqlServerString(quotedTable), sqlServerString(quotedTable), sqlServerString(column), sqlServerString(quotedTable))
+	return fmt.Sprintf("EXEC(%s);", sqlServerString(batch))
+}
+
+// sqlServerString renders s as a SQL Server Unicode string literal
+func sqlServerString(s string) string {
+	return "N'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// columnAfterClause positions col right after its predecessor in columns, or FIRST
+func columnAfterClause(columns []ColumnInfo, col ColumnInfo, q func(string) string) string {
+	if col.Position <= 1 {
+		return " FIRST"
+	}
+	for _, c := range columns {
+		if c.Position == col.Position-1 {
+			return fmt.Sprintf(" AFTER %s", q(c.Name))
+		}
+	}
+	return ""
+}
+
+// sharedPredecessor returns the nearest column before name that also exists on the
+// other side, so an added or dropped neighbour doesn't count as a move
+func sharedPredecessor(columns []ColumnInfo, name string, other map[string]ColumnInfo) string {
+	predecessor := ""
+	for _, c := range columns {
+		if c.Name == name {
+			return predecessor
+		}
+		if _, exists := other[c.Name]; exists {
+			predecessor = c.Name
+		}
+	}
+	return predecessor
+}
+
+// addColumnSQL adds a column; the AFTER/FIRST position clause is only understood by MySQL
+func (o CompareOptions) addColumnSQL(tableName string, col ColumnInfo, afterClause string) string {
+	switch o.Dialect {
+	case MySQL, "":
+		return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s%s;", o.quote(tableName), o.quote(col.Name), buildColumnDef(o.Dialect, col), afterClause)
+	case SQLServer:
+		return fmt.Sprintf("ALTER TABLE %s ADD %s %s;", o.quote(tableName), o.quote(col.Name), buildColumnDef(o.Dialect, col))
+	default:
+		return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", o.quote(tableName), o.quote(col.Name), buildColumnDef(o.Dialect, col))
+	}
+}
+
+// modifyColumnSQL changes target's definition of a column to source's. PostgreSQL takes
+// type, nullability and default as separate ALTER COLUMN statements; SQL Server alters
+// type and nullability together and keeps the default in a constraint, which is looked
+// up by column to drop it and recreated as DF_<table>_<column>.
+// afterClause moves the column on MySQL and is ignored elsewhere.
+func (o CompareOptions) modifyColumnSQL(tableName string, source, target ColumnInfo, afterClause string) string {
+	table, column := o.quote(tableName), o.quote(source.Name)
+	typeChanged := source.Type != target.Type || !collationsEqual(source.Collation, target.Collation)
+	nullChanged := source.Nullable != target.Nullable
+	defaultChanged := !defaultsEqual(source.Default, target.Default)
+	identityChanged := !identitiesEqual(source.Identity, target.Identity)
+
+	collate := collateClause(o.Dialect, source.Collation)
+
+	var steps []string
+	switch o.Dialect {
+	case PostgreSQL:
+		if typeChanged {
+			using := ""
+			if source.Type != target.Type {
+				using = fmt.Sprintf(" USING %s::%s", column, source.Type)
+			}
+			steps = append(steps, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s%s%s;", table, column, source.Type, collate, using))
+		}
+		if nullChanged {
+			action := "DROP NOT NULL"
+			if source.Nullable == "NO" {
+				action = "SET NOT NULL"
+			}
+			steps = append(steps, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;", table, column, action))
+		}
+		if defaultChanged {
+			if source.Default != nil {
+				steps = append(steps, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, column, formatDefault(*source.Default)))
+			} else {
+				steps = append(steps, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, column))
+			}
+		}
+		if identityChanged {
+			steps = append(steps, alterIdentitySQL(table, column, source.Identity, target.Identity))
+		}
+	case SQLServer:
+		if source.Computed != "" || target.Computed != "" {
+			// A computed column can't be altered; drop it and add it back
+			steps = append(steps,
+				fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", table, column),
+				o.addColumnSQL(tableName, source, ""))
+			break
+		}
+		if typeChanged || nullChanged {
+			null := " NULL"
+			if source.Nullable == "NO" {
+				null = " NOT NULL"
+			}
+			steps = append(steps, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s%s%s;", table, column, source.Type, collate, null))
+		}
+		if defaultChanged {
+			constraint := o.quote(fmt.Sprintf("DF_%s_%s", tableName, source.Name))
+			steps = append(steps, dropSQLServerDefaultSQL(table, o.IdentifierCase.Apply(source.Name)))
+			if source.Default != nil {
+				steps = append(steps, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s DEFAULT %s FOR %s;", table, constraint, formatDefault(*source.Default), column))
+			}
+		}
+		if identityChanged {
+			// Seed and increment are fixed when the column is created
+			steps = append(steps, fmt.Sprintf("-- SQL Server cannot change the identity of %s.%s in place; rebuild the column (%s -> %s)", tableName, source.Name, formatIdentity(target.Identity), formatIdentity(source.Identity)))
+		}
+	case SQLite:
+		steps = append(steps, fmt.Sprintf("-- SQLite cannot alter %s.%s in place; rebuild the table to change it to %s", tableName, source.Name, buildColumnDef(SQLite, source)))
+	default:
+		steps = append(steps, fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s%s;", table, column, buildColumnDef(o.Dialect, source), afterClause))
+	}
+
+	if len(steps) == 0 {
+		// Only attributes the dialect has no ALTER COLUMN form for differ (e.g. SRID, ON UPDATE)
+		steps = append(steps, fmt.Sprintf("-- %s.%s differs in attributes that cannot be altered in place", tableName, source.Name))
+	}
+	return strings.Join(steps, "\n")
+}
+
+// requiresBackfill reports whether adding the column fails on a non-empty table:
+// NOT NULL without a default, and not filled in by the engine
+func requiresBackfill(col ColumnInfo) bool {
+	if col.Nullable != "NO" || col.Default != nil || col.Computed != "" {
+		return false
+	}
+	extra := strings.ToLower(col.Extra)
+	return !strings.Contains(extra, "auto_increment") && !strings.Contains(extra, "generated") &&
+		!strings.Contains(extra, "identity")
+}
+
+// buildSafeNotNullAdd adds a NOT NULL column without a default in three steps:
+// add it nullable, backfill existing rows, then set NOT NULL
+func buildSafeNotNullAdd(tableName string, col ColumnInfo, afterClause string, opts CompareOptions) DiffResult {
+	q := opts.quote
+	nullable := col
+	nullable.Nullable = "YES"
+
+	steps := []string{opts.addColumnSQL(tableName, nullable, afterClause)}
+	detail := fmt.Sprintf("Add column: %s (NOT NULL without default; added nullable, backfilled, then set NOT NULL)", col.Name)
+	if value, ok := backfillValue(opts.Dialect, col.Type); ok {
+		steps = append(steps, fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s IS NULL;", q(tableName), q(col.Name), value, q(col.Name)))
+	} else {
+		// No neutral value for this type; the final step fails until rows are filled in
+		steps = append(steps, fmt.Sprintf("-- Backfill %s.%s for existing rows before it can be made NOT NULL", tableName, col.Name))
+		detail = fmt.Sprintf("Add column: %s (NOT NULL without default; existing rows need a value before NOT NULL can be set)", col.Name)
+	}
+	steps = append(steps, opts.modifyColumnSQL(tableName, col, nullable, ""))
+
+	return DiffResult{
+		Type:      "modified",
+		TableName: tableName,
+		Detail:    detail,
+		SQL:       strings.Join(steps, "\n"),
+	}
+}
+
+var numericTypePattern = regexp.MustCompile(`^((tiny|small|medium|big)?int(eger|[248])?|decimal|numeric|float[48]?|double|real|bit|bool(ean)?)\b`)
+
+// backfillValue returns a neutral literal for numeric, boolean and character types in
+// the dialect. PostgreSQL's boolean takes FALSE and its bit strings have no literal
+// that fits every length; the other dialects store booleans as numbers.
+func backfillValue(dbType DBType, colType string) (string, bool) {
+	t := strings.ToLower(colType)
+	switch {
+	case dbType == PostgreSQL && strings.HasPrefix(t, "bool"):
+		return "FALSE", true
+	case dbType == PostgreSQL && strings.HasPrefix(t, "bit"):
+		return "", false
+	case numericTypePattern.MatchString(t):
+		return "0", true
+	case strings.Contains(t, "char"), strings.HasSuffix(t, "text"):
+		return "''", true
+	}
+	return "", false
+}
+
+// collateClause renders a COLLATE clause for the dialect, or nothing without a
+// collation. PostgreSQL folds unquoted names to lower case, so they are quoted.
+func collateClause(dbType DBType, collation string) string {
+	if collation == "" {
+		return ""
+	}
+	if dbType == PostgreSQL {
+		return " COLLATE " + quoteIdentifier(PostgreSQL, collation)
+	}
+	return " COLLATE " + collation
+}
+
+// buildColumnDef renders a column's definition after its name, for the dialect
+func buildColumnDef(dbType DBType, col ColumnInfo) string {
+	// Computed columns take their type from the expression
+	if col.Computed != "" {
+		def := "AS " + col.Computed
+		if col.Persisted {
+			def += " PERSISTED"
+		}
+		return def
+	}
 	def := col.Type
+	// MySQL takes SRID as a column attribute; PostGIS types already embed it as (type,srid)
+	if col.SRID != nil && !strings.Contains(col.Type, "(") {
+		def += fmt.Sprintf(" SRID %d", *col.SRID)
+	}
+	def += collateClause(dbType, col.Collation)
 	if col.Nullable == "NO" {
 		def += " NOT NULL"
 	}
 	if col.Default != nil {
-		defaultVal := *col.Default
-		// Don't quote numeric defaults, NULL, or function calls like CURRENT_TIMESTAMP
-		if isNumericDefault(defaultVal) || isSpecialDefault(defaultVal) {
-			def += fmt.Sprintf(" DEFAULT %s", defaultVal)
-		} else {
-			def += fmt.Sprintf(" DEFAULT '%s'", defaultVal)
-		}
+		def += " DEFAULT " + formatDefault(*col.Default)
+	}
+	if col.OnUpdate != "" {
+		def += " ON UPDATE " + col.OnUpdate
+	}
+	if col.Identity != nil {
+		def += " " + col.Identity.definition()
 	}
 	if col.Extra != "" {
 		def += " " + col.Extra
 	}
+	if col.Invisible {
+		def += " INVISIBLE"
+	}
 	return def
 }
 
+// formatDefault renders a MySQL-style (unquoted) default value as SQL
+func formatDefault(defaultVal string) string {
+	// Don't quote numeric defaults, NULL, or function calls like CURRENT_TIMESTAMP
+	if isNumericDefault(defaultVal) || isSpecialDefault(defaultVal) {
+		return defaultVal
+	}
+	return fmt.Sprintf("'%s'", defaultVal)
+}
+
+func formatSRID(srid *int) string {
+	if srid == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%d", *srid)
+}
+
+// extrasEqual compares MySQL EXTRA attributes ignoring case and spacing,
+// e.g. a dump's AUTO_INCREMENT against information_schema's auto_increment
+func extrasEqual(a, b string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " "))
+}
+
+func isAutoIncrement(col ColumnInfo) bool {
+	_, found := stripExtraToken(col.Extra, "auto_increment")
+	return found
+}
+
+func formatAutoIncrement(col ColumnInfo) string {
+	if isAutoIncrement(col) {
+		return "AUTO_INCREMENT"
+	}
+	return "none"
+}
+
+// stripExtraToken removes a keyword from a MySQL EXTRA string and reports whether it was present
+func stripExtraToken(extra, token string) (string, bool) {
+	var kept []string
+	found := false
+	for _, part := range strings.Fields(extra) {
+		if strings.EqualFold(part, token) {
+			found = true
+			continue
+		}
+		kept = append(kept, part)
+	}
+	return strings.Join(kept, " "), found
+}
+
 func isNumericDefault(val string) bool {
 	if val == "" {
 		return false
@@ -913,7 +1862,89 @@ func isSpecialDefault(val string) bool {
 
 func columnsEqual(a, b ColumnInfo) bool {
 	return a.Type == b.Type && a.Nullable == b.Nullable &&
-		a.Extra == b.Extra && defaultsEqual(a.Default, b.Default)
+		extrasEqual(a.Extra, b.Extra) && defaultsEqual(a.Default, b.Default) && onUpdatesEqual(a.OnUpdate, b.OnUpdate) &&
+		a.Invisible == b.Invisible && intPtrsEqual(a.SRID, b.SRID) &&
+		collationsEqual(a.Collation, b.Collation) && computedEqual(a, b) && identitiesEqual(a.Identity, b.Identity)
+}
+
+// collationsEqual compares effective column collations. A side that doesn't
+// report one (SQLite, non-text columns) doesn't count as a difference.
+func collationsEqual(a, b string) bool {
+	if a == "" || b == "" {
+		return true
+	}
+	return strings.EqualFold(a, b)
+}
+
+var onUpdatePattern = regexp.MustCompile(`(?i)\bon\s+update\s+(\S+)`)
+
+// splitOnUpdate extracts the ON UPDATE value from a MySQL EXTRA string
+func splitOnUpdate(extra string) (string, string) {
+	match := onUpdatePattern.FindStringSubmatchIndex(extra)
+	if match == nil {
+		return extra, ""
+	}
+	onUpdate := extra[match[2]:match[3]]
+	rest := strings.Join(strings.Fields(extra[:match[0]]+" "+extra[match[1]:]), " ")
+	return rest, onUpdate
+}
+
+// normalizeOnUpdate treats CURRENT_TIMESTAMP, CURRENT_TIMESTAMP() and NOW() as the same value
+func normalizeOnUpdate(value string) string {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if strings.HasPrefix(value, "NOW(") {
+		value = "CURRENT_TIMESTAMP" + strings.TrimPrefix(value, "NOW")
+	}
+	return strings.TrimSuffix(value, "()")
+}
+
+// computedEqual compares computed column expressions ignoring the redundant
+// parentheses and whitespace SQL Server adds when it stores a definition
+func computedEqual(a, b ColumnInfo) bool {
+	return normalizeComputed(a.Computed) == normalizeComputed(b.Computed) && a.Persisted == b.Persisted
+}
+
+func normalizeComputed(expr string) string {
+	expr = strings.Join(strings.Fields(expr), "")
+	for len(expr) > 1 && expr[0] == '(' && expr[len(expr)-1] == ')' && enclosedByParens(expr) {
+		expr = expr[1 : len(expr)-1]
+	}
+	return strings.ToLower(expr)
+}
+
+// enclosedByParens reports whether the first parenthesis closes at the end of s
+func enclosedByParens(s string) bool {
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i == len(s)-1
+			}
+		}
+	}
+	return false
+}
+
+func onUpdatesEqual(a, b string) bool {
+	return normalizeOnUpdate(a) == normalizeOnUpdate(b)
+}
+
+func formatComputed(col ColumnInfo) string {
+	if col.Computed == "" {
+		return "not computed"
+	}
+	return buildColumnDef(SQLServer, col)
+}
+
+func formatOnUpdate(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
 }
 
 func defaultsEqual(a, b *string) bool {
@@ -926,14 +1957,136 @@ func defaultsEqual(a, b *string) bool {
 	return *a == *b
 }
 
-func buildIndexMap(indexes []IndexInfo) map[string][]string {
+func intPtrsEqual(a, b *int) bool {
+	if a == nil && b == nil {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+	return *a == *b
+}
+
+func buildIndexMap(indexes []IndexInfo, quote func(string) string) map[string][]string {
+	// Key parts are listed in index order; SeqInIdx is 0 where the dialect doesn't report it
+	ordered := make([]IndexInfo, len(indexes))
+	copy(ordered, indexes)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Name != ordered[j].Name {
+			return ordered[i].Name < ordered[j].Name
+		}
+		return ordered[i].SeqInIdx < ordered[j].SeqInIdx
+	})
+
 	result := make(map[string][]string)
+	for _, idx := range ordered {
+		if idx.Expression != "" {
+			// Functional key parts must be wrapped in their own parentheses
+			result[idx.Name] = append(result[idx.Name], fmt.Sprintf("(%s)", idx.Expression))
+			continue
+		}
+		result[idx.Name] = append(result[idx.Name], quote(idx.Column))
+	}
+	return result
+}
+
+// indexPartsEqual compares index key parts, normalizing expressions so that
+// quoting, case and whitespace differences introduced by the engine don't count
+func indexPartsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if normalizeIndexExpression(a[i]) != normalizeIndexExpression(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexPartSetsEqual compares index key parts ignoring their order
+func indexPartSetsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := make([]string, len(a))
+	sortedB := make([]string, len(b))
+	for i := range a {
+		sortedA[i] = normalizeIndexExpression(a[i])
+		sortedB[i] = normalizeIndexExpression(b[i])
+	}
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	return stringSlicesEqual(sortedA, sortedB)
+}
+
+func normalizeIndexExpression(expr string) string {
+	var sb strings.Builder
+	inString := false
+	for _, c := range expr {
+		switch {
+		case c == '\'':
+			inString = !inString
+			sb.WriteRune(c)
+		case inString:
+			sb.WriteRune(c)
+		case c == '`' || c == '"' || c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			// identifier quotes and whitespace are insignificant
+		default:
+			sb.WriteRune(unicode.ToLower(c))
+		}
+	}
+	return sb.String()
+}
+
+// buildIndexVisibility returns which indexes are marked invisible
+func buildIndexVisibility(indexes []IndexInfo) map[string]bool {
+	result := make(map[string]bool)
 	for _, idx := range indexes {
-		result[idx.Name] = append(result[idx.Name], fmt.Sprintf("`%s`", idx.Column))
+		if idx.Invisible {
+			result[idx.Name] = true
+		}
 	}
 	return result
 }
 
+// buildIndexDefinitions maps index names to their captured CREATE INDEX statements
+func buildIndexDefinitions(indexes []IndexInfo) map[string]string {
+	result := make(map[string]string)
+	for _, idx := range indexes {
+		if idx.Definition != "" {
+			result[idx.Name] = idx.Definition
+		}
+	}
+	return result
+}
+
+// indexDefinitionsEqual compares captured index definitions, which only count
+// when both sides have one; whitespace and case differences are ignored
+func indexDefinitionsEqual(a, b string) bool {
+	if a == "" || b == "" {
+		return true
+	}
+	return strings.EqualFold(strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " "))
+}
+
+func buildIndexUniqueness(indexes []IndexInfo) map[string]bool {
+	result := make(map[string]bool)
+	for _, idx := range indexes {
+		if idx.NonUnique == 0 {
+			result[idx.Name] = true
+		}
+	}
+	return result
+}
+
+func indexVisibilitySuffix(invisible bool) string {
+	if invisible {
+		return " INVISIBLE"
+	}
+	return ""
+}
+
 func stringSlicesEqual(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
@@ -963,10 +2116,10 @@ func CreateDatabase(config ConnectionConfig, dbName, charset, collation string)
 }
 
 func createMySQLDatabase(config ConnectionConfig, dbName, charset, collation string) error {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/",
-		config.User, config.Password, config.Host, config.Port)
+	cfg := config
+	cfg.Database = ""
 
-	db, err := sql.Open("mysql", dsn)
+	db, err := Connect(cfg)
 	if err != nil {
 		return err
 	}
@@ -1016,9 +2169,9 @@ func createSQLServerDatabase(config ConnectionConfig, dbName string) error {
 func DropDatabase(config ConnectionConfig, dbName string) error {
 	switch config.Type {
 	case MySQL, "":
-		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/",
-			config.User, config.Password, config.Host, config.Port)
-		db, err := sql.Open("mysql", dsn)
+		cfg := config
+		cfg.Database = ""
+		db, err := Connect(cfg)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,220 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompareTableStructurePostgreSQLIndexes(t *testing.T) {
+	column := ColumnInfo{Name: "email", Type: "text", Nullable: "YES", Position: 1}
+	index := func(definition string) []IndexInfo {
+		return []IndexInfo{{Name: "users_email_idx", Column: "email", SeqInIdx: 1, NonUnique: 1, Definition: definition}}
+	}
+	tests := []struct {
+		name           string
+		source         []IndexInfo
+		target         []IndexInfo
+		identifierCase IdentifierCase
+		want           string
+	}{
+		{
+			name:   "missing index replays its definition",
+			source: index("CREATE INDEX users_email_idx ON public.users USING btree (email)"),
+			want:   "CREATE INDEX \"users_email_idx\" ON \"users\" USING btree (email);",
+		},
+		{
+			name:   "changed method recreates the index",
+			source: index("CREATE INDEX users_email_idx ON public.users USING hash (email)"),
+			target: index("CREATE INDEX users_email_idx ON public.users USING btree (email)"),
+			want:   "DROP INDEX \"users_email_idx\";\nCREATE INDEX \"users_email_idx\" ON \"users\" USING hash (email);",
+		},
+		{
+			name:   "same definition is unchanged",
+			source: index("CREATE INDEX users_email_idx ON public.users USING btree (email)"),
+			target: index("CREATE  INDEX users_email_idx ON public.users USING btree (email)"),
+		},
+		{
+			name:           "replayed definition follows the identifier case",
+			source:         index(`CREATE UNIQUE INDEX "Users_Email_Idx" ON "Sales"."Users" USING btree (lower(email)) WHERE (email IS NOT NULL)`),
+			identifierCase: IdentifierCaseLower,
+			want:           "CREATE UNIQUE INDEX \"users_email_idx\" ON \"users\" USING btree (lower(email)) WHERE (email IS NOT NULL);",
+		},
+		{
+			name:   "definition of a partitioned table keeps ONLY",
+			source: index("CREATE INDEX users_email_idx ON ONLY public.users USING btree (email)"),
+			want:   "CREATE INDEX \"users_email_idx\" ON ONLY \"users\" USING btree (email);",
+		},
+		{
+			name:   "without a definition the key columns are quoted",
+			source: []IndexInfo{{Name: "users_email_idx", Column: "email", SeqInIdx: 1, NonUnique: 1}},
+			want:   "CREATE INDEX \"users_email_idx\" ON \"users\" (\"email\");",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := TableInfo{Name: "users", Columns: []ColumnInfo{column}, Indexes: tt.source}
+			target := TableInfo{Name: "users", Columns: []ColumnInfo{column}, Indexes: tt.target}
+			var got []string
+			opts := CompareOptions{Dialect: PostgreSQL, IdentifierCase: tt.identifierCase}
+			for _, diff := range compareTableStructure("users", source, target, opts) {
+				got = append(got, diff.SQL)
+			}
+			if strings.Join(got, "\n") != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModifyColumnSQLServerDefault(t *testing.T) {
+	oldDefault, newDefault := "0", "1"
+	target := ColumnInfo{Name: "qty", Type: "int", Nullable: "NO", Default: &oldDefault}
+	source := target
+	source.Default = &newDefault
+
+	got := CompareOptions{Dialect: SQLServer}.modifyColumnSQL("orders", source, target, "")
+	want := "EXEC(N'DECLARE @df sysname; " +
+		"SELECT @df = name FROM sys.default_constraints WHERE parent_object_id = OBJECT_ID(N''[orders]'') " +
+		"AND parent_column_id = COLUMNPROPERTY(OBJECT_ID(N''[orders]''), N''qty'', ''ColumnId''); " +
+		"IF @df IS NOT NULL EXEC(''ALTER TABLE '' + N''[orders]'' + '' DROP CONSTRAINT '' + QUOTENAME(@df));');\n" +
+		"ALTER TABLE [orders] ADD CONSTRAINT [DF_orders_qty] DEFAULT 1 FOR [qty];"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBuildDSNMySQLReportsMatchedRows(t *testing.T) {
+	// Saving an unedited row must not look like a missing one
+	_, dsn, err := buildDSN(ConnectionConfig{Type: MySQL, Host: "localhost", Port: 3306, User: "root", Database: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dsn, "clientFoundRows=true") {
+		t.Errorf("dsn %q doesn't set clientFoundRows", dsn)
+	}
+}
+
+func TestBuildSafeNotNullAdd(t *testing.T) {
+	tests := []struct {
+		dialect DBType
+		colType string
+		want    string
+	}{
+		{MySQL, "int", "ALTER TABLE `t` ADD COLUMN `c` int;\nUPDATE `t` SET `c` = 0 WHERE `c` IS NULL;\nALTER TABLE `t` MODIFY COLUMN `c` int NOT NULL;"},
+		{MySQL, "tinyint(1)", "ALTER TABLE `t` ADD COLUMN `c` tinyint(1);\nUPDATE `t` SET `c` = 0 WHERE `c` IS NULL;\nALTER TABLE `t` MODIFY COLUMN `c` tinyint(1) NOT NULL;"},
+		{PostgreSQL, "boolean", "ALTER TABLE \"t\" ADD COLUMN \"c\" boolean;\nUPDATE \"t\" SET \"c\" = FALSE WHERE \"c\" IS NULL;\nALTER TABLE \"t\" ALTER COLUMN \"c\" SET NOT NULL;"},
+		{PostgreSQL, "character varying(20)", "ALTER TABLE \"t\" ADD COLUMN \"c\" character varying(20);\nUPDATE \"t\" SET \"c\" = '' WHERE \"c\" IS NULL;\nALTER TABLE \"t\" ALTER COLUMN \"c\" SET NOT NULL;"},
+		{PostgreSQL, "bit(3)", "ALTER TABLE \"t\" ADD COLUMN \"c\" bit(3);\n-- Backfill t.c for existing rows before it can be made NOT NULL\nALTER TABLE \"t\" ALTER COLUMN \"c\" SET NOT NULL;"},
+		{SQLServer, "bit", "ALTER TABLE [t] ADD [c] bit;\nUPDATE [t] SET [c] = 0 WHERE [c] IS NULL;\nALTER TABLE [t] ALTER COLUMN [c] bit NOT NULL;"},
+		{SQLServer, "date", "ALTER TABLE [t] ADD [c] date;\n-- Backfill t.c for existing rows before it can be made NOT NULL\nALTER TABLE [t] ALTER COLUMN [c] date NOT NULL;"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.dialect)+" "+tt.colType, func(t *testing.T) {
+			col := ColumnInfo{Name: "c", Type: tt.colType, Nullable: "NO", Position: 1}
+			got := buildSafeNotNullAdd("t", col, "", CompareOptions{Dialect: tt.dialect}).SQL
+			if got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareColumnCollations(t *testing.T) {
+	column := func(collation string) ColumnInfo {
+		return ColumnInfo{Name: "name", Type: "varchar(50)", Nullable: "YES", Position: 1, Collation: collation}
+	}
+	tests := []struct {
+		name    string
+		dialect DBType
+		source  string
+		target  string
+		want    string
+	}{
+		{"mysql change", MySQL, "utf8mb4_bin", "utf8mb4_general_ci", "ALTER TABLE `t` MODIFY COLUMN `name` varchar(50) COLLATE utf8mb4_bin;"},
+		{"postgres names are quoted", PostgreSQL, "en_US", "C", "ALTER TABLE \"t\" ALTER COLUMN \"name\" TYPE varchar(50) COLLATE \"en_US\";"},
+		{"sql server change", SQLServer, "Latin1_General_CS_AS", "Latin1_General_CI_AS", "ALTER TABLE [t] ALTER COLUMN [name] varchar(50) COLLATE Latin1_General_CS_AS NULL;"},
+		{"case differences are equal", MySQL, "UTF8MB4_BIN", "utf8mb4_bin", ""},
+		{"unknown side is equal", PostgreSQL, "", "C", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := TableInfo{Name: "t", Columns: []ColumnInfo{column(tt.source)}}
+			target := TableInfo{Name: "t", Columns: []ColumnInfo{column(tt.target)}}
+			var got []string
+			for _, diff := range compareTableStructure("t", source, target, CompareOptions{Dialect: tt.dialect}) {
+				got = append(got, diff.SQL)
+			}
+			if strings.Join(got, "\n") != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextCancellationAbortsOperations(t *testing.T) {
+	dir := t.TempDir()
+	source := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(dir, "source.db")}
+	target := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(dir, "target.db")}
+	for _, config := range []ConnectionConfig{source, target} {
+		db, err := sql.Open("sqlite3", config.FilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec("CREATE TABLE item (id INTEGER PRIMARY KEY, name TEXT); INSERT INTO item VALUES (1, 'a')"); err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"connect", func() error { _, err := ConnectContext(ctx, source); return err }},
+		{"schema", func() error { _, err := GetSchemaContext(ctx, source); return err }},
+		{"table data", func() error { _, err := GetTableDataContext(ctx, source, "item", 1, 10); return err }},
+		{"tables for sync", func() error { _, err := GetTablesForSyncContext(ctx, source); return err }},
+		{"compare data", func() error {
+			_, err := CompareTableDataContext(ctx, source, target, "item", DataCompareOptions{})
+			return err
+		}},
+		{"sync summary", func() error { _, err := GetDataSyncSummaryContext(ctx, source, target, "item"); return err }},
+		{"sampled compare", func() error {
+			_, err := CompareTableDataSampledContext(ctx, source, target, "item", 50)
+			return err
+		}},
+		{"verify", func() error { _, err := VerifySyncContext(ctx, source, target, "item"); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); !errors.Is(err, context.Canceled) {
+				t.Errorf("err = %v, want context.Canceled", err)
+			}
+		})
+	}
+}
+
+func TestConnectContextBindsPool(t *testing.T) {
+	config := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(t.TempDir(), "bound.db")}
+	ctx, cancel := context.WithCancel(context.Background())
+	db, err := ConnectContext(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE item (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if _, err := db.Exec("INSERT INTO item VALUES (1)"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("exec after cancel: got %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// settingEffects names the server settings that change how data is stored or
+// compared, with what a difference between source and target can cause
+var settingEffects = map[string]string{
+	// MySQL
+	"sql_mode":                        "values rejected on one side may be silently truncated or zeroed on the other",
+	"time_zone":                       "TIMESTAMP values are converted through a different time zone",
+	"character_set_server":            "new tables and columns default to a different character set",
+	"collation_server":                "new tables and columns default to a different collation",
+	"explicit_defaults_for_timestamp": "TIMESTAMP columns get different implicit defaults",
+	"lower_case_table_names":          "table names are matched with different case sensitivity",
+	// PostgreSQL
+	"TimeZone":        "timestamptz values are rendered in a different time zone",
+	"DateStyle":       "dates in text form are written and parsed differently",
+	"IntervalStyle":   "intervals in text form are written differently",
+	"server_encoding": "the target may not store every character of the source",
+	// SQL Server
+	"collation":  "text compares and sorts differently",
+	"language":   "dates in text form are parsed differently",
+	"dateformat": "dates in text form are parsed differently",
+	// SQLite
+	"encoding": "text is stored in a different encoding",
+}
+
+// SettingDifference is a server setting that differs between source and target
+type SettingDifference struct {
+	Name    string `json:"name"`
+	Source  string `json:"source"`
+	Target  string `json:"target"`
+	Message string `json:"message"`
+}
+
+// GetDatabaseSettings returns the server settings of a connection that affect
+// data fidelity, such as sql_mode and time_zone on MySQL
+func GetDatabaseSettings(config ConnectionConfig) (map[string]string, error) {
+	return GetDatabaseSettingsContext(context.Background(), config)
+}
+
+// GetDatabaseSettingsContext is GetDatabaseSettings, aborting when ctx is done
+func GetDatabaseSettingsContext(ctx context.Context, config ConnectionConfig) (map[string]string, error) {
+	db, err := ConnectContext(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var rows *sql.Rows
+	switch config.Type {
+	case MySQL, "":
+		rows, err = db.QueryContext(ctx, `SHOW VARIABLES WHERE Variable_name IN
+			('sql_mode', 'time_zone', 'character_set_server', 'collation_server', 'explicit_defaults_for_timestamp', 'lower_case_table_names')`)
+	case PostgreSQL:
+		rows, err = db.QueryContext(ctx, `SELECT name, setting FROM pg_settings
+			WHERE name IN ('TimeZone', 'DateStyle', 'IntervalStyle', 'server_encoding')`)
+	case SQLServer:
+		rows, err = db.QueryContext(ctx, `
+			SELECT 'collation', CAST(SERVERPROPERTY('Collation') AS nvarchar(128))
+			UNION ALL SELECT 'language', @@LANGUAGE
+			UNION ALL SELECT 'dateformat', date_format FROM sys.dm_exec_sessions WHERE session_id = @@SPID`)
+	case SQLite:
+		rows, err = db.QueryContext(ctx, "SELECT 'encoding', encoding FROM pragma_encoding")
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", config.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings: %v", err)
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		settings[name] = value
+	}
+	return settings, rows.Err()
+}
+
+// CompareDatabaseSettings reports the data-affecting settings that both sides
+// have but set differently. sql_mode is compared as a set of modes.
+func CompareDatabaseSettings(source, target map[string]string) []SettingDifference {
+	var diffs []SettingDifference
+	for name, effect := range settingEffects {
+		s, inSource := source[name]
+		t, inTarget := target[name]
+		if !inSource || !inTarget || settingValuesEqual(name, s, t) {
+			continue
+		}
+		diffs = append(diffs, SettingDifference{
+			Name:    name,
+			Source:  s,
+			Target:  t,
+			Message: fmt.Sprintf("%s differs (%q vs %q): %s", name, s, t, effect),
+		})
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs
+}
+
+func settingValuesEqual(name, a, b string) bool {
+	if name != "sql_mode" {
+		return a == b
+	}
+	split := func(v string) []string {
+		modes := strings.Split(strings.ToUpper(v), ",")
+		sort.Strings(modes)
+		return modes
+	}
+	return strings.Join(split(a), ",") == strings.Join(split(b), ",")
+}
@@ -0,0 +1,247 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EnumInfo holds a PostgreSQL enum type and its allowed values in sort order
+type EnumInfo struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// getPostgreSQLEnums reads all enum types in the public schema from pg_enum
+func getPostgreSQLEnums(db *sql.DB) (map[string]EnumInfo, error) {
+	rows, err := db.Query(`
+		SELECT t.typname, e.enumlabel
+		FROM pg_type t
+		JOIN pg_enum e ON e.enumtypid = t.oid
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		WHERE n.nspname = 'public'
+		ORDER BY t.typname, e.enumsortorder`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	enums := make(map[string]EnumInfo)
+	for rows.Next() {
+		var typeName, label string
+		if err := rows.Scan(&typeName, &label); err != nil {
+			return nil, err
+		}
+		info := enums[typeName]
+		info.Name = typeName
+		info.Values = append(info.Values, label)
+		enums[typeName] = info
+	}
+	return enums, nil
+}
+
+// compareEnums diffs PostgreSQL enum types. Postgres can append values to an
+// enum but cannot drop or reorder them, so those changes are reported with a
+// recreate hint instead of runnable SQL.
+func compareEnums(source, target map[string]EnumInfo) []DiffResult {
+	var results []DiffResult
+
+	for name, sourceEnum := range source {
+		targetEnum, exists := target[name]
+		if !exists {
+			results = append(results, DiffResult{
+				Type:       "added",
+				TableName:  name,
+				Detail:     "Enum type exists in source but not in target",
+				SQL:        fmt.Sprintf("CREATE TYPE \"%s\" AS ENUM (%s);", name, quoteEnumValues(sourceEnum.Values)),
+				ObjectType: "enum",
+			})
+			continue
+		}
+		if stringSlicesEqual(sourceEnum.Values, targetEnum.Values) {
+			continue
+		}
+
+		targetSet := make(map[string]bool)
+		for _, v := range targetEnum.Values {
+			targetSet[v] = true
+		}
+		sourceSet := make(map[string]bool)
+		for _, v := range sourceEnum.Values {
+			sourceSet[v] = true
+		}
+
+		var removed []string
+		for _, v := range targetEnum.Values {
+			if !sourceSet[v] {
+				removed = append(removed, v)
+			}
+		}
+
+		var added []string
+		var stmts []string
+		for i, v := range sourceEnum.Values {
+			if targetSet[v] {
+				continue
+			}
+			added = append(added, v)
+			stmt := fmt.Sprintf("ALTER TYPE \"%s\" ADD VALUE '%s'", name, escapeEnumValue(v))
+			if i > 0 {
+				stmt += fmt.Sprintf(" AFTER '%s'", escapeEnumValue(sourceEnum.Values[i-1]))
+			}
+			stmts = append(stmts, stmt+";")
+		}
+
+		detail := fmt.Sprintf("Enum values differ: %s -> %s", strings.Join(targetEnum.Values, ", "), strings.Join(sourceEnum.Values, ", "))
+		if len(removed) > 0 || len(added) == 0 {
+			// Removing or reordering values requires recreating the type and every dependent column
+			detail += " (values removed or reordered, the type must be recreated manually)"
+			stmts = append(stmts, fmt.Sprintf("-- recreate type \"%s\" AS ENUM (%s)", name, quoteEnumValues(sourceEnum.Values)))
+		}
+
+		results = append(results, DiffResult{
+			Type:       "modified",
+			TableName:  name,
+			Detail:     detail,
+			SQL:        strings.Join(stmts, "\n"),
+			ObjectType: "enum",
+		})
+	}
+
+	for name := range target {
+		if _, exists := source[name]; !exists {
+			results = append(results, DiffResult{
+				Type:       "removed",
+				TableName:  name,
+				Detail:     "Enum type exists in target but not in source",
+				SQL:        fmt.Sprintf("DROP TYPE \"%s\";", name),
+				ObjectType: "enum",
+			})
+		}
+	}
+
+	return results
+}
+
+func quoteEnumValues(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "'" + escapeEnumValue(v) + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func escapeEnumValue(v string) string {
+	return strings.ReplaceAll(v, "'", "''")
+}
+
+// parseEnumValues extracts the values of a MySQL column type like enum('a','b”c')
+func parseEnumValues(columnType string) []string {
+	lower := strings.ToLower(columnType)
+	if !strings.HasPrefix(lower, "enum(") || !strings.HasSuffix(lower, ")") {
+		return nil
+	}
+	body := columnType[len("enum(") : len(columnType)-1]
+
+	var values []string
+	var current strings.Builder
+	inValue := false
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if !inValue {
+			if c == '\'' {
+				inValue = true
+				current.Reset()
+			}
+			continue
+		}
+		if c == '\'' {
+			if i+1 < len(body) && body[i+1] == '\'' {
+				current.WriteByte('\'')
+				i++
+				continue
+			}
+			inValue = false
+			values = append(values, current.String())
+			continue
+		}
+		current.WriteByte(c)
+	}
+	return values
+}
+
+// getEnumColumnValues returns the allowed values of every enum-typed column in a table
+func getEnumColumnValues(db *sql.DB, dbType DBType, database, tableName string) (map[string][]string, error) {
+	result := make(map[string][]string)
+
+	switch dbType {
+	case MySQL, "":
+		rows, err := db.Query(`
+			SELECT COLUMN_NAME, COLUMN_TYPE
+			FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND DATA_TYPE = 'enum'`, database, tableName)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var colName, colType string
+			if err := rows.Scan(&colName, &colType); err != nil {
+				return nil, err
+			}
+			result[colName] = parseEnumValues(colType)
+		}
+	case PostgreSQL:
+		rows, err := db.Query(`
+			SELECT c.column_name, e.enumlabel
+			FROM information_schema.columns c
+			JOIN pg_type t ON t.typname = c.udt_name
+			JOIN pg_enum e ON e.enumtypid = t.oid
+			WHERE c.table_schema = 'public' AND c.table_name = $1
+			ORDER BY c.column_name, e.enumsortorder`, tableName)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var colName, label string
+			if err := rows.Scan(&colName, &label); err != nil {
+				return nil, err
+			}
+			result[colName] = append(result[colName], label)
+		}
+	}
+
+	return result, nil
+}
+
+// checkEnumValues returns a warning for every value in row that the target enum column does not allow
+func checkEnumValues(row map[string]interface{}, enumColumns map[string][]string) string {
+	cols := make([]string, 0, len(enumColumns))
+	for col := range enumColumns {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	var problems []string
+	for _, col := range cols {
+		allowed := enumColumns[col]
+		val, ok := row[col]
+		if !ok || val == nil {
+			continue
+		}
+		s := fmt.Sprintf("%v", val)
+		found := false
+		for _, a := range allowed {
+			if a == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("value %q for column %s is not allowed by the target enum", s, col))
+		}
+	}
+	return strings.Join(problems, "; ")
+}
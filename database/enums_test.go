@@ -0,0 +1,26 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEnumValues(t *testing.T) {
+	tests := []struct {
+		columnType string
+		want       []string
+	}{
+		{"enum('a','b')", []string{"a", "b"}},
+		{"ENUM('small', 'large')", []string{"small", "large"}},
+		{"enum('a','b''c')", []string{"a", "b'c"}},
+		{"enum('x,y','(z)')", []string{"x,y", "(z)"}},
+		{"enum('')", []string{""}},
+		{"set('a','b')", nil},
+		{"varchar(10)", nil},
+	}
+	for _, tt := range tests {
+		if got := parseEnumValues(tt.columnType); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseEnumValues(%q) = %q, want %q", tt.columnType, got, tt.want)
+		}
+	}
+}
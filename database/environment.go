@@ -0,0 +1,103 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Environment tags a saved connection so destructive operations against
+// production can be gated
+type Environment string
+
+const (
+	EnvironmentDev     Environment = "dev"
+	EnvironmentStaging Environment = "staging"
+	EnvironmentProd    Environment = "prod"
+)
+
+// ErrConfirmationRequired is returned when a destructive operation targets a
+// prod-tagged connection without the confirmation token
+var ErrConfirmationRequired = errors.New("confirmation required")
+
+// destructivePattern matches statements that drop objects or remove rows
+var destructivePattern = regexp.MustCompile(`(?is)^\s*(DROP\s|TRUNCATE\s|DELETE\s|ALTER\s+TABLE\s.*\bDROP\s)`)
+
+// IsDestructiveStatement reports whether a statement drops objects or deletes rows
+func IsDestructiveStatement(stmt string) bool {
+	return destructivePattern.MatchString(stmt)
+}
+
+// RequireConfirmation gates destructive statements against a prod-tagged connection:
+// they only run when confirmation is the connection's name. conn may be nil for a
+// connection that isn't saved, which is never gated.
+func RequireConfirmation(conn *SavedConnection, statements []string, confirmation string) error {
+	if conn == nil || conn.Environment != EnvironmentProd || confirmation == conn.Name {
+		return nil
+	}
+	for _, stmt := range statements {
+		if IsDestructiveStatement(stmt) {
+			return fmt.Errorf("%w: %q is tagged prod; type the connection name to run destructive statements", ErrConfirmationRequired, conn.Name)
+		}
+	}
+	return nil
+}
+
+// WriteKind names a row edit for RequireConfirmationFor
+type WriteKind string
+
+const (
+	WriteInsert WriteKind = "insert"
+	WriteUpdate WriteKind = "update"
+	WriteDelete WriteKind = "delete"
+)
+
+// RequireConfirmationFor gates a row edit against a prod-tagged connection the
+// way RequireConfirmation gates destructive statements. Every kind is gated:
+// editing rows by hand changes production data whatever the statement.
+func RequireConfirmationFor(conn *SavedConnection, kind WriteKind, confirmation string) error {
+	if conn == nil || conn.Environment != EnvironmentProd || confirmation == conn.Name {
+		return nil
+	}
+	return fmt.Errorf("%w: %q is tagged prod; type the connection name to %s rows", ErrConfirmationRequired, conn.Name, kind)
+}
+
+// FindByServer returns the saved connection pointing at the same server and
+// database as config, preferring a prod-tagged one, or nil if there is none
+func (s *ConnectionStore) FindByServer(config ConnectionConfig) *SavedConnection {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found *SavedConnection
+	for i, c := range s.Connections {
+		if !SameDatabase(c.Config, config) {
+			continue
+		}
+		if found == nil || c.Environment == EnvironmentProd {
+			conn := s.Connections[i]
+			found = &conn
+		}
+	}
+	return found
+}
+
+// SetEnvironment changes the environment tag and color of a saved connection
+func (s *ConnectionStore) SetEnvironment(name string, env Environment, color string) error {
+	switch env {
+	case "", EnvironmentDev, EnvironmentStaging, EnvironmentProd:
+	default:
+		return fmt.Errorf("unknown environment: %s", env)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, c := range s.Connections {
+		if c.Name == name {
+			s.Connections[i].Environment = env
+			s.Connections[i].Color = color
+			return s.save()
+		}
+	}
+	return fmt.Errorf("connection not found: %s", name)
+}
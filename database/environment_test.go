@@ -0,0 +1,64 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRequireConfirmation(t *testing.T) {
+	prod := &SavedConnection{Name: "billing-prod", Environment: EnvironmentProd}
+	staging := &SavedConnection{Name: "billing-staging", Environment: EnvironmentStaging}
+
+	tests := []struct {
+		name         string
+		conn         *SavedConnection
+		statements   []string
+		confirmation string
+		wantBlocked  bool
+	}{
+		{"prod drop without confirmation", prod, []string{"DROP TABLE orders"}, "", true},
+		{"prod delete with wrong name", prod, []string{"DELETE FROM orders"}, "billing", true},
+		{"prod alter drop column", prod, []string{"ALTER TABLE orders\n  DROP COLUMN note"}, "", true},
+		{"prod truncate among others", prod, []string{"INSERT INTO a VALUES (1)", "TRUNCATE TABLE orders"}, "", true},
+		{"prod confirmed", prod, []string{"DROP TABLE orders"}, "billing-prod", false},
+		{"prod non-destructive", prod, []string{"INSERT INTO a VALUES (1)", "ALTER TABLE a ADD COLUMN b INT"}, "", false},
+		{"staging drop", staging, []string{"DROP TABLE orders"}, "", false},
+		{"unsaved connection", nil, []string{"DROP TABLE orders"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RequireConfirmation(tt.conn, tt.statements, tt.confirmation)
+			if blocked := errors.Is(err, ErrConfirmationRequired); blocked != tt.wantBlocked || (err != nil && !blocked) {
+				t.Errorf("err = %v, want blocked %v", err, tt.wantBlocked)
+			}
+		})
+	}
+}
+
+func TestRequireConfirmationFor(t *testing.T) {
+	prod := &SavedConnection{Name: "billing-prod", Environment: EnvironmentProd}
+	dev := &SavedConnection{Name: "billing-dev", Environment: EnvironmentDev}
+
+	tests := []struct {
+		name         string
+		conn         *SavedConnection
+		kind         WriteKind
+		confirmation string
+		wantBlocked  bool
+	}{
+		{"prod insert", prod, WriteInsert, "", true},
+		{"prod update", prod, WriteUpdate, "", true},
+		{"prod delete", prod, WriteDelete, "wrong", true},
+		{"prod delete confirmed", prod, WriteDelete, "billing-prod", false},
+		{"dev delete", dev, WriteDelete, "", false},
+		{"unsaved connection", nil, WriteUpdate, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RequireConfirmationFor(tt.conn, tt.kind, tt.confirmation)
+			if blocked := errors.Is(err, ErrConfirmationRequired); blocked != tt.wantBlocked || (err != nil && !blocked) {
+				t.Errorf("err = %v, want blocked %v", err, tt.wantBlocked)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var mermaidNameCleaner = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// ExportERDiagram writes the schema as a Mermaid erDiagram definition with
+// primary and foreign key columns marked and one relationship per foreign key
+func ExportERDiagram(schema *SchemaInfo, w io.Writer) error {
+	if schema == nil {
+		return fmt.Errorf("schema is required")
+	}
+
+	tableNames := make([]string, 0, len(schema.Tables))
+	for name := range schema.Tables {
+		tableNames = append(tableNames, name)
+	}
+	sort.Strings(tableNames)
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("erDiagram\n")
+
+	for _, name := range tableNames {
+		table := schema.Tables[name]
+		fkColumns := make(map[string]bool)
+		for _, fk := range table.ForeignKeys {
+			for _, col := range fk.Columns {
+				fkColumns[col] = true
+			}
+		}
+
+		fmt.Fprintf(bw, "    %s {\n", mermaidName(name))
+		for _, col := range table.Columns {
+			var keys []string
+			if col.Key == "PRI" {
+				keys = append(keys, "PK")
+			}
+			if fkColumns[col.Name] {
+				keys = append(keys, "FK")
+			}
+			line := fmt.Sprintf("        %s %s", mermaidName(col.Type), mermaidName(col.Name))
+			if len(keys) > 0 {
+				line += " " + strings.Join(keys, ", ")
+			}
+			fmt.Fprintln(bw, line)
+		}
+		bw.WriteString("    }\n")
+	}
+
+	for _, name := range tableNames {
+		table := schema.Tables[name]
+		for _, fk := range table.ForeignKeys {
+			fmt.Fprintf(bw, "    %s %s %s : %q\n",
+				mermaidName(fk.RefTable), relationshipCardinality(table, fk), mermaidName(name), fk.Name)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// relationshipCardinality is "exactly one" parent when every FK column is NOT NULL, else "zero or one"
+func relationshipCardinality(table TableInfo, fk ForeignKeyInfo) string {
+	for _, col := range table.Columns {
+		if col.Nullable == "YES" && containsString(fk.Columns, col.Name) {
+			return "|o--o{"
+		}
+	}
+	return "||--o{"
+}
+
+// mermaidName strips characters Mermaid does not accept in entity, type and attribute names
+func mermaidName(name string) string {
+	cleaned := strings.Trim(mermaidNameCleaner.ReplaceAllString(name, "_"), "_")
+	if cleaned == "" {
+		return "_"
+	}
+	return cleaned
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// implicitCommitPattern matches the DDL statements MySQL commits implicitly,
+// ending any transaction they run in
+var implicitCommitPattern = regexp.MustCompile(`(?is)^\s*(CREATE|ALTER|DROP|TRUNCATE|RENAME)\s`)
+
+// ExecuteInTransaction runs statements all-or-nothing
+func ExecuteInTransaction(config ConnectionConfig, statements []string) ([]string, error) {
+	return ExecuteInTransactionContext(context.Background(), config, statements)
+}
+
+// ExecuteInTransactionContext runs the statements in one transaction, committing
+// only when every statement succeeds and rolling back otherwise. PostgreSQL, SQL
+// Server and SQLite roll back DDL too. MySQL commits DDL implicitly, so a script
+// with DDL is not atomic there; it still runs, and the returned warnings say so.
+func ExecuteInTransactionContext(ctx context.Context, config ConnectionConfig, statements []string) ([]string, error) {
+	var pending []string
+	for _, stmt := range statements {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			pending = append(pending, stmt)
+		}
+	}
+
+	var warnings []string
+	firstDDL := -1
+	if config.Type == MySQL || config.Type == "" {
+		for i, stmt := range pending {
+			if implicitCommitPattern.MatchString(stmt) {
+				firstDDL = i
+				warnings = append(warnings, fmt.Sprintf("MySQL commits DDL implicitly: statements up to and including %d can't be rolled back", i+1))
+				break
+			}
+		}
+	}
+
+	db, err := ConnectContext(ctx, config)
+	if err != nil {
+		return warnings, err
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return warnings, err
+	}
+	defer tx.Rollback()
+
+	for i, stmt := range pending {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			if firstDDL >= 0 && firstDDL <= i {
+				return warnings, fmt.Errorf("statement %d failed; earlier statements were committed implicitly by DDL and not rolled back: %v", i+1, err)
+			}
+			return warnings, fmt.Errorf("statement %d failed, all changes rolled back: %v", i+1, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return warnings, fmt.Errorf("failed to commit: %v", err)
+	}
+	return warnings, nil
+}
@@ -0,0 +1,299 @@
+package database
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ExportOptions controls how NULL is represented in exported table data.
+// The same options must be passed to the import functions to round-trip.
+type ExportOptions struct {
+	// NullToken is written to CSV cells holding SQL NULL, e.g. `\N`, "NULL" or "".
+	// With an empty token NULL and empty strings can no longer be told apart.
+	NullToken string `json:"nullToken"`
+	// OmitNullKeys drops NULL columns from JSON rows instead of writing null
+	OmitNullKeys bool `json:"omitNullKeys"`
+	// BulkLoad makes CSV imports use COPY on PostgreSQL and bulk copy on SQL Server.
+	// MySQL and SQLite keep inserting row by row in one transaction.
+	BulkLoad bool `json:"bulkLoad"`
+}
+
+// DefaultExportOptions returns the MySQL-style `\N` NULL convention
+func DefaultExportOptions() ExportOptions {
+	return ExportOptions{NullToken: `\N`}
+}
+
+// tableExport is the JSON layout of an exported table
+type tableExport struct {
+	Columns []string                 `json:"columns"`
+	Rows    []map[string]interface{} `json:"rows"`
+}
+
+// ExportTableCSV writes all rows of a table as CSV with a header line
+func ExportTableCSV(config ConnectionConfig, tableName string, w io.Writer, opts ExportOptions) error {
+	return exportTable(config, tableName, func(columns []string, next func() (map[string]interface{}, error)) error {
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+		record := make([]string, len(columns))
+		for {
+			row, err := next()
+			if err != nil {
+				return err
+			}
+			if row == nil {
+				break
+			}
+			for i, col := range columns {
+				val := row[col]
+				if val == nil {
+					record[i] = opts.NullToken
+				} else {
+					record[i] = formatExportValue(val)
+				}
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+}
+
+// ExportTableJSON writes all rows of a table as {"columns": [...], "rows": [{...}]}.
+// Binary columns are written base64-encoded.
+func ExportTableJSON(config ConnectionConfig, tableName string, w io.Writer, opts ExportOptions) error {
+	return exportTable(config, tableName, func(columns []string, next func() (map[string]interface{}, error)) error {
+		bw := bufio.NewWriter(w)
+		header, err := json.Marshal(columns)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "{\"columns\":%s,\"rows\":[", header)
+
+		first := true
+		for {
+			row, err := next()
+			if err != nil {
+				return err
+			}
+			if row == nil {
+				break
+			}
+			if opts.OmitNullKeys {
+				for col, val := range row {
+					if val == nil {
+						delete(row, col)
+					}
+				}
+			}
+			data, err := json.Marshal(row)
+			if err != nil {
+				return err
+			}
+			if !first {
+				bw.WriteString(",")
+			}
+			first = false
+			bw.Write(data)
+		}
+
+		bw.WriteString("]}\n")
+		return bw.Flush()
+	})
+}
+
+// exportTable streams the rows of a table to the given writer function
+func exportTable(config ConnectionConfig, tableName string, write func(columns []string, next func() (map[string]interface{}, error)) error) error {
+	db, err := Connect(config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	dbType := config.Type
+	if dbType == "" {
+		dbType = MySQL
+	}
+
+	columns, err := getColumns(db, dbType, config.Database, tableName)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("table %s not found", tableName)
+	}
+
+	types, err := getColumnDataTypes(db, dbType, config.Database, tableName)
+	if err != nil {
+		return err
+	}
+
+	quotedCols := make([]string, len(columns))
+	for i, col := range columns {
+		quotedCols[i] = quoteIdentifier(dbType, col)
+	}
+	rows, err := db.Query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(quotedCols, ", "), quoteIdentifier(dbType, tableName)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	values := make([]interface{}, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+
+	next := func() (map[string]interface{}, error) {
+		if !rows.Next() {
+			return nil, rows.Err()
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			// Binary values stay bytes, which encoding/json writes as base64
+			if b, ok := values[i].([]byte); ok && !isBinaryType(types[col]) {
+				row[col] = string(b)
+			} else {
+				row[col] = values[i]
+			}
+		}
+		return row, nil
+	}
+
+	return write(columns, next)
+}
+
+// formatExportValue renders a non-NULL value as text the databases accept back on import
+func formatExportValue(val interface{}) string {
+	switch v := val.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999999")
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// ImportTableCSV inserts the rows of a CSV file (with header line) into a table
+// inside a single transaction. Cells equal to opts.NullToken are inserted as NULL.
+// Returns the number of rows inserted.
+func ImportTableCSV(config ConnectionConfig, tableName string, r io.Reader, opts ExportOptions) (int, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read CSV header: %v", err)
+	}
+
+	db, err := Connect(config)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	dbType := config.Type
+	if dbType == "" {
+		dbType = MySQL
+	}
+
+	columns, err := getColumns(db, dbType, config.Database, tableName)
+	if err != nil {
+		return 0, err
+	}
+	known := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		known[col] = true
+	}
+	quotedCols := make([]string, len(header))
+	marks := make([]string, len(header))
+	for i, col := range header {
+		if !known[col] {
+			return 0, fmt.Errorf("column %s does not exist in table %s", col, tableName)
+		}
+		quotedCols[i] = quoteIdentifier(dbType, col)
+		marks[i] = placeholder(dbType, i+1)
+	}
+	insertSQL := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quoteIdentifier(dbType, tableName), strings.Join(quotedCols, ", "), strings.Join(marks, ", "))
+
+	var converters []func(string) (interface{}, error)
+	bulk := opts.BulkLoad && bulkLoadSQL(dbType, tableName, header) != ""
+	if bulk {
+		insertSQL = bulkLoadSQL(dbType, tableName, header)
+		converters, err = bulkValueConverters(db, dbType, tableName, header)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare(insertSQL)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	defer stmt.Close()
+
+	count := 0
+	args := make([]interface{}, len(header))
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to read CSV line %d: %v", count+2, err)
+		}
+		for i, cell := range record {
+			switch {
+			case cell == opts.NullToken:
+				args[i] = nil
+			case bulk:
+				if args[i], err = converters[i](cell); err != nil {
+					tx.Rollback()
+					return 0, fmt.Errorf("failed to convert CSV line %d column %s: %v", count+2, header[i], err)
+				}
+			default:
+				args[i] = cell
+			}
+		}
+		if _, err := stmt.Exec(args...); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to insert CSV line %d: %v", count+2, err)
+		}
+		count++
+	}
+
+	if bulk {
+		// The bulk statements buffer rows until flushed
+		if _, err := stmt.Exec(); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to flush bulk load: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
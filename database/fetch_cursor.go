@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// forEachCursorRow reads the result of query through a server-side cursor,
+// opts.fetchSize rows at a time. lib/pq has no fetch size setting, and a plain
+// SELECT makes the server produce the whole result up front; a cursor only
+// materializes the rows of each FETCH. The MySQL and SQL Server drivers already
+// read rows off the connection as they are scanned, and SQLite steps the
+// statement row by row, so they need no cursor.
+func forEachCursorRow(db *sql.DB, dbType DBType, query string, columns []string, opts tableReadOptions, fn func(row map[string]interface{})) error {
+	// cursors only live inside a transaction; nothing is written, so it is rolled back
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DECLARE syncforge_rows NO SCROLL CURSOR FOR "+query, opts.args...); err != nil {
+		return err
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM syncforge_rows", opts.fetchSize)
+	for {
+		rows, err := tx.Query(fetch)
+		if err != nil {
+			return err
+		}
+		count, err := scanComparisonRows(rows, dbType, columns, opts, fn)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if count < opts.fetchSize {
+			return nil
+		}
+	}
+}
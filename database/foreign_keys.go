@@ -0,0 +1,287 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ForeignKeyInfo holds a foreign key constraint
+type ForeignKeyInfo struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	RefTable   string   `json:"refTable"`
+	RefColumns []string `json:"refColumns"`
+	OnDelete   string   `json:"onDelete"` // CASCADE, SET NULL, SET DEFAULT, RESTRICT, NO ACTION
+	OnUpdate   string   `json:"onUpdate"`
+}
+
+// compareForeignKeys diffs the foreign keys of a table by name. A constraint
+// can't be altered in place, so any difference, including only the referential
+// actions, is a drop and recreate.
+func compareForeignKeys(tableName string, source, target []ForeignKeyInfo, dialect DBType, q func(string) string) []DiffResult {
+	var results []DiffResult
+
+	targetMap := make(map[string]ForeignKeyInfo)
+	for _, fk := range target {
+		targetMap[fk.Name] = fk
+	}
+	sourceMap := make(map[string]ForeignKeyInfo)
+	for _, fk := range source {
+		sourceMap[fk.Name] = fk
+	}
+
+	for _, sourceFK := range source {
+		targetFK, exists := targetMap[sourceFK.Name]
+		if !exists {
+			results = append(results, DiffResult{
+				Type:      "modified",
+				TableName: tableName,
+				Detail:    fmt.Sprintf("Add foreign key: %s", sourceFK.Name),
+				SQL:       buildAddForeignKey(tableName, sourceFK, q),
+			})
+			continue
+		}
+
+		if changes := foreignKeyChanges(sourceFK, targetFK); len(changes) > 0 {
+			results = append(results, DiffResult{
+				Type:      "modified",
+				TableName: tableName,
+				Detail:    fmt.Sprintf("Recreate foreign key: %s (%s)", sourceFK.Name, strings.Join(changes, ", ")),
+				SQL:       dropForeignKeySQL(tableName, sourceFK.Name, dialect, q) + "\n" + buildAddForeignKey(tableName, sourceFK, q),
+			})
+		}
+	}
+
+	for _, targetFK := range target {
+		if _, exists := sourceMap[targetFK.Name]; !exists {
+			results = append(results, DiffResult{
+				Type:      "modified",
+				TableName: tableName,
+				Detail:    fmt.Sprintf("Drop foreign key: %s", targetFK.Name),
+				SQL:       dropForeignKeySQL(tableName, targetFK.Name, dialect, q),
+			})
+		}
+	}
+
+	return results
+}
+
+// dropForeignKeySQL drops a foreign key; only MySQL has its own DROP FOREIGN KEY form
+func dropForeignKeySQL(tableName, name string, dialect DBType, q func(string) string) string {
+	if dialect == MySQL || dialect == "" {
+		return fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s;", q(tableName), q(name))
+	}
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", q(tableName), q(name))
+}
+
+// foreignKeyChanges describes how the source constraint differs from the target
+func foreignKeyChanges(source, target ForeignKeyInfo) []string {
+	var changes []string
+	if !stringSlicesEqual(source.Columns, target.Columns) || source.RefTable != target.RefTable ||
+		!stringSlicesEqual(source.RefColumns, target.RefColumns) {
+		changes = append(changes, "columns or referenced table")
+	}
+	if normalizeFKAction(source.OnDelete) != normalizeFKAction(target.OnDelete) {
+		changes = append(changes, fmt.Sprintf("ON DELETE %s -> %s", normalizeFKAction(target.OnDelete), normalizeFKAction(source.OnDelete)))
+	}
+	if normalizeFKAction(source.OnUpdate) != normalizeFKAction(target.OnUpdate) {
+		changes = append(changes, fmt.Sprintf("ON UPDATE %s -> %s", normalizeFKAction(target.OnUpdate), normalizeFKAction(source.OnUpdate)))
+	}
+	return changes
+}
+
+// normalizeFKAction maps an unset action to NO ACTION, the SQL default
+func normalizeFKAction(action string) string {
+	action = strings.ToUpper(strings.TrimSpace(action))
+	if action == "" {
+		return "NO ACTION"
+	}
+	return action
+}
+
+func buildAddForeignKey(tableName string, fk ForeignKeyInfo, q func(string) string) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s) ON DELETE %s ON UPDATE %s;",
+		q(tableName), q(fk.Name), quoteColumnList(fk.Columns, q), q(fk.RefTable), quoteColumnList(fk.RefColumns, q),
+		normalizeFKAction(fk.OnDelete), normalizeFKAction(fk.OnUpdate))
+}
+
+// quoteColumnList quotes names and joins them into a column list
+func quoteColumnList(names []string, q func(string) string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = q(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// fkActionCodes maps pg_constraint confdeltype/confupdtype to the SQL action
+var fkActionCodes = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
+}
+
+// getForeignKeys reads the foreign keys of a table in the given dialect
+func getForeignKeys(db *sql.DB, dbType DBType, tableName string) ([]ForeignKeyInfo, error) {
+	switch dbType {
+	case PostgreSQL:
+		return getPostgreSQLForeignKeys(db, tableName)
+	case SQLServer:
+		return getSQLServerForeignKeys(db, tableName)
+	case SQLite:
+		return getSQLiteForeignKeys(db, tableName)
+	default:
+		return getMySQLForeignKeys(db, tableName)
+	}
+}
+
+func getMySQLForeignKeys(db *sql.DB, tableName string) ([]ForeignKeyInfo, error) {
+	rows, err := db.Query(`
+		SELECT kcu.CONSTRAINT_NAME, kcu.COLUMN_NAME, kcu.REFERENCED_TABLE_NAME, kcu.REFERENCED_COLUMN_NAME,
+			rc.DELETE_RULE, rc.UPDATE_RULE
+		FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE kcu
+		JOIN INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc
+			ON rc.CONSTRAINT_SCHEMA = kcu.CONSTRAINT_SCHEMA AND rc.CONSTRAINT_NAME = kcu.CONSTRAINT_NAME
+			AND rc.TABLE_NAME = kcu.TABLE_NAME
+		WHERE kcu.TABLE_SCHEMA = DATABASE() AND kcu.TABLE_NAME = ? AND kcu.REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY kcu.CONSTRAINT_NAME, kcu.ORDINAL_POSITION`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanForeignKeys(rows, nil)
+}
+
+func getPostgreSQLForeignKeys(db *sql.DB, tableName string) ([]ForeignKeyInfo, error) {
+	rows, err := db.Query(`
+		SELECT c.conname, a.attname, rt.relname, ra.attname, c.confdeltype, c.confupdtype
+		FROM pg_constraint c
+		JOIN pg_class t ON t.oid = c.conrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_class rt ON rt.oid = c.confrelid
+		CROSS JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, refattnum, ord)
+		JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+		JOIN pg_attribute ra ON ra.attrelid = c.confrelid AND ra.attnum = k.refattnum
+		WHERE c.contype = 'f' AND n.nspname = 'public' AND t.relname = $1
+		ORDER BY c.conname, k.ord`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanForeignKeys(rows, fkActionCodes)
+}
+
+func getSQLServerForeignKeys(db *sql.DB, tableName string) ([]ForeignKeyInfo, error) {
+	rows, err := db.Query(`
+		SELECT rc.CONSTRAINT_NAME, fk.COLUMN_NAME, pk.TABLE_NAME, pk.COLUMN_NAME, rc.DELETE_RULE, rc.UPDATE_RULE
+		FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc
+		JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE fk
+			ON fk.CONSTRAINT_SCHEMA = rc.CONSTRAINT_SCHEMA AND fk.CONSTRAINT_NAME = rc.CONSTRAINT_NAME
+		JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE pk
+			ON pk.CONSTRAINT_SCHEMA = rc.UNIQUE_CONSTRAINT_SCHEMA AND pk.CONSTRAINT_NAME = rc.UNIQUE_CONSTRAINT_NAME
+			AND pk.ORDINAL_POSITION = fk.ORDINAL_POSITION
+		WHERE fk.TABLE_NAME = @p1
+		ORDER BY rc.CONSTRAINT_NAME, fk.ORDINAL_POSITION`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanForeignKeys(rows, nil)
+}
+
+// getSQLiteForeignKeys reads PRAGMA foreign_key_list. SQLite doesn't keep constraint
+// names there, so each key is named after its table and columns to compare stably.
+func getSQLiteForeignKeys(db *sql.DB, tableName string) ([]ForeignKeyInfo, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA foreign_key_list('%s')", tableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fks []ForeignKeyInfo
+	byID := make(map[int]int)
+	for rows.Next() {
+		var id, seq int
+		var refTable, from, onUpdate, onDelete, match string
+		var to sql.NullString
+		if err := rows.Scan(&id, &seq, &refTable, &from, &to, &onUpdate, &onDelete, &match); err != nil {
+			return nil, err
+		}
+		i, ok := byID[id]
+		if !ok {
+			i = len(fks)
+			byID[id] = i
+			fks = append(fks, ForeignKeyInfo{RefTable: refTable, OnDelete: onDelete, OnUpdate: onUpdate})
+		}
+		fks[i].Columns = append(fks[i].Columns, from)
+		// A missing target column means the referenced table's primary key
+		fks[i].RefColumns = append(fks[i].RefColumns, to.String)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range fks {
+		fks[i].Name = fmt.Sprintf("fk_%s_%s", tableName, strings.Join(fks[i].Columns, "_"))
+	}
+	return fks, nil
+}
+
+// scanForeignKeys groups rows of (name, column, ref table, ref column, on delete,
+// on update) into constraints, translating action codes through actions when set
+func scanForeignKeys(rows *sql.Rows, actions map[string]string) ([]ForeignKeyInfo, error) {
+	var fks []ForeignKeyInfo
+	byName := make(map[string]int)
+	for rows.Next() {
+		var name, column, refTable, refColumn, onDelete, onUpdate string
+		if err := rows.Scan(&name, &column, &refTable, &refColumn, &onDelete, &onUpdate); err != nil {
+			return nil, err
+		}
+		if actions != nil {
+			onDelete, onUpdate = actions[onDelete], actions[onUpdate]
+		}
+		i, ok := byName[name]
+		if !ok {
+			i = len(fks)
+			byName[name] = i
+			fks = append(fks, ForeignKeyInfo{Name: name, RefTable: refTable, OnDelete: onDelete, OnUpdate: onUpdate})
+		}
+		fks[i].Columns = append(fks[i].Columns, column)
+		fks[i].RefColumns = append(fks[i].RefColumns, refColumn)
+	}
+	return fks, rows.Err()
+}
+
+// foreignKeyDepth ranks tables by how many levels of referenced tables they sit on:
+// a table referencing nothing is 0, one referencing a depth-0 table is 1, and so on.
+// Self references and references outside tables are ignored; cycles are cut.
+func foreignKeyDepth(tables map[string]TableInfo) map[string]int {
+	depth := make(map[string]int, len(tables))
+	visiting := make(map[string]bool)
+	var visit func(name string) int
+	visit = func(name string) int {
+		if d, ok := depth[name]; ok {
+			return d
+		}
+		if visiting[name] {
+			return 0
+		}
+		visiting[name] = true
+		d := 0
+		for _, fk := range tables[name].ForeignKeys {
+			if _, ok := tables[fk.RefTable]; !ok || fk.RefTable == name {
+				continue
+			}
+			d = max(d, visit(fk.RefTable)+1)
+		}
+		visiting[name] = false
+		depth[name] = d
+		return d
+	}
+	for name := range tables {
+		visit(name)
+	}
+	return depth
+}
@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GrantInfo holds a single privilege granted to a user or role
+type GrantInfo struct {
+	Grantee   string `json:"grantee"`
+	Privilege string `json:"privilege"`
+	Object    string `json:"object"` // table name, or "*" for the whole database
+}
+
+// CompareGrants compares user/role privileges between two databases and
+// returns the GRANT/REVOKE statements that align the target with the source.
+// This is opt-in: it is never part of CompareSchemas, and the grantees must
+// already exist on the target server.
+func CompareGrants(sourceConfig, targetConfig ConnectionConfig) ([]DiffResult, error) {
+	return CompareGrantsContext(context.Background(), sourceConfig, targetConfig)
+}
+
+// CompareGrantsContext is CompareGrants, aborting when ctx is done
+func CompareGrantsContext(ctx context.Context, sourceConfig, targetConfig ConnectionConfig) ([]DiffResult, error) {
+	sourceType := sourceConfig.Type
+	if sourceType == "" {
+		sourceType = MySQL
+	}
+	targetType := targetConfig.Type
+	if targetType == "" {
+		targetType = MySQL
+	}
+	if sourceType != targetType {
+		return nil, fmt.Errorf("grants can only be compared between databases of the same type")
+	}
+
+	sourceGrants, err := GetGrantsContext(ctx, sourceConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source grants: %v", err)
+	}
+	targetGrants, err := GetGrantsContext(ctx, targetConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read target grants: %v", err)
+	}
+	return diffGrants(targetType, targetConfig.Database, sourceGrants, targetGrants), nil
+}
+
+// diffGrants returns the GRANTs of privileges only the source holds, then the
+// REVOKEs of those only the target holds
+func diffGrants(targetType DBType, database string, sourceGrants, targetGrants []GrantInfo) []DiffResult {
+	sourceSet := make(map[GrantInfo]bool)
+	for _, g := range sourceGrants {
+		sourceSet[g] = true
+	}
+	targetSet := make(map[GrantInfo]bool)
+	for _, g := range targetGrants {
+		targetSet[g] = true
+	}
+
+	var results []DiffResult
+	for _, g := range sourceGrants {
+		if !targetSet[g] {
+			results = append(results, DiffResult{
+				Type:       "added",
+				TableName:  g.Object,
+				Detail:     fmt.Sprintf("Grant %s on %s to %s", g.Privilege, g.Object, g.Grantee),
+				SQL:        buildGrantSQL(targetType, database, g, true),
+				ObjectType: "grant",
+			})
+		}
+	}
+	for _, g := range targetGrants {
+		if !sourceSet[g] {
+			results = append(results, DiffResult{
+				Type:       "removed",
+				TableName:  g.Object,
+				Detail:     fmt.Sprintf("Revoke %s on %s from %s", g.Privilege, g.Object, g.Grantee),
+				SQL:        buildGrantSQL(targetType, database, g, false),
+				ObjectType: "grant",
+			})
+		}
+	}
+	return results
+}
+
+// GetGrants reads the privileges granted within the configured database
+func GetGrants(config ConnectionConfig) ([]GrantInfo, error) {
+	return GetGrantsContext(context.Background(), config)
+}
+
+// GetGrantsContext is GetGrants, aborting when ctx is done
+func GetGrantsContext(ctx context.Context, config ConnectionConfig) ([]GrantInfo, error) {
+	var query string
+	switch config.Type {
+	case MySQL, "":
+		query = `
+			SELECT GRANTEE, PRIVILEGE_TYPE, '*'
+			FROM INFORMATION_SCHEMA.SCHEMA_PRIVILEGES
+			WHERE TABLE_SCHEMA = DATABASE()
+			UNION ALL
+			SELECT GRANTEE, PRIVILEGE_TYPE, TABLE_NAME
+			FROM INFORMATION_SCHEMA.TABLE_PRIVILEGES
+			WHERE TABLE_SCHEMA = DATABASE()`
+	case PostgreSQL:
+		// Skip the implicit privileges table owners hold on their own tables
+		query = `
+			SELECT g.grantee, g.privilege_type, g.table_name
+			FROM information_schema.role_table_grants g
+			JOIN pg_tables t ON t.schemaname = g.table_schema AND t.tablename = g.table_name
+			WHERE g.table_schema = 'public' AND g.grantee <> t.tableowner`
+	case SQLServer:
+		query = `
+			SELECT pr.name, pe.permission_name,
+				CASE WHEN pe.class = 0 THEN '*' ELSE OBJECT_NAME(pe.major_id) END
+			FROM sys.database_permissions pe
+			JOIN sys.database_principals pr ON pe.grantee_principal_id = pr.principal_id
+			WHERE pe.state IN ('G', 'W') AND pe.class IN (0, 1)
+				AND pr.name NOT IN ('public', 'dbo', 'guest')`
+	case SQLite:
+		return nil, fmt.Errorf("SQLite has no user privileges")
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", config.Type)
+	}
+
+	db, err := ConnectContext(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return scanGrants(ctx, db, query)
+}
+
+func scanGrants(ctx context.Context, db *sql.DB, query string) ([]GrantInfo, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grants []GrantInfo
+	for rows.Next() {
+		var g GrantInfo
+		if err := rows.Scan(&g.Grantee, &g.Privilege, &g.Object); err != nil {
+			return nil, err
+		}
+		g.Privilege = strings.ToUpper(g.Privilege)
+		grants = append(grants, g)
+	}
+
+	sort.Slice(grants, func(i, j int) bool {
+		if grants[i].Grantee != grants[j].Grantee {
+			return grants[i].Grantee < grants[j].Grantee
+		}
+		if grants[i].Object != grants[j].Object {
+			return grants[i].Object < grants[j].Object
+		}
+		return grants[i].Privilege < grants[j].Privilege
+	})
+	return grants, rows.Err()
+}
+
+// buildGrantSQL renders a GRANT (or REVOKE) statement for the target dialect
+func buildGrantSQL(dbType DBType, database string, g GrantInfo, grant bool) string {
+	var object, grantee string
+	switch dbType {
+	case MySQL, "":
+		// INFORMATION_SCHEMA already reports the grantee as 'user'@'host'
+		grantee = g.Grantee
+		if g.Object == "*" {
+			object = fmt.Sprintf("`%s`.*", database)
+		} else {
+			object = fmt.Sprintf("`%s`.`%s`", database, g.Object)
+		}
+	case PostgreSQL:
+		grantee = quoteIdentifier(dbType, g.Grantee)
+		object = "TABLE " + quoteIdentifier(dbType, g.Object)
+	case SQLServer:
+		grantee = quoteIdentifier(dbType, g.Grantee)
+		if g.Object != "*" {
+			object = quoteIdentifier(dbType, g.Object)
+		}
+	}
+
+	on := ""
+	if object != "" {
+		on = " ON " + object
+	}
+	if grant {
+		return fmt.Sprintf("GRANT %s%s TO %s;", g.Privilege, on, grantee)
+	}
+	return fmt.Sprintf("REVOKE %s%s FROM %s;", g.Privilege, on, grantee)
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBuildGrantSQL(t *testing.T) {
+	tests := []struct {
+		dbType DBType
+		grant  GrantInfo
+		revoke bool
+		want   string
+	}{
+		{MySQL, GrantInfo{Grantee: "'app'@'%'", Privilege: "SELECT", Object: "orders"}, false, "GRANT SELECT ON `shop`.`orders` TO 'app'@'%';"},
+		{MySQL, GrantInfo{Grantee: "'app'@'%'", Privilege: "INSERT", Object: "*"}, true, "REVOKE INSERT ON `shop`.* FROM 'app'@'%';"},
+		{PostgreSQL, GrantInfo{Grantee: "reporting", Privilege: "SELECT", Object: "orders"}, false, `GRANT SELECT ON TABLE "orders" TO "reporting";`},
+		{SQLServer, GrantInfo{Grantee: "app", Privilege: "UPDATE", Object: "orders"}, true, "REVOKE UPDATE ON [orders] FROM [app];"},
+		{SQLServer, GrantInfo{Grantee: "app", Privilege: "CONNECT", Object: "*"}, false, "GRANT CONNECT TO [app];"},
+	}
+	for _, tt := range tests {
+		if got := buildGrantSQL(tt.dbType, "shop", tt.grant, !tt.revoke); got != tt.want {
+			t.Errorf("%s %+v: got %s, want %s", tt.dbType, tt.grant, got, tt.want)
+		}
+	}
+}
+
+func TestDiffGrants(t *testing.T) {
+	shared := GrantInfo{Grantee: "app", Privilege: "SELECT", Object: "orders"}
+	sourceOnly := GrantInfo{Grantee: "app", Privilege: "INSERT", Object: "orders"}
+	targetOnly := GrantInfo{Grantee: "intern", Privilege: "DELETE", Object: "orders"}
+
+	results := diffGrants(PostgreSQL, "shop", []GrantInfo{shared, sourceOnly}, []GrantInfo{shared, targetOnly})
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Type+" "+r.SQL)
+	}
+	want := []string{
+		`added GRANT INSERT ON TABLE "orders" TO "app";`,
+		`removed REVOKE DELETE ON TABLE "orders" FROM "intern";`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestScanGrantsSortsAndNormalizes(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "grants.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	grants, err := scanGrants(context.Background(), db, `
+		SELECT 'b', 'select', 'orders'
+		UNION ALL SELECT 'a', 'update', 'orders'
+		UNION ALL SELECT 'a', 'insert', '*'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []GrantInfo{
+		{Grantee: "a", Privilege: "INSERT", Object: "*"},
+		{Grantee: "a", Privilege: "UPDATE", Object: "orders"},
+		{Grantee: "b", Privilege: "SELECT", Object: "orders"},
+	}
+	if !reflect.DeepEqual(grants, want) {
+		t.Errorf("got %+v, want %+v", grants, want)
+	}
+}
+
+func TestCompareGrantsRejectsMixedTypes(t *testing.T) {
+	_, err := CompareGrants(ConnectionConfig{Type: PostgreSQL}, ConnectionConfig{Type: MySQL})
+	if err == nil {
+		t.Error("expected an error comparing PostgreSQL grants with MySQL")
+	}
+	sqlite := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(t.TempDir(), "a.db")}
+	if _, err := CompareGrants(sqlite, sqlite); err == nil {
+		t.Error("expected an error for SQLite, which has no privileges")
+	}
+}
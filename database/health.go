@@ -0,0 +1,114 @@
+package database
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ConnectionHealth holds the result of a connection probe
+type ConnectionHealth struct {
+	OK        bool      `json:"ok"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// HealthCache remembers connection probe results for a short time so that
+// repeatedly rendering connection status doesn't hit the network each time.
+// Entries are keyed by the server and database a config points at, never by
+// its credentials.
+type HealthCache struct {
+	ttl     time.Duration
+	probe   func(ConnectionConfig) error
+	now     func() time.Time
+	entries map[string]ConnectionHealth
+	mu      sync.Mutex
+}
+
+// NewHealthCache creates a health cache whose entries expire after ttl
+func NewHealthCache(ttl time.Duration) *HealthCache {
+	return &HealthCache{
+		ttl:     ttl,
+		probe:   TestConnection,
+		now:     time.Now,
+		entries: make(map[string]ConnectionHealth),
+	}
+}
+
+// Check returns the cached health of a connection, probing it when there is no
+// fresh entry or when forceRefresh is set
+func (c *HealthCache) Check(config ConnectionConfig, forceRefresh bool) ConnectionHealth {
+	key := healthCacheKey(config)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && !forceRefresh && c.fresh(entry) {
+		return entry
+	}
+
+	// Probe without holding the lock so slow hosts don't block other checks
+	entry = ConnectionHealth{OK: true, CheckedAt: c.now()}
+	if err := c.probe(config); err != nil {
+		entry.OK = false
+		entry.Error = err.Error()
+	}
+
+	c.mu.Lock()
+	c.evictExpired()
+	c.entries[key] = entry
+	c.mu.Unlock()
+	return entry
+}
+
+func (c *HealthCache) fresh(entry ConnectionHealth) bool {
+	return c.now().Sub(entry.CheckedAt) < c.ttl
+}
+
+// evictExpired drops stale entries, such as those of deleted or edited
+// connections, so the cache doesn't grow; c.mu must be held
+func (c *HealthCache) evictExpired() {
+	for key, entry := range c.entries {
+		if !c.fresh(entry) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// CheckAll checks every saved connection concurrently, keyed by connection name
+func (c *HealthCache) CheckAll(conns []SavedConnection, forceRefresh bool) map[string]ConnectionHealth {
+	result := make(map[string]ConnectionHealth, len(conns))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, conn := range conns {
+		wg.Add(1)
+		go func(conn SavedConnection) {
+			defer wg.Done()
+			health := c.Check(conn.Config, forceRefresh)
+			mu.Lock()
+			result[conn.Name] = health
+			mu.Unlock()
+		}(conn)
+	}
+	wg.Wait()
+	return result
+}
+
+// Invalidate drops the cached entry for a connection
+func (c *HealthCache) Invalidate(config ConnectionConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, healthCacheKey(config))
+}
+
+// healthCacheKey identifies the server and database a config points at.
+// Passwords and other secrets are left out so they are never held as keys.
+func healthCacheKey(config ConnectionConfig) string {
+	key := fmt.Sprintf("%s|%s|%d|%s|%s|%s", displayDBType(config.Type), config.Host, config.Port,
+		config.User, config.Database, config.FilePath)
+	if t := config.SSHTunnel; t != nil {
+		key += fmt.Sprintf("|ssh:%s@%s:%d", t.User, t.Host, t.Port)
+	}
+	return key
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthCacheCheck(t *testing.T) {
+	config := ConnectionConfig{Type: PostgreSQL, Host: "db", Port: 5432, User: "app", Password: "s3cret", Database: "shop"}
+	newPassword := config
+	newPassword.Password = "rotated"
+	otherDatabase := config
+	otherDatabase.Database = "crm"
+
+	type step struct {
+		advance      time.Duration
+		config       ConnectionConfig
+		forceRefresh bool
+		wantProbes   int
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"cache hit within ttl", []step{{0, config, false, 1}, {20 * time.Second, config, false, 1}}},
+		{"expired after ttl", []step{{0, config, false, 1}, {30 * time.Second, config, false, 2}}},
+		{"force refresh", []step{{0, config, false, 1}, {time.Second, config, true, 2}, {time.Second, config, false, 2}}},
+		{"password change shares the entry", []step{{0, config, false, 1}, {time.Second, newPassword, false, 1}}},
+		{"other database probed separately", []step{{0, config, false, 1}, {time.Second, otherDatabase, false, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+			probes := 0
+			cache := NewHealthCache(30 * time.Second)
+			cache.now = func() time.Time { return now }
+			cache.probe = func(ConnectionConfig) error {
+				probes++
+				return nil
+			}
+			for i, s := range tt.steps {
+				now = now.Add(s.advance)
+				if health := cache.Check(s.config, s.forceRefresh); !health.OK {
+					t.Fatalf("step %d: health %+v", i, health)
+				}
+				if probes != s.wantProbes {
+					t.Errorf("step %d: %d probes, want %d", i, probes, s.wantProbes)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthCacheEvictsExpiredEntries(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewHealthCache(30 * time.Second)
+	cache.now = func() time.Time { return now }
+	cache.probe = func(ConnectionConfig) error { return errors.New("connection refused") }
+
+	cache.Check(ConnectionConfig{Host: "old"}, false)
+	now = now.Add(time.Minute)
+	health := cache.Check(ConnectionConfig{Host: "new"}, false)
+
+	if health.OK || health.Error != "connection refused" {
+		t.Errorf("health = %+v, want the probe error", health)
+	}
+	if len(cache.entries) != 1 {
+		t.Errorf("%d entries cached, want the expired one evicted", len(cache.entries))
+	}
+}
+
+func TestHealthCacheKeyOmitsSecrets(t *testing.T) {
+	key := healthCacheKey(ConnectionConfig{
+		Host:        "db",
+		Password:    "s3cret",
+		PasswordRef: "env:DB_PASSWORD",
+		SSHTunnel:   &SSHTunnelConfig{Host: "bastion", Password: "tunnel-pw", Passphrase: "key-pass"},
+	})
+	for _, secret := range []string{"s3cret", "env:DB_PASSWORD", "tunnel-pw", "key-pass"} {
+		if strings.Contains(key, secret) {
+			t.Errorf("key contains %q: %s", secret, key)
+		}
+	}
+}
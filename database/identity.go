@@ -0,0 +1,67 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+// IdentityInfo holds the options of an identity column. Generation is ALWAYS or
+// BY DEFAULT on PostgreSQL and empty on SQL Server, which has only one kind.
+type IdentityInfo struct {
+	Generation string `json:"generation,omitempty"`
+	Start      int64  `json:"start"`
+	Increment  int64  `json:"increment"`
+}
+
+// definition renders the identity as a column attribute in its own dialect
+func (id *IdentityInfo) definition() string {
+	if id.Generation == "" {
+		return fmt.Sprintf("IDENTITY(%d,%d)", id.Start, id.Increment)
+	}
+	return fmt.Sprintf("GENERATED %s AS IDENTITY (START WITH %d INCREMENT BY %d)", id.Generation, id.Start, id.Increment)
+}
+
+func identitiesEqual(a, b *IdentityInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func formatIdentity(id *IdentityInfo) string {
+	if id == nil {
+		return "none"
+	}
+	return id.definition()
+}
+
+// postgreSQLIdentity builds the identity of a column from information_schema.columns,
+// which reports the options as text; nil when the column isn't an identity
+func postgreSQLIdentity(isIdentity, generation, start, increment sql.NullString) *IdentityInfo {
+	if isIdentity.String != "YES" {
+		return nil
+	}
+	id := &IdentityInfo{Generation: generation.String, Start: 1, Increment: 1}
+	if n, err := strconv.ParseInt(start.String, 10, 64); err == nil {
+		id.Start = n
+	}
+	if n, err := strconv.ParseInt(increment.String, 10, 64); err == nil {
+		id.Increment = n
+	}
+	return id
+}
+
+// alterIdentitySQL changes the identity of a PostgreSQL column to source's. Changing
+// the start only affects a later RESTART; values already handed out are kept.
+func alterIdentitySQL(table, column string, source, target *IdentityInfo) string {
+	switch {
+	case source == nil:
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP IDENTITY IF EXISTS;", table, column)
+	case target == nil:
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s ADD %s;", table, column, source.definition())
+	default:
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET GENERATED %s SET START WITH %d SET INCREMENT BY %d;",
+			table, column, source.Generation, source.Start, source.Increment)
+	}
+}
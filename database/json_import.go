@@ -0,0 +1,170 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// JSON import modes
+const (
+	ImportInsert = "insert"
+	ImportUpsert = "upsert" // overwrite rows whose primary key already exists
+)
+
+// jsonImportBatchRows is how many rows go into one INSERT; batches shrink for wide
+// tables to stay under SQL Server's limit of 2100 parameters per statement
+const jsonImportBatchRows = 100
+
+var (
+	binaryTypePattern  = regexp.MustCompile(`^(binary|varbinary|(tiny|medium|long)?blob|bytea|image)\b`)
+	integerTypePattern = regexp.MustCompile(`^((tiny|small|medium|big)?int(eger|[248])?|(small|big)?serial)\b`)
+)
+
+func isBinaryType(dataType string) bool {
+	return binaryTypePattern.MatchString(strings.ToLower(strings.TrimSpace(dataType)))
+}
+
+// ImportTableJSON inserts the rows of a file written by ExportTableJSON into a table
+// inside a single transaction, converting JSON values to the column types: numbers
+// to integers or exact decimals, base64 strings to bytes for binary columns, and
+// null or missing keys to NULL. Mode ImportUpsert overwrites rows with the same
+// primary key instead of failing on them. Returns the number of rows imported.
+func ImportTableJSON(config ConnectionConfig, tableName string, r io.Reader, mode string) (int, error) {
+	if mode == "" {
+		mode = ImportInsert
+	}
+	if mode != ImportInsert && mode != ImportUpsert {
+		return 0, fmt.Errorf("unknown import mode: %s", mode)
+	}
+
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var data tableExport
+	if err := dec.Decode(&data); err != nil {
+		return 0, fmt.Errorf("failed to read JSON: %v", err)
+	}
+	if len(data.Columns) == 0 {
+		return 0, fmt.Errorf("JSON has no columns")
+	}
+
+	db, err := Connect(config)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	dbType := config.Type
+	if dbType == "" {
+		dbType = MySQL
+	}
+
+	types, err := getColumnDataTypes(db, dbType, config.Database, tableName)
+	if err != nil {
+		return 0, err
+	}
+	for _, col := range data.Columns {
+		if _, ok := types[col]; !ok {
+			return 0, fmt.Errorf("column %s does not exist in table %s", col, tableName)
+		}
+	}
+
+	var primaryKeys []string
+	if mode == ImportUpsert {
+		primaryKeys, err = getPrimaryKeys(db, dbType, config.Database, tableName)
+		if err != nil {
+			return 0, err
+		}
+		if len(primaryKeys) == 0 {
+			return 0, fmt.Errorf("table %s has no primary key, rows cannot be upserted", tableName)
+		}
+		for _, pk := range primaryKeys {
+			if !containsString(data.Columns, pk) {
+				return 0, fmt.Errorf("JSON lacks primary key column %s needed to upsert", pk)
+			}
+		}
+	}
+
+	rows := make([]map[string]interface{}, len(data.Rows))
+	for i, raw := range data.Rows {
+		row := make(map[string]interface{}, len(data.Columns))
+		for _, col := range data.Columns {
+			val, err := coerceJSONValue(raw[col], types[col])
+			if err != nil {
+				return 0, fmt.Errorf("row %d column %s: %v", i+1, col, err)
+			}
+			row[col] = val
+		}
+		rows[i] = row
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	batch := max(min(jsonImportBatchRows, 2000/len(data.Columns)), 1)
+	for start := 0; start < len(rows); start += batch {
+		chunk := rows[start:min(start+batch, len(rows))]
+		values := &sqlValues{dbType: dbType, bind: true}
+		var query string
+		if mode == ImportUpsert {
+			query = buildUpsertSQL(values, tableName, chunk, data.Columns, primaryKeys)
+		} else {
+			query = buildMultiInsertSQL(values, tableName, chunk, data.Columns)
+		}
+		if _, err := tx.Exec(query, values.args...); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to import rows %d-%d: %v", start+1, start+len(chunk), err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// coerceJSONValue converts a value decoded with UseNumber to what the column type takes
+func coerceJSONValue(val interface{}, dataType string) (interface{}, error) {
+	t := strings.ToLower(strings.TrimSpace(dataType))
+	switch v := val.(type) {
+	case nil:
+		return nil, nil
+	case json.Number:
+		if integerTypePattern.MatchString(t) {
+			if n, err := v.Int64(); err == nil {
+				return n, nil
+			}
+		}
+		// Decimals keep their exact digits as text
+		return v.String(), nil
+	case string:
+		if isBinaryType(t) {
+			b, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return nil, fmt.Errorf("binary value is not base64: %v", err)
+			}
+			return b, nil
+		}
+		return v, nil
+	case bool:
+		if numericTypePattern.MatchString(t) && !strings.HasPrefix(t, "bool") {
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+		return v, nil
+	case map[string]interface{}, []interface{}:
+		// JSON columns round-trip as their text
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	default:
+		return nil, fmt.Errorf("unsupported JSON value %v", v)
+	}
+}
@@ -0,0 +1,506 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// slotWaitTimeout bounds how long a query queues for a free slot when its
+// context has no deadline of its own
+var slotWaitTimeout = 2 * time.Minute
+
+// querySlots holds one semaphore per database, shared by every *sql.DB opened to it
+var querySlots = struct {
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}{slots: make(map[string]chan struct{})}
+
+// errNoFreeSlot is returned when the wait for a slot timed out, as opposed to
+// the server not answering
+var errNoFreeSlot = errors.New("timed out waiting for a free query slot")
+
+// limitKey identifies the database and role a config connects as. Source and
+// target of a comparison on one server get separate limits, so holding a cursor
+// open on one side never starves the queries on the other.
+func limitKey(config ConnectionConfig) string {
+	if config.Type == SQLite {
+		return fmt.Sprintf("sqlite|%s", config.FilePath)
+	}
+	return fmt.Sprintf("%s|%s|%d|%s|%s", displayDBType(config.Type), config.Host, config.Port, config.User, config.Database)
+}
+
+// queryLimit is how many queries may run at once against the config's database:
+// MaxConcurrentQueries, falling back to the pool's MaxOpenConns
+func (c ConnectionConfig) queryLimit() int {
+	if c.MaxConcurrentQueries > 0 {
+		return c.MaxConcurrentQueries
+	}
+	if c.MaxOpenConns > 0 {
+		return c.MaxOpenConns
+	}
+	return DefaultMaxOpenConns
+}
+
+// databaseSlots returns the semaphore for the database, resizing it when the limit changed.
+// Holders of the previous semaphore release into it, so in-flight work is unaffected.
+func databaseSlots(config ConnectionConfig) chan struct{} {
+	limit := config.queryLimit()
+
+	key := limitKey(config)
+	querySlots.mu.Lock()
+	defer querySlots.mu.Unlock()
+
+	slots, ok := querySlots.slots[key]
+	if !ok || cap(slots) != limit {
+		slots = make(chan struct{}, limit)
+		querySlots.slots[key] = slots
+	}
+	return slots
+}
+
+// acquireSlot takes one of slots, waiting until one is free, ctx or bound is done,
+// or slotWaitTimeout passed for a ctx without a deadline
+func acquireSlot(ctx, bound context.Context, slots chan struct{}) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, slotWaitTimeout)
+		defer cancel()
+	}
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-bound.Done():
+		return bound.Err()
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return errNoFreeSlot
+		}
+		return ctx.Err()
+	}
+}
+
+// limitedConnector hands out connections that gate every query on a per-database
+// semaphore, so at most cap(slots) queries run against the database at once however
+// many pools are open to it. Idle connections hold no slot.
+// It also binds the connections to the context the *sql.DB was opened with.
+type limitedConnector struct {
+	base   driver.Connector
+	slots  chan struct{}
+	bound  context.Context
+	tunnel *sshTunnel
+}
+
+func (c *limitedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	dialCtx, stop := bindContext(ctx, c.bound)
+	conn, err := c.base.Connect(dialCtx)
+	stop()
+	if err != nil {
+		return nil, boundError(c.bound, err)
+	}
+	return &limitedConn{Conn: conn, slots: c.slots, bound: c.bound}, nil
+}
+
+// bindContext returns a context that is done when either ctx or bound is.
+// stop releases the link to bound; it is safe to skip when the result must
+// outlive the call (rows), since bound is always cancelled by its owner.
+func bindContext(ctx, bound context.Context) (context.Context, func()) {
+	if bound.Done() == nil {
+		return ctx, func() {}
+	}
+	merged, cancel := context.WithCancel(ctx)
+	if bound.Err() != nil {
+		// AfterFunc would cancel asynchronously; a done context must fail the call now
+		cancel()
+		return merged, func() {}
+	}
+	stop := context.AfterFunc(bound, cancel)
+	return merged, func() { stop() }
+}
+
+// boundError reports the bound context's error when it is why an operation failed
+func boundError(bound context.Context, err error) error {
+	if err != nil && bound.Err() != nil {
+		return bound.Err()
+	}
+	return err
+}
+
+func (c *limitedConnector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// Close is called by (*sql.DB).Close and tears down the SSH tunnel, if any
+func (c *limitedConnector) Close() error {
+	if c.tunnel != nil {
+		return c.tunnel.Close()
+	}
+	return nil
+}
+
+// dsnConnector adapts drivers that don't implement driver.DriverContext
+type dsnConnector struct {
+	dsn string
+	drv driver.Driver
+}
+
+func (c dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.drv.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.drv
+}
+
+// newLimitedConnector wraps the driver's connector with the database's semaphore
+// and binds its connections to ctx. Connections are dialed through tunnel when set.
+func newLimitedConnector(ctx context.Context, drv driver.Driver, dsn string, config ConnectionConfig, tunnel *sshTunnel) (driver.Connector, error) {
+	var base driver.Connector = dsnConnector{dsn: dsn, drv: drv}
+	if tunnel != nil {
+		var err error
+		base, err = tunnel.connector(config.Type, dsn)
+		if err != nil {
+			return nil, err
+		}
+	} else if dc, ok := drv.(driver.DriverContext); ok {
+		var err error
+		base, err = dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &limitedConnector{base: base, slots: databaseSlots(config), bound: ctx, tunnel: tunnel}, nil
+}
+
+// limitedConn takes a slot for each query, held until its rows are closed, and
+// for each transaction, held until it ends; statements inside a transaction run
+// on its slot. It forwards the optional driver interfaces so database/sql keeps
+// using the driver's fast paths. Every call is also cancelled when the context
+// the connection is bound to is done, and queries that fail because the server
+// dropped the connection are retried by database/sql on a fresh one. Exec is not
+// retried: the statement may have run.
+type limitedConn struct {
+	driver.Conn
+	slots chan struct{}
+	bound context.Context
+	inTx  bool // a transaction holds the slot its statements run on
+	lost  bool // the server dropped the connection; database/sql must not reuse it
+}
+
+// acquire takes a slot for one statement, unless a transaction already holds one.
+// The returned release gives it back and is safe to call more than once.
+func (c *limitedConn) acquire(ctx context.Context) (func(), error) {
+	if c.inTx {
+		return func() {}, nil
+	}
+	if err := acquireSlot(ctx, c.bound, c.slots); err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	return func() { once.Do(func() { <-c.slots }) }, nil
+}
+
+func (c *limitedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.bound.Err() != nil {
+		return nil, c.bound.Err()
+	}
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The rows outlive this call, so the link to the bound context stays
+	ctx, _ = bindContext(ctx, c.bound)
+	rows, err := q.QueryContext(ctx, query, args)
+	if err != nil {
+		release()
+		if c.bound.Err() == nil && isConnectionLost(err) {
+			// Reads are safe to repeat; ErrBadConn makes database/sql retry on a new connection
+			c.lost = true
+			return nil, driver.ErrBadConn
+		}
+		return nil, boundError(c.bound, err)
+	}
+	return &limitedRows{Rows: rows, release: release}, nil
+}
+
+func (c *limitedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c.bound.Err() != nil {
+		return nil, c.bound.Err()
+	}
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	ctx, stop := bindContext(ctx, c.bound)
+	defer stop()
+	result, err := e.ExecContext(ctx, query, args)
+	return result, boundError(c.bound, err)
+}
+
+func (c *limitedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if c.bound.Err() != nil {
+		return nil, c.bound.Err()
+	}
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &limitedStmt{Stmt: stmt, conn: c}, nil
+}
+
+func (c *limitedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *limitedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if c.bound.Err() != nil {
+		return nil, c.bound.Err()
+	}
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var tx driver.Tx
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		// A transaction lives until commit/rollback, so the link stays
+		txCtx, _ := bindContext(ctx, c.bound)
+		tx, err = b.BeginTx(txCtx, opts)
+		err = boundError(c.bound, err)
+	} else {
+		tx, err = c.Conn.Begin() //nolint:staticcheck // fallback for drivers without BeginTx
+	}
+	if err != nil {
+		release()
+		return nil, err
+	}
+	c.inTx = true
+	return &limitedTx{Tx: tx, conn: c, release: release}, nil
+}
+
+func (c *limitedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *limitedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		release, err := c.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+		ctx, stop := bindContext(ctx, c.bound)
+		defer stop()
+		err = p.Ping(ctx)
+		if c.bound.Err() == nil && isConnectionLost(err) {
+			c.lost = true
+			return driver.ErrBadConn
+		}
+		return boundError(c.bound, err)
+	}
+	return c.bound.Err()
+}
+
+func (c *limitedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *limitedConn) ResetSession(ctx context.Context) error {
+	if c.lost {
+		return driver.ErrBadConn
+	}
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *limitedConn) IsValid() bool {
+	if c.lost {
+		return false
+	}
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// limitedTx releases the transaction's slot when it ends
+type limitedTx struct {
+	driver.Tx
+	conn    *limitedConn
+	release func()
+}
+
+func (t *limitedTx) Commit() error {
+	defer t.end()
+	return t.Tx.Commit()
+}
+
+func (t *limitedTx) Rollback() error {
+	defer t.end()
+	return t.Tx.Rollback()
+}
+
+func (t *limitedTx) end() {
+	t.conn.inTx = false
+	t.release()
+}
+
+// limitedStmt gates a prepared statement's executions like limitedConn gates queries
+type limitedStmt struct {
+	driver.Stmt
+	conn *limitedConn
+}
+
+func (s *limitedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	release, err := s.conn.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	ctx, stop := bindContext(ctx, s.conn.bound)
+	defer stop()
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err := e.ExecContext(ctx, args)
+		return result, boundError(s.conn.bound, err)
+	}
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Exec(values) //nolint:staticcheck // fallback for drivers without StmtExecContext
+}
+
+func (s *limitedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	release, err := s.conn.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rows driver.Rows
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		queryCtx, _ := bindContext(ctx, s.conn.bound)
+		rows, err = q.QueryContext(queryCtx, args)
+		err = boundError(s.conn.bound, err)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			rows, err = s.Stmt.Query(values) //nolint:staticcheck // fallback for drivers without StmtQueryContext
+		}
+	}
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &limitedRows{Rows: rows, release: release}, nil
+}
+
+// CheckNamedValue defers to the statement's checker, then the connection's, so
+// wrapping the statement doesn't hide the driver's argument conversions
+func (s *limitedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+func (s *limitedStmt) ColumnConverter(idx int) driver.ValueConverter {
+	if c, ok := s.Stmt.(driver.ColumnConverter); ok { //nolint:staticcheck // still used by some drivers
+		return c.ColumnConverter(idx)
+	}
+	return driver.DefaultParameterConverter
+}
+
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("driver does not support named parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+// limitedRows releases the query's slot once the rows are closed. The column type
+// methods report what database/sql assumes when the driver's rows lack them.
+type limitedRows struct {
+	driver.Rows
+	release func()
+}
+
+func (r *limitedRows) Close() error {
+	defer r.release()
+	return r.Rows.Close()
+}
+
+func (r *limitedRows) HasNextResultSet() bool {
+	if n, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return n.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *limitedRows) NextResultSet() error {
+	if n, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return n.NextResultSet()
+	}
+	return io.EOF
+}
+
+func (r *limitedRows) ColumnTypeScanType(index int) reflect.Type {
+	if t, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return t.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(any)).Elem()
+}
+
+func (r *limitedRows) ColumnTypeDatabaseTypeName(index int) string {
+	if t, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return t.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *limitedRows) ColumnTypeLength(index int) (int64, bool) {
+	if t, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return t.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *limitedRows) ColumnTypeNullable(index int) (bool, bool) {
+	if t, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return t.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *limitedRows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	if t, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return t.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,220 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDriver counts the queries running at once. A query runs from the call
+// until its rows are closed; queryErrs fail the first queries in turn.
+type fakeDriver struct {
+	delay time.Duration
+
+	mu         sync.Mutex
+	running    int
+	maxRunning int
+	queryErrs  []error
+	opened     int
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.opened++
+	return &fakeConn{d: d}, nil
+}
+
+func (d *fakeDriver) enter() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.queryErrs) > 0 {
+		err := d.queryErrs[0]
+		d.queryErrs = d.queryErrs[1:]
+		return err
+	}
+	d.running++
+	d.maxRunning = max(d.maxRunning, d.running)
+	return nil
+}
+
+func (d *fakeDriver) leave() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.running--
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return fakeTx{}, nil }
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.d.enter(); err != nil {
+		return nil, err
+	}
+	time.Sleep(c.d.delay)
+	return &fakeRows{d: c.d}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.d.enter(); err != nil {
+		return nil, err
+	}
+	defer c.d.leave()
+	time.Sleep(c.d.delay)
+	return driver.RowsAffected(1), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	d    *fakeDriver
+	done bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+
+func (r *fakeRows) Close() error {
+	r.d.leave()
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+// openFake opens a pool on drv limited like one against config's server
+func openFake(t *testing.T, drv *fakeDriver, config ConnectionConfig) *sql.DB {
+	t.Helper()
+	connector, err := newLimitedConnector(context.Background(), drv, "", config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(connector)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestLimitedConnectorCapsConcurrentQueries(t *testing.T) {
+	for _, limit := range []int{1, 3} {
+		drv := &fakeDriver{delay: 10 * time.Millisecond}
+		config := ConnectionConfig{Type: SQLite, FilePath: t.Name(), MaxConcurrentQueries: limit}
+		// Two pools to the same server share its limit
+		dbs := []*sql.DB{openFake(t, drv, config), openFake(t, drv, config)}
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 24)
+		for i := 0; i < 24; i++ {
+			wg.Add(1)
+			go func(db *sql.DB, i int) {
+				defer wg.Done()
+				var err error
+				switch i % 3 {
+				case 0:
+					_, err = db.Exec("UPDATE t SET n = 1")
+				case 1:
+					var n int
+					err = db.QueryRow("SELECT n FROM t").Scan(&n)
+				default:
+					var tx *sql.Tx
+					if tx, err = db.Begin(); err == nil {
+						if _, err = tx.Exec("UPDATE t SET n = 1"); err == nil {
+							err = tx.Commit()
+						}
+					}
+				}
+				errs <- err
+			}(dbs[i%2], i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("limit %d: %v", limit, err)
+			}
+		}
+
+		if drv.maxRunning > limit {
+			t.Errorf("limit %d: %d queries ran at once", limit, drv.maxRunning)
+		}
+		if drv.maxRunning < limit {
+			t.Errorf("limit %d: only %d queries ran at once", limit, drv.maxRunning)
+		}
+	}
+}
+
+func TestLimitedConnectorIdleConnectionsHoldNoSlot(t *testing.T) {
+	drv := &fakeDriver{}
+	config := ConnectionConfig{Type: SQLite, FilePath: t.Name(), MaxConcurrentQueries: 1}
+	source, target := openFake(t, drv, config), openFake(t, drv, config)
+
+	if _, err := source.Exec("UPDATE t SET n = 1"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := target.ExecContext(ctx, "UPDATE t SET n = 1"); err != nil {
+		t.Fatalf("query behind an idle connection of another pool: %v", err)
+	}
+}
+
+func TestLimitedConnectorGivesUpWaiting(t *testing.T) {
+	defer func(timeout time.Duration) { slotWaitTimeout = timeout }(slotWaitTimeout)
+	slotWaitTimeout = 20 * time.Millisecond
+
+	drv := &fakeDriver{}
+	config := ConnectionConfig{Type: SQLite, FilePath: t.Name(), MaxConcurrentQueries: 1}
+	db := openFake(t, drv, config)
+
+	rows, err := db.Query("SELECT n FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	if _, err := db.Exec("UPDATE t SET n = 1"); !errors.Is(err, errNoFreeSlot) {
+		t.Errorf("got %v, want %v", err, errNoFreeSlot)
+	}
+}
+
+func TestLimitedConnectorSourceAndTargetOnOneServer(t *testing.T) {
+	defer func(timeout time.Duration) { slotWaitTimeout = timeout }(slotWaitTimeout)
+	slotWaitTimeout = 100 * time.Millisecond
+
+	drv := &fakeDriver{}
+	server := ConnectionConfig{Type: PostgreSQL, Host: t.Name(), Port: 5432, User: "app", MaxConcurrentQueries: 1}
+	sourceConfig, targetConfig := server, server
+	sourceConfig.Database, targetConfig.Database = "shop", "shop_copy"
+	source, target := openFake(t, drv, sourceConfig), openFake(t, drv, targetConfig)
+
+	// A comparison streams the source while it queries the target
+	rows, err := source.Query("SELECT n FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var n int
+		if err := target.QueryRow("SELECT n FROM t").Scan(&n); err != nil {
+			t.Fatalf("target query while the source cursor is open: %v", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
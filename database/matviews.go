@@ -0,0 +1,96 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// MaterializedViewInfo holds a PostgreSQL materialized view
+type MaterializedViewInfo struct {
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+	Populated  bool   `json:"populated"`
+}
+
+// getPostgreSQLMaterializedViews reads the materialized views of the public schema
+func getPostgreSQLMaterializedViews(db *sql.DB) (map[string]MaterializedViewInfo, error) {
+	rows, err := db.Query(`
+		SELECT matviewname, definition, ispopulated
+		FROM pg_matviews
+		WHERE schemaname = 'public'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	views := make(map[string]MaterializedViewInfo)
+	for rows.Next() {
+		var mv MaterializedViewInfo
+		if err := rows.Scan(&mv.Name, &mv.Definition, &mv.Populated); err != nil {
+			return nil, err
+		}
+		views[mv.Name] = mv
+	}
+	return views, nil
+}
+
+// compareMaterializedViews diffs materialized views. A definition can't be
+// altered in place, so a changed view is dropped and recreated.
+func compareMaterializedViews(source, target map[string]MaterializedViewInfo) []DiffResult {
+	var results []DiffResult
+	const refreshNote = "; refresh later with REFRESH MATERIALIZED VIEW (CONCURRENTLY needs a unique index)"
+
+	for name, sourceView := range source {
+		targetView, exists := target[name]
+		switch {
+		case !exists:
+			results = append(results, DiffResult{
+				Type:       "added",
+				TableName:  name,
+				Detail:     "Materialized view exists in source but not in target" + refreshNote,
+				SQL:        buildCreateMaterializedView(sourceView),
+				ObjectType: "materialized_view",
+			})
+		case normalizeSQLBody(sourceView.Definition) != normalizeSQLBody(targetView.Definition):
+			results = append(results, DiffResult{
+				Type:       "modified",
+				TableName:  name,
+				Detail:     "Materialized view definition differs, recreating" + refreshNote,
+				SQL:        fmt.Sprintf("DROP MATERIALIZED VIEW \"%s\";\n%s", name, buildCreateMaterializedView(sourceView)),
+				ObjectType: "materialized_view",
+			})
+		case sourceView.Populated && !targetView.Populated:
+			results = append(results, DiffResult{
+				Type:       "modified",
+				TableName:  name,
+				Detail:     "Materialized view is not populated in target",
+				SQL:        fmt.Sprintf("REFRESH MATERIALIZED VIEW \"%s\";", name),
+				ObjectType: "materialized_view",
+			})
+		}
+	}
+
+	for name := range target {
+		if _, exists := source[name]; !exists {
+			results = append(results, DiffResult{
+				Type:       "removed",
+				TableName:  name,
+				Detail:     "Materialized view exists in target but not in source",
+				SQL:        fmt.Sprintf("DROP MATERIALIZED VIEW \"%s\";", name),
+				ObjectType: "materialized_view",
+			})
+		}
+	}
+
+	return results
+}
+
+func buildCreateMaterializedView(mv MaterializedViewInfo) string {
+	withData := "WITH NO DATA"
+	if mv.Populated {
+		withData = "WITH DATA"
+	}
+	definition := strings.TrimSuffix(strings.TrimSpace(mv.Definition), ";")
+	return fmt.Sprintf("CREATE MATERIALIZED VIEW \"%s\" AS\n%s\n%s;", mv.Name, definition, withData)
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var migrationNameCleaner = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// FlywayFileName returns the Flyway versioned migration name, e.g. V1__add_users.sql
+func FlywayFileName(version, description string) string {
+	version = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(version), "V"), "v")
+	return fmt.Sprintf("V%s__%s.sql", version, migrationSlug(description))
+}
+
+// migrationSlug turns a free-form description into a file-name friendly form
+func migrationSlug(description string) string {
+	slug := strings.Trim(migrationNameCleaner.ReplaceAllString(description, "_"), "_")
+	if slug == "" {
+		return "migration"
+	}
+	return strings.ToLower(slug)
+}
+
+// ExportFlywayMigration writes the diff statements as a Flyway SQL migration
+func ExportFlywayMigration(diffs []DiffResult, version, description string, w io.Writer) error {
+	if strings.TrimSpace(version) == "" {
+		return fmt.Errorf("migration version is required")
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "-- %s\n", FlywayFileName(version, description))
+	if description != "" {
+		fmt.Fprintf(bw, "-- %s\n", description)
+	}
+	fmt.Fprintf(bw, "-- Generated by SyncForge on %s\n", time.Now().Format("2006-01-02 15:04:05"))
+
+	for _, stmt := range migrationStatements(diffs) {
+		fmt.Fprintf(bw, "\n%s\n", stmt)
+	}
+	return bw.Flush()
+}
+
+// ExportAlembicMigration writes an Alembic revision script. upDiffs become upgrade()
+// and downDiffs, usually the reverse comparison (target against source), become downgrade().
+func ExportAlembicMigration(upDiffs, downDiffs []DiffResult, revision, downRevision, description string, w io.Writer) error {
+	if strings.TrimSpace(revision) == "" {
+		return fmt.Errorf("migration revision is required")
+	}
+
+	down := "None"
+	if downRevision != "" {
+		down = strconv.Quote(downRevision)
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "\"\"\"%s\n\nRevision ID: %s\nRevises: %s\nCreate Date: %s\n\nGenerated by SyncForge\n\"\"\"\n",
+		strings.ReplaceAll(description, `"""`, `'''`), revision, downRevision, time.Now().Format("2006-01-02 15:04:05"))
+	bw.WriteString("from alembic import op\n\n")
+	fmt.Fprintf(bw, "revision = %s\n", strconv.Quote(revision))
+	fmt.Fprintf(bw, "down_revision = %s\n", down)
+	bw.WriteString("branch_labels = None\ndepends_on = None\n")
+
+	writeAlembicFunction(bw, "upgrade", migrationStatements(upDiffs))
+	writeAlembicFunction(bw, "downgrade", migrationStatements(downDiffs))
+	return bw.Flush()
+}
+
+func writeAlembicFunction(w *bufio.Writer, name string, statements []string) {
+	fmt.Fprintf(w, "\n\ndef %s():\n", name)
+	if len(statements) == 0 {
+		w.WriteString("    pass\n")
+		return
+	}
+	for _, stmt := range statements {
+		fmt.Fprintf(w, "    op.execute(%s)\n", strconv.Quote(stmt))
+	}
+}
+
+// migrationStatements collects the non-empty SQL of the diffs in order
+func migrationStatements(diffs []DiffResult) []string {
+	var statements []string
+	for _, diff := range diffs {
+		stmt := strings.TrimSpace(diff.SQL)
+		if stmt == "" {
+			continue
+		}
+		statements = append(statements, stmt)
+	}
+	return statements
+}
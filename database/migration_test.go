@@ -0,0 +1,77 @@
+package database
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFlywayFileName(t *testing.T) {
+	tests := []struct {
+		version, description, want string
+	}{
+		{"1", "add users", "V1__add_users.sql"},
+		{"V2.1", "Add  orders & items!", "V2.1__add_orders_items.sql"},
+		{" v3 ", "", "V3__migration.sql"},
+	}
+	for _, tt := range tests {
+		if got := FlywayFileName(tt.version, tt.description); got != tt.want {
+			t.Errorf("FlywayFileName(%q, %q) = %q, want %q", tt.version, tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestExportFlywayMigration(t *testing.T) {
+	diffs := []DiffResult{
+		{SQL: "CREATE TABLE users (id INT);"},
+		{SQL: "  "},
+		{SQL: "ALTER TABLE orders ADD COLUMN user_id INT;\n"},
+	}
+	var buf bytes.Buffer
+	if err := ExportFlywayMigration(diffs, "4", "add users", &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "-- V4__add_users.sql\n-- add users\n") {
+		t.Errorf("missing header:\n%s", out)
+	}
+	if !strings.HasSuffix(out, "\nCREATE TABLE users (id INT);\n\nALTER TABLE orders ADD COLUMN user_id INT;\n") {
+		t.Errorf("statements not written in order:\n%s", out)
+	}
+
+	if err := ExportFlywayMigration(diffs, " ", "", &buf); err == nil {
+		t.Error("expected an error without a version")
+	}
+}
+
+func TestExportAlembicMigration(t *testing.T) {
+	up := []DiffResult{{SQL: `CREATE TABLE "users" (name TEXT DEFAULT 'x');`}}
+	var buf bytes.Buffer
+	if err := ExportAlembicMigration(up, nil, "ab12", "", `add """users"""`, &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`"""add '''users'''` + "\n",
+		"revision = \"ab12\"\n",
+		"down_revision = None\n",
+		"def upgrade():\n    op.execute(\"CREATE TABLE \\\"users\\\" (name TEXT DEFAULT 'x');\")\n",
+		"def downgrade():\n    pass\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := ExportAlembicMigration(up, up, "cd34", "ab12", "", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "down_revision = \"ab12\"\n") {
+		t.Errorf("down revision not written:\n%s", buf.String())
+	}
+
+	if err := ExportAlembicMigration(up, nil, "", "", "", &buf); err == nil {
+		t.Error("expected an error without a revision")
+	}
+}
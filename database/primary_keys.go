@@ -0,0 +1,164 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// PrimaryKeyInfo holds a table's primary key with its columns in key order
+type PrimaryKeyInfo struct {
+	Name    string   `json:"name"` // constraint name; empty where the dialect doesn't name it
+	Columns []string `json:"columns"`
+}
+
+// comparePrimaryKeys diffs the primary key of a table. Column order matters:
+// a key on (a, b) is not the same key as one on (b, a).
+func comparePrimaryKeys(tableName string, source, target *PrimaryKeyInfo, dialect DBType, q func(string) string) []DiffResult {
+	if source == nil && target == nil {
+		return nil
+	}
+	if source != nil && target != nil && primaryKeyColumnsEqual(source.Columns, target.Columns) {
+		return nil
+	}
+
+	var detail string
+	var steps []string
+	switch {
+	case target == nil:
+		detail = fmt.Sprintf("Add primary key: (%s)", strings.Join(source.Columns, ", "))
+	case source == nil:
+		detail = fmt.Sprintf("Drop primary key: (%s)", strings.Join(target.Columns, ", "))
+	default:
+		detail = fmt.Sprintf("Change primary key: (%s) -> (%s)", strings.Join(target.Columns, ", "), strings.Join(source.Columns, ", "))
+	}
+
+	switch dialect {
+	case MySQL, "":
+		var clauses []string
+		if target != nil {
+			clauses = append(clauses, "DROP PRIMARY KEY")
+		}
+		if source != nil {
+			clauses = append(clauses, fmt.Sprintf("ADD PRIMARY KEY (%s)", quoteColumnList(source.Columns, q)))
+		}
+		steps = append(steps, fmt.Sprintf("ALTER TABLE %s %s;", q(tableName), strings.Join(clauses, ", ")))
+	case SQLite:
+		steps = append(steps, fmt.Sprintf("-- SQLite cannot change the primary key of %s in place; rebuild the table", tableName))
+	default:
+		if target != nil {
+			steps = append(steps, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", q(tableName), q(primaryKeyName(tableName, target, dialect))))
+		}
+		if source != nil {
+			steps = append(steps, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s PRIMARY KEY (%s);",
+				q(tableName), q(primaryKeyName(tableName, source, dialect)), quoteColumnList(source.Columns, q)))
+		}
+	}
+
+	return []DiffResult{{
+		Type:      "modified",
+		TableName: tableName,
+		Detail:    detail,
+		SQL:       strings.Join(steps, "\n"),
+	}}
+}
+
+func primaryKeyColumnsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// primaryKeyName returns the constraint name to use for pk, falling back to the
+// dialect's default naming when the key has none (MySQL and SQLite don't name it)
+func primaryKeyName(tableName string, pk *PrimaryKeyInfo, dialect DBType) string {
+	if pk.Name != "" && pk.Name != "PRIMARY" {
+		return pk.Name
+	}
+	if dialect == SQLServer {
+		return "PK_" + tableName
+	}
+	return tableName + "_pkey"
+}
+
+// isPrimaryKeyIndex reports whether an index is the one backing the primary key;
+// it is compared by comparePrimaryKeys rather than as a regular index
+func isPrimaryKeyIndex(indexName string, pk *PrimaryKeyInfo) bool {
+	return indexName == "PRIMARY" || (pk != nil && pk.Name != "" && indexName == pk.Name)
+}
+
+func getMySQLPrimaryKey(db *sql.DB, tableName string) (*PrimaryKeyInfo, error) {
+	rows, err := db.Query(`
+		SELECT CONSTRAINT_NAME, COLUMN_NAME
+		FROM information_schema.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY'
+		ORDER BY ORDINAL_POSITION`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return scanPrimaryKey(rows)
+}
+
+func getPostgreSQLPrimaryKey(db *sql.DB, tableName string) (*PrimaryKeyInfo, error) {
+	rows, err := db.Query(`
+		SELECT con.conname, a.attname
+		FROM pg_constraint con
+		JOIN pg_class t ON t.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		CROSS JOIN LATERAL unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord)
+		JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+		WHERE n.nspname = 'public' AND t.relname = $1 AND con.contype = 'p'
+		ORDER BY k.ord`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return scanPrimaryKey(rows)
+}
+
+func getSQLServerPrimaryKey(db *sql.DB, tableName string) (*PrimaryKeyInfo, error) {
+	rows, err := db.Query(`
+		SELECT i.name, c.name
+		FROM sys.indexes i
+		JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+		JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+		WHERE i.object_id = OBJECT_ID(@p1) AND i.is_primary_key = 1
+		ORDER BY ic.key_ordinal`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return scanPrimaryKey(rows)
+}
+
+// getSQLitePrimaryKey builds the primary key from PRAGMA table_info,
+// where pk is the column's 1-based position in the key
+func getSQLitePrimaryKey(db *sql.DB, tableName string) (*PrimaryKeyInfo, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT '', name FROM pragma_table_info('%s') WHERE pk > 0 ORDER BY pk", tableName))
+	if err != nil {
+		return nil, err
+	}
+	return scanPrimaryKey(rows)
+}
+
+// scanPrimaryKey reads (constraint name, column) rows in key order; no rows means no primary key
+func scanPrimaryKey(rows *sql.Rows) (*PrimaryKeyInfo, error) {
+	defer rows.Close()
+
+	var pk *PrimaryKeyInfo
+	for rows.Next() {
+		var name, column string
+		if err := rows.Scan(&name, &column); err != nil {
+			return nil, err
+		}
+		if pk == nil {
+			pk = &PrimaryKeyInfo{Name: name}
+		}
+		pk.Columns = append(pk.Columns, column)
+	}
+	return pk, rows.Err()
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+)
+
+// QueryResult holds the outcome of an ad-hoc script: the rows of its final
+// statement, when that statement returns rows, and the affected-row counts of
+// the statements run for their effect
+type QueryResult struct {
+	Columns []string       `json:"columns"`
+	Rows    []TableRowData `json:"rows"`
+	// ReturnsRows is whether the final statement produced a result set
+	ReturnsRows bool `json:"returnsRows"`
+	// AffectedRows has one count per statement that didn't return rows, in
+	// script order; -1 where the driver can't tell
+	AffectedRows []int64 `json:"affectedRows"`
+}
+
+// rowsPattern matches statements that produce a result set
+var rowsPattern = regexp.MustCompile(`(?is)^\s*(SELECT|WITH|SHOW|PRAGMA|EXPLAIN|DESCRIBE|DESC|VALUES|TABLE)\b|\bRETURNING\b|\bOUTPUT\s+(INSERTED|DELETED)\.`)
+
+// ExecuteQuery runs a script of statements and returns the result of the last one
+func ExecuteQuery(config ConnectionConfig, statements []string) (*QueryResult, error) {
+	return ExecuteQueryContext(context.Background(), config, statements)
+}
+
+// ExecuteQueryContext runs the statements in order on one connection, so session
+// state such as temporary tables carries over between them. The final statement is
+// read as a result set when it looks like one that returns rows; the others are
+// executed and their affected-row counts collected.
+func ExecuteQueryContext(ctx context.Context, config ConnectionConfig, statements []string) (*QueryResult, error) {
+	db, err := ConnectContext(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var pending []string
+	for _, stmt := range statements {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			pending = append(pending, stmt)
+		}
+	}
+
+	result := &QueryResult{Columns: []string{}, Rows: []TableRowData{}, AffectedRows: []int64{}}
+	for i, stmt := range pending {
+		if i == len(pending)-1 && rowsPattern.MatchString(stmt) {
+			if err := queryRows(ctx, conn, stmt, result); err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		res, err := conn.ExecContext(ctx, stmt)
+		if err != nil {
+			return nil, err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			affected = -1
+		}
+		result.AffectedRows = append(result.AffectedRows, affected)
+	}
+	return result, nil
+}
+
+// queryRows reads every row of stmt into result. A statement that turns out to
+// return no columns, such as a PRAGMA that sets a value, leaves ReturnsRows false.
+func queryRows(ctx context.Context, conn *sql.Conn, stmt string, result *QueryResult) error {
+	rows, err := conn.QueryContext(ctx, stmt)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return rows.Err()
+	}
+
+	resultRows, err := scanTableRows(rows, columns)
+	if err != nil {
+		return err
+	}
+	result.Columns = columns
+	result.ReturnsRows = true
+	if resultRows != nil {
+		result.Rows = resultRows
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"syscall"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// connectionLostMessages are fragments of driver errors that mean an open
+// connection was reset, for drivers that don't return typed errors
+var connectionLostMessages = []string{
+	"connection reset",
+	"broken pipe",
+	"forcibly closed",
+	"server closed the connection",
+	"bad connection",
+	"invalid connection",
+}
+
+// isConnectionLost reports whether err means the server dropped a connection
+// that was already established, so the statement can be retried on a fresh
+// one. Only bad-connection, EOF and reset errors qualify: timeouts, refused
+// dials and other network errors are returned as they are, since a new
+// connection would most likely fail the same way.
+func isConnectionLost(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// 08003/08006: the connection is gone; 57P01: the server terminated it
+		switch pqErr.Code {
+		case "08003", "08006", "57P01":
+			return true
+		}
+		return false
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range connectionLostMessages {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
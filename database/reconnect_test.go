@@ -0,0 +1,79 @@
+package database
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+func TestLimitedConnRetriesLostConnection(t *testing.T) {
+	drv := &fakeDriver{queryErrs: []error{io.EOF}}
+	db := openFake(t, drv, ConnectionConfig{Type: SQLite, FilePath: t.Name()})
+
+	var n int
+	if err := db.QueryRow("SELECT 1").Scan(&n); err != nil {
+		t.Fatalf("query after a dropped connection: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("got %d, want 1", n)
+	}
+	if drv.opened != 2 {
+		t.Errorf("opened %d connections, want the dropped one replaced", drv.opened)
+	}
+}
+
+func TestIsConnectionLost(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"bad connection", driver.ErrBadConn, true},
+		{"eof", io.EOF, true},
+		{"wrapped unexpected eof", fmt.Errorf("read packet: %w", io.ErrUnexpectedEOF), true},
+		{"reset", &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, true},
+		{"broken pipe", &net.OpError{Op: "write", Net: "tcp", Err: syscall.EPIPE}, true},
+		{"mysql invalid connection", mysql.ErrInvalidConn, true},
+		{"postgres connection failure", &pq.Error{Code: "08006"}, true},
+		{"postgres admin shutdown", &pq.Error{Code: "57P01"}, true},
+		{"postgres cannot connect now", &pq.Error{Code: "57P03"}, false},
+		{"postgres rejected connection", &pq.Error{Code: "08004"}, false},
+		{"postgres syntax error", &pq.Error{Code: "42601"}, false},
+		{"sql server reset message", errors.New("read tcp: wsarecv: An existing connection was forcibly closed by the remote host."), true},
+		{"refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, false},
+		{"refused message", errors.New("dial tcp 10.0.0.1:5432: connect: connection refused"), false},
+		{"timeout", &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, false},
+		{"dns", &net.DNSError{Err: "no such host", Name: "db"}, false},
+		{"query error", errors.New("table not found"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConnectionLost(tt.err); got != tt.want {
+				t.Errorf("isConnectionLost(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitedConnDoesNotRetryRefusedConnection(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	drv := &fakeDriver{queryErrs: []error{refused}}
+	db := openFake(t, drv, ConnectionConfig{Type: SQLite, FilePath: t.Name()})
+
+	var n int
+	if err := db.QueryRow("SELECT 1").Scan(&n); !errors.Is(err, syscall.ECONNREFUSED) {
+		t.Fatalf("err = %v, want the refused error", err)
+	}
+	if drv.opened != 1 {
+		t.Errorf("opened %d connections, want no retry", drv.opened)
+	}
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// reportSchema creates the tables of a report database. Each comparison is a
+// run; its schema and data diffs reference it by run_id.
+const reportSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	run_id TEXT PRIMARY KEY,
+	created_at TEXT NOT NULL,
+	schema_diff_count INTEGER NOT NULL,
+	data_diff_count INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS schema_diffs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_id TEXT NOT NULL REFERENCES runs(run_id),
+	type TEXT NOT NULL,
+	object_type TEXT NOT NULL,
+	table_name TEXT NOT NULL,
+	detail TEXT NOT NULL,
+	sql TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_schema_diffs_run ON schema_diffs(run_id);
+CREATE INDEX IF NOT EXISTS idx_schema_diffs_table ON schema_diffs(table_name);
+CREATE TABLE IF NOT EXISTS data_diffs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_id TEXT NOT NULL REFERENCES runs(run_id),
+	type TEXT NOT NULL,
+	table_name TEXT NOT NULL,
+	primary_key TEXT,
+	old_values TEXT,
+	new_values TEXT,
+	sql TEXT NOT NULL,
+	warning TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_data_diffs_run ON data_diffs(run_id);
+CREATE INDEX IF NOT EXISTS idx_data_diffs_table ON data_diffs(table_name);
+`
+
+// WriteDiffToReportDB records a comparison run in a SQLite report database at
+// reportPath, creating it if needed, so results can be queried over time.
+// Row values are stored as JSON.
+func WriteDiffToReportDB(reportPath string, runID string, diffs []DiffResult, dataDiffs []DataDiffResult) error {
+	if runID == "" {
+		return fmt.Errorf("run ID is required")
+	}
+
+	db, err := sql.Open("sqlite3", reportPath)
+	if err != nil {
+		return fmt.Errorf("failed to open report database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(reportSchema); err != nil {
+		return fmt.Errorf("failed to create report tables: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT INTO runs (run_id, created_at, schema_diff_count, data_diff_count) VALUES (?, ?, ?, ?)",
+		runID, time.Now().UTC().Format(time.RFC3339), len(diffs), len(dataDiffs))
+	if err != nil {
+		return fmt.Errorf("failed to record run %s: %v", runID, err)
+	}
+
+	for _, d := range diffs {
+		_, err := tx.Exec("INSERT INTO schema_diffs (run_id, type, object_type, table_name, detail, sql) VALUES (?, ?, ?, ?, ?, ?)",
+			runID, d.Type, d.ObjectType, d.TableName, d.Detail, d.SQL)
+		if err != nil {
+			return fmt.Errorf("failed to record schema diff: %v", err)
+		}
+	}
+
+	for _, d := range dataDiffs {
+		pk, err := reportJSON(d.PrimaryKey)
+		if err != nil {
+			return err
+		}
+		oldValues, err := reportJSON(d.OldValues)
+		if err != nil {
+			return err
+		}
+		newValues, err := reportJSON(d.NewValues)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("INSERT INTO data_diffs (run_id, type, table_name, primary_key, old_values, new_values, sql, warning) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			runID, d.Type, d.TableName, pk, oldValues, newValues, d.SQL, d.Warning)
+		if err != nil {
+			return fmt.Errorf("failed to record data diff: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// reportJSON encodes row values for the report, NULL when there are none
+func reportJSON(values map[string]interface{}) (interface{}, error) {
+	if values == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode row values: %v", err)
+	}
+	return string(encoded), nil
+}
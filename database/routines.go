@@ -0,0 +1,172 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RoutineInfo holds a stored procedure or function
+type RoutineInfo struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`                // PROCEDURE or FUNCTION
+	Arguments  string `json:"arguments,omitempty"` // PostgreSQL argument types; overloads are keyed name(arguments)
+	Definition string `json:"definition"`          // complete CREATE statement
+}
+
+// mysqlDefinerPattern matches the DEFINER clause, which names a server account
+// and would make the same routine differ between servers
+var mysqlDefinerPattern = regexp.MustCompile("(?i)\\s+DEFINER\\s*=\\s*(`[^`]*`|'[^']*'|\\S+)@(`[^`]*`|'[^']*'|\\S+)")
+
+func getMySQLRoutines(db *sql.DB) (map[string]RoutineInfo, error) {
+	rows, err := db.Query(`
+		SELECT ROUTINE_NAME, ROUTINE_TYPE
+		FROM INFORMATION_SCHEMA.ROUTINES
+		WHERE ROUTINE_SCHEMA = DATABASE()`)
+	if err != nil {
+		return nil, err
+	}
+	var list []RoutineInfo
+	for rows.Next() {
+		var r RoutineInfo
+		if err := rows.Scan(&r.Name, &r.Type); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	routines := make(map[string]RoutineInfo)
+	for _, r := range list {
+		// SHOW CREATE returns name, sql_mode, the statement and three charset columns
+		var name, sqlMode, charset, collation, dbCollation string
+		var createSQL sql.NullString // NULL without privileges on the routine
+		err := db.QueryRow(fmt.Sprintf("SHOW CREATE %s `%s`", r.Type, r.Name)).
+			Scan(&name, &sqlMode, &createSQL, &charset, &collation, &dbCollation)
+		if err != nil {
+			return nil, err
+		}
+		if !createSQL.Valid {
+			return nil, fmt.Errorf("no privilege to read the definition of %s %s", strings.ToLower(r.Type), r.Name)
+		}
+		r.Definition = mysqlDefinerPattern.ReplaceAllString(createSQL.String, "")
+		routines[r.Name] = r
+	}
+	return routines, nil
+}
+
+// getPostgreSQLRoutines reads the functions and procedures of the public schema,
+// skipping aggregates and functions installed by extensions
+func getPostgreSQLRoutines(db *sql.DB) (map[string]RoutineInfo, error) {
+	rows, err := db.Query(`
+		SELECT p.proname, CASE p.prokind WHEN 'p' THEN 'PROCEDURE' ELSE 'FUNCTION' END,
+			pg_get_function_identity_arguments(p.oid), pg_get_functiondef(p.oid)
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = 'public' AND p.prokind IN ('f', 'p')
+			AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = p.oid AND d.deptype = 'e')`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	routines := make(map[string]RoutineInfo)
+	for rows.Next() {
+		var r RoutineInfo
+		if err := rows.Scan(&r.Name, &r.Type, &r.Arguments, &r.Definition); err != nil {
+			return nil, err
+		}
+		routines[fmt.Sprintf("%s(%s)", r.Name, r.Arguments)] = r
+	}
+	return routines, rows.Err()
+}
+
+func getSQLServerRoutines(db *sql.DB) (map[string]RoutineInfo, error) {
+	rows, err := db.Query(`
+		SELECT o.name, CASE WHEN o.type = 'P' THEN 'PROCEDURE' ELSE 'FUNCTION' END, m.definition
+		FROM sys.objects o
+		JOIN sys.sql_modules m ON m.object_id = o.object_id
+		WHERE o.type IN ('P', 'FN', 'IF', 'TF') AND o.is_ms_shipped = 0`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	routines := make(map[string]RoutineInfo)
+	for rows.Next() {
+		var r RoutineInfo
+		var definition sql.NullString // NULL for routines created WITH ENCRYPTION
+		if err := rows.Scan(&r.Name, &r.Type, &definition); err != nil {
+			return nil, err
+		}
+		r.Definition = definition.String
+		routines[r.Name] = r
+	}
+	return routines, rows.Err()
+}
+
+// compareRoutines diffs routines by their whitespace-normalized definition.
+// Most engines can't alter a routine body in place, so a change is a drop and create.
+func compareRoutines(source, target map[string]RoutineInfo, q func(string) string) []DiffResult {
+	var results []DiffResult
+
+	for key, sourceRoutine := range source {
+		targetRoutine, exists := target[key]
+		switch {
+		case !exists:
+			results = append(results, DiffResult{
+				Type:       "added",
+				TableName:  key,
+				Detail:     fmt.Sprintf("%s exists in source but not in target", routineLabel(sourceRoutine)),
+				SQL:        routineCreateSQL(sourceRoutine),
+				ObjectType: "routine",
+			})
+		case normalizeSQLBody(sourceRoutine.Definition) != normalizeSQLBody(targetRoutine.Definition):
+			results = append(results, DiffResult{
+				Type:       "modified",
+				TableName:  key,
+				Detail:     fmt.Sprintf("%s definition differs, recreating", routineLabel(sourceRoutine)),
+				SQL:        routineDropSQL(targetRoutine, q) + "\n" + routineCreateSQL(sourceRoutine),
+				ObjectType: "routine",
+			})
+		}
+	}
+
+	for key, targetRoutine := range target {
+		if _, exists := source[key]; !exists {
+			results = append(results, DiffResult{
+				Type:       "removed",
+				TableName:  key,
+				Detail:     fmt.Sprintf("%s exists in target but not in source", routineLabel(targetRoutine)),
+				SQL:        routineDropSQL(targetRoutine, q),
+				ObjectType: "routine",
+			})
+		}
+	}
+
+	return results
+}
+
+func routineLabel(r RoutineInfo) string {
+	if r.Type == "PROCEDURE" {
+		return "Procedure"
+	}
+	return "Function"
+}
+
+func routineCreateSQL(r RoutineInfo) string {
+	return strings.TrimSuffix(strings.TrimSpace(r.Definition), ";") + ";"
+}
+
+// routineDropSQL drops the routine; PostgreSQL needs the argument types to pick the overload
+func routineDropSQL(r RoutineInfo, q func(string) string) string {
+	if r.Arguments != "" {
+		return fmt.Sprintf("DROP %s %s(%s);", r.Type, q(r.Name), r.Arguments)
+	}
+	return fmt.Sprintf("DROP %s %s;", r.Type, q(r.Name))
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// ErrSameDatabase is returned when source and target of a data comparison are the
+// same database, where a sync would at best do nothing
+var ErrSameDatabase = errors.New("source and target are the same database")
+
+// SameDatabase reports whether two configs address the same database. Hosts are
+// compared case-insensitively with the loopback names treated as one, default
+// ports filled in and SQLite paths made absolute. Servers behind an SSH tunnel are
+// only the same when reached through the same bastion. Different host names of
+// one server (an IP and a DNS name) are not detected.
+func SameDatabase(a, b ConnectionConfig) bool {
+	typeA, typeB := a.Type, b.Type
+	if typeA == "" {
+		typeA = MySQL
+	}
+	if typeB == "" {
+		typeB = MySQL
+	}
+	if typeA != typeB {
+		return false
+	}
+	if typeA == SQLite {
+		return normalizeFilePath(a.FilePath) == normalizeFilePath(b.FilePath)
+	}
+
+	portA, portB := a.Port, b.Port
+	if portA == 0 {
+		portA = defaultPort(typeA)
+	}
+	if portB == 0 {
+		portB = defaultPort(typeB)
+	}
+	if portA != portB || normalizeHost(a.Host) != normalizeHost(b.Host) {
+		return false
+	}
+	if (a.SSHTunnel == nil) != (b.SSHTunnel == nil) {
+		return false
+	}
+	if a.SSHTunnel != nil && (normalizeHost(a.SSHTunnel.Host) != normalizeHost(b.SSHTunnel.Host) || sshPort(a.SSHTunnel) != sshPort(b.SSHTunnel)) {
+		return false
+	}
+
+	// PostgreSQL folds only unquoted names, MySQL on Linux is case-sensitive too;
+	// SQL Server's default collation is case-insensitive
+	if typeA == SQLServer {
+		return strings.EqualFold(a.Database, b.Database)
+	}
+	return a.Database == b.Database
+}
+
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	switch host {
+	case "", "localhost", "127.0.0.1", "::1", "[::1]":
+		return "localhost"
+	}
+	return host
+}
+
+func normalizeFilePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+func sshPort(t *SSHTunnelConfig) int {
+	if t.Port == 0 {
+		return 22
+	}
+	return t.Port
+}
@@ -0,0 +1,278 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Schema change operations
+const (
+	OpCreateTable  = "create_table"
+	OpDropTable    = "drop_table"
+	OpAddColumn    = "add_column"
+	OpDropColumn   = "drop_column"
+	OpModifyColumn = "modify_column"
+	OpAddIndex     = "add_index"
+	OpDropIndex    = "drop_index"
+	OpModifyIndex  = "modify_index"
+)
+
+// SchemaChange is one difference between two schemas as a structured operation.
+// Unlike DiffResult it carries no SQL; RenderSchemaChanges renders it for a dialect.
+type SchemaChange struct {
+	Op     string     `json:"op"`
+	Table  string     `json:"table"`
+	Column string     `json:"column,omitempty"`
+	Index  string     `json:"index,omitempty"`
+	OldDef *ChangeDef `json:"oldDef,omitempty"` // definition in the target, nil for additions
+	NewDef *ChangeDef `json:"newDef,omitempty"` // definition in the source, nil for removals
+}
+
+// ChangeDef holds the definition of the object a SchemaChange is about; only the
+// field matching the operation is set
+type ChangeDef struct {
+	Table  *TableInfo  `json:"table,omitempty"`
+	Column *ColumnInfo `json:"column,omitempty"`
+	Index  *IndexDef   `json:"index,omitempty"`
+}
+
+// IndexDef is an index as a whole: its key parts in order, each an unquoted column
+// name or a parenthesized expression
+type IndexDef struct {
+	Parts     []string `json:"parts"`
+	Unique    bool     `json:"unique,omitempty"`
+	Invisible bool     `json:"invisible,omitempty"`
+	// Definition is the captured PostgreSQL CREATE INDEX statement, if any
+	Definition string `json:"definition,omitempty"`
+}
+
+// CompareSchemaChanges compares the tables, columns and indexes of two schemas as
+// structured changes that turn target into source. Tables are created in foreign key
+// order and dropped last. Other objects are only reported by CompareSchemas.
+func CompareSchemaChanges(source, target *SchemaInfo) []SchemaChange {
+	var changes []SchemaChange
+
+	depth := foreignKeyDepth(source.Tables)
+	var added []string
+	for name := range source.Tables {
+		if _, exists := target.Tables[name]; !exists {
+			added = append(added, name)
+		}
+	}
+	sort.Slice(added, func(i, j int) bool {
+		if depth[added[i]] != depth[added[j]] {
+			return depth[added[i]] < depth[added[j]]
+		}
+		return added[i] < added[j]
+	})
+	for _, name := range added {
+		table := source.Tables[name]
+		changes = append(changes, SchemaChange{Op: OpCreateTable, Table: name, NewDef: &ChangeDef{Table: &table}})
+	}
+
+	for _, name := range sortedSchemaTables(source.Tables) {
+		if targetTable, exists := target.Tables[name]; exists {
+			changes = append(changes, tableChanges(name, source.Tables[name], targetTable)...)
+		}
+	}
+
+	targetDepth := foreignKeyDepth(target.Tables)
+	var removed []string
+	for name := range target.Tables {
+		if _, exists := source.Tables[name]; !exists {
+			removed = append(removed, name)
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		if targetDepth[removed[i]] != targetDepth[removed[j]] {
+			return targetDepth[removed[i]] > targetDepth[removed[j]]
+		}
+		return removed[i] < removed[j]
+	})
+	for _, name := range removed {
+		table := target.Tables[name]
+		changes = append(changes, SchemaChange{Op: OpDropTable, Table: name, OldDef: &ChangeDef{Table: &table}})
+	}
+
+	return changes
+}
+
+// tableChanges lists the column and index changes of a table present on both sides.
+// Indexes are dropped before columns, which could take them along, and added after.
+func tableChanges(tableName string, source, target TableInfo) []SchemaChange {
+	var changes []SchemaChange
+
+	sourceIdx := buildIndexDefs(source)
+	targetIdx := buildIndexDefs(target)
+	for _, name := range sortedIndexNames(targetIdx) {
+		if _, exists := sourceIdx[name]; !exists {
+			changes = append(changes, SchemaChange{Op: OpDropIndex, Table: tableName, Index: name, OldDef: &ChangeDef{Index: targetIdx[name]}})
+		}
+	}
+
+	targetCols := make(map[string]ColumnInfo)
+	for _, col := range target.Columns {
+		targetCols[col.Name] = col
+	}
+	sourceCols := make(map[string]bool)
+	for _, col := range source.Columns {
+		sourceCols[col.Name] = true
+		newCol := col
+		oldCol, exists := targetCols[col.Name]
+		switch {
+		case !exists:
+			changes = append(changes, SchemaChange{Op: OpAddColumn, Table: tableName, Column: col.Name, NewDef: &ChangeDef{Column: &newCol}})
+		case !columnsEqual(newCol, oldCol):
+			changes = append(changes, SchemaChange{Op: OpModifyColumn, Table: tableName, Column: col.Name,
+				OldDef: &ChangeDef{Column: &oldCol}, NewDef: &ChangeDef{Column: &newCol}})
+		}
+	}
+	for _, col := range target.Columns {
+		if !sourceCols[col.Name] {
+			oldCol := col
+			changes = append(changes, SchemaChange{Op: OpDropColumn, Table: tableName, Column: col.Name, OldDef: &ChangeDef{Column: &oldCol}})
+		}
+	}
+
+	for _, name := range sortedIndexNames(sourceIdx) {
+		newIdx := sourceIdx[name]
+		oldIdx, exists := targetIdx[name]
+		switch {
+		case !exists:
+			changes = append(changes, SchemaChange{Op: OpAddIndex, Table: tableName, Index: name, NewDef: &ChangeDef{Index: newIdx}})
+		case !indexPartsEqual(newIdx.Parts, oldIdx.Parts) || newIdx.Invisible != oldIdx.Invisible ||
+			!indexDefinitionsEqual(newIdx.Definition, oldIdx.Definition):
+			changes = append(changes, SchemaChange{Op: OpModifyIndex, Table: tableName, Index: name,
+				OldDef: &ChangeDef{Index: oldIdx}, NewDef: &ChangeDef{Index: newIdx}})
+		}
+	}
+
+	return changes
+}
+
+// buildIndexDefs groups a table's index rows into whole indexes, leaving out the
+// primary key's index
+func buildIndexDefs(table TableInfo) map[string]*IndexDef {
+	parts := buildIndexMap(table.Indexes, func(name string) string { return name })
+	unique := buildIndexUniqueness(table.Indexes)
+	invisible := buildIndexVisibility(table.Indexes)
+	definitions := buildIndexDefinitions(table.Indexes)
+
+	defs := make(map[string]*IndexDef)
+	for name, p := range parts {
+		if isPrimaryKeyIndex(name, table.PrimaryKey) {
+			continue
+		}
+		defs[name] = &IndexDef{Parts: p, Unique: unique[name], Invisible: invisible[name], Definition: definitions[name]}
+	}
+	return defs
+}
+
+func sortedIndexNames(defs map[string]*IndexDef) []string {
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedSchemaTables(tables map[string]TableInfo) []string {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RenderSchemaChanges renders structured changes as SQL statements for a dialect.
+// Column types are used as captured; compare with CompareSchemasCrossDialect when
+// they need translating.
+func RenderSchemaChanges(changes []SchemaChange, dialect DBType) ([]string, error) {
+	opts := CompareOptions{Dialect: dialect}
+	statements := make([]string, 0, len(changes))
+	for _, change := range changes {
+		stmt, err := opts.renderSchemaChange(change)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, stmt)
+	}
+	return statements, nil
+}
+
+func (o CompareOptions) renderSchemaChange(c SchemaChange) (string, error) {
+	q := o.quote
+	switch c.Op {
+	case OpCreateTable:
+		if c.NewDef == nil || c.NewDef.Table == nil {
+			return "", fmt.Errorf("%s %s: missing table definition", c.Op, c.Table)
+		}
+		return o.createTableSQL(c.Table, *c.NewDef.Table), nil
+	case OpDropTable:
+		return fmt.Sprintf("DROP TABLE %s;", q(c.Table)), nil
+	case OpAddColumn:
+		if c.NewDef == nil || c.NewDef.Column == nil {
+			return "", fmt.Errorf("%s %s.%s: missing column definition", c.Op, c.Table, c.Column)
+		}
+		return o.addColumnSQL(c.Table, *c.NewDef.Column, ""), nil
+	case OpDropColumn:
+		return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", q(c.Table), q(c.Column)), nil
+	case OpModifyColumn:
+		if c.NewDef == nil || c.NewDef.Column == nil || c.OldDef == nil || c.OldDef.Column == nil {
+			return "", fmt.Errorf("%s %s.%s: missing column definition", c.Op, c.Table, c.Column)
+		}
+		return o.modifyColumnSQL(c.Table, *c.NewDef.Column, *c.OldDef.Column, ""), nil
+	case OpAddIndex, OpModifyIndex:
+		if c.NewDef == nil || c.NewDef.Index == nil {
+			return "", fmt.Errorf("%s %s.%s: missing index definition", c.Op, c.Table, c.Index)
+		}
+		idx := c.NewDef.Index
+		if c.Op == OpModifyIndex {
+			return recreateIndexSQL(c.Table, c.Index, o.quoteIndexParts(idx.Parts), idx.Invisible, idx.Definition, o), nil
+		}
+		return o.addIndexSQL(c.Table, c.Index, o.quoteIndexParts(idx.Parts), idx.Invisible, idx.Definition), nil
+	case OpDropIndex:
+		return o.dropIndexSQL(c.Table, c.Index), nil
+	default:
+		return "", fmt.Errorf("unknown schema change operation: %s", c.Op)
+	}
+}
+
+// quoteIndexParts quotes the column names among index key parts, leaving expressions as they are
+func (o CompareOptions) quoteIndexParts(parts []string) []string {
+	quoted := make([]string, len(parts))
+	for i, part := range parts {
+		if strings.HasPrefix(part, "(") {
+			quoted[i] = part
+		} else {
+			quoted[i] = o.quote(part)
+		}
+	}
+	return quoted
+}
+
+// createTableSQL builds CREATE TABLE from a table's columns and primary key, with
+// its other indexes as separate statements
+func (o CompareOptions) createTableSQL(tableName string, table TableInfo) string {
+	columns := make([]ColumnInfo, len(table.Columns))
+	copy(columns, table.Columns)
+	sort.SliceStable(columns, func(i, j int) bool { return columns[i].Position < columns[j].Position })
+
+	parts := make([]string, 0, len(columns)+1)
+	for _, col := range columns {
+		parts = append(parts, fmt.Sprintf("%s %s", o.quote(col.Name), buildColumnDef(o.Dialect, col)))
+	}
+	if table.PrimaryKey != nil {
+		parts = append(parts, fmt.Sprintf("PRIMARY KEY (%s)", quoteColumnList(table.PrimaryKey.Columns, o.quote)))
+	}
+	statements := []string{fmt.Sprintf("CREATE TABLE %s (\n  %s\n);", o.quote(tableName), strings.Join(parts, ",\n  "))}
+
+	indexes := buildIndexDefs(table)
+	for _, name := range sortedIndexNames(indexes) {
+		statements = append(statements, o.addIndexSQL(tableName, name, o.quoteIndexParts(indexes[name].Parts), indexes[name].Invisible, indexes[name].Definition))
+	}
+	return strings.Join(statements, "\n")
+}
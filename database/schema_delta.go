@@ -0,0 +1,51 @@
+package database
+
+// ComparisonDelta classifies the schema differences of two comparison runs
+type ComparisonDelta struct {
+	New        []DiffResult `json:"new"`        // found now but not in the previous run
+	Resolved   []DiffResult `json:"resolved"`   // found in the previous run but not now
+	Persisting []DiffResult `json:"persisting"` // found in both runs, as reported now
+}
+
+// schemaDiffKey identifies a schema difference across runs. The SQL is left out:
+// it can change with compare options while the difference stays the same.
+type schemaDiffKey struct {
+	objectType, diffType, tableName, detail string
+}
+
+func keyOfDiff(d DiffResult) schemaDiffKey {
+	return schemaDiffKey{d.ObjectType, d.Type, d.TableName, d.Detail}
+}
+
+// DiffComparisons compares the results of two schema comparisons, e.g. of the same
+// databases in consecutive CI runs, to track drift. Results keep the order of the
+// run they come from; a difference reported twice in a run is matched twice.
+func DiffComparisons(previous, current []DiffResult) ComparisonDelta {
+	unmatched := make(map[schemaDiffKey]int)
+	for _, d := range previous {
+		unmatched[keyOfDiff(d)]++
+	}
+
+	delta := ComparisonDelta{New: []DiffResult{}, Resolved: []DiffResult{}, Persisting: []DiffResult{}}
+	matched := make(map[schemaDiffKey]int)
+	for _, d := range current {
+		key := keyOfDiff(d)
+		if unmatched[key] > 0 {
+			unmatched[key]--
+			matched[key]++
+			delta.Persisting = append(delta.Persisting, d)
+		} else {
+			delta.New = append(delta.New, d)
+		}
+	}
+	for _, d := range previous {
+		// The first copies of a repeated difference are the ones matched above
+		key := keyOfDiff(d)
+		if matched[key] > 0 {
+			matched[key]--
+			continue
+		}
+		delta.Resolved = append(delta.Resolved, d)
+	}
+	return delta
+}
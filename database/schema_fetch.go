@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// schemaFetchConcurrency returns how many tables are read at once
+func (c ConnectionConfig) schemaFetchConcurrency() int {
+	if c.Type == SQLite {
+		// A single connection; more workers would only queue on the file lock
+		return 1
+	}
+	if c.SchemaFetchConcurrency > 0 {
+		return c.SchemaFetchConcurrency
+	}
+	return runtime.NumCPU()
+}
+
+// fetchTableInfos reads the TableInfo of each table with a bounded pool of workers.
+// The first failure stops the remaining work and is returned with the table name.
+func fetchTableInfos(ctx context.Context, db *sql.DB, tableNames []string, concurrency int, fetch func(*sql.DB, string) (*TableInfo, error)) (map[string]TableInfo, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	tables := make(map[string]TableInfo, len(tableNames))
+	var mu sync.Mutex
+	var firstErr error
+
+	names := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range names {
+				info, err := fetch(db, name)
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("failed to read table %s: %v", name, err)
+						cancel()
+					}
+				} else {
+					tables[name] = *info
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, name := range tableNames {
+		select {
+		case names <- name:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(names)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
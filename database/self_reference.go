@@ -0,0 +1,168 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// orderSelfReferences reorders the inserts and deletes of tables with a foreign
+// key to themselves, such as employee.manager_id -> employee.id, so no statement
+// breaks the key mid-sync: a row is inserted after the row it references and
+// deleted before it. The reordered statements keep the positions the table's
+// inserts or deletes had among the other diffs. Rows referencing each other in
+// a cycle keep their relative order.
+func orderSelfReferences(db *sql.DB, dbType DBType, database string, diffs []DataDiffResult) ([]DataDiffResult, error) {
+	counts := make(map[string]int)
+	for _, d := range diffs {
+		if d.Type == "insert" || d.Type == "delete" {
+			counts[d.TableName]++
+		}
+	}
+
+	ordered := diffs
+	copied := false
+	for tableName, count := range counts {
+		if count < 2 {
+			continue
+		}
+		fks, err := getForeignKeys(db, dbType, tableName)
+		if err != nil {
+			return nil, err
+		}
+		var selfRefs []ForeignKeyInfo
+		for _, fk := range fks {
+			if strings.EqualFold(fk.RefTable, tableName) {
+				selfRefs = append(selfRefs, fk)
+			}
+		}
+		if len(selfRefs) == 0 {
+			continue
+		}
+		if err := resolveReferencedColumns(db, dbType, database, tableName, selfRefs); err != nil {
+			return nil, err
+		}
+
+		if !copied {
+			ordered = append([]DataDiffResult(nil), diffs...)
+			copied = true
+		}
+		reorderRows(ordered, tableName, "insert", selfRefs, false)
+		reorderRows(ordered, tableName, "delete", selfRefs, true)
+	}
+	return ordered, nil
+}
+
+// resolveReferencedColumns fills in the referenced columns SQLite leaves empty
+// for a key that references the primary key
+func resolveReferencedColumns(db *sql.DB, dbType DBType, database, tableName string, fks []ForeignKeyInfo) error {
+	var primaryKeys []string
+	for i := range fks {
+		for j, col := range fks[i].RefColumns {
+			if col != "" {
+				continue
+			}
+			if primaryKeys == nil {
+				var err error
+				if primaryKeys, err = getPrimaryKeys(db, dbType, database, tableName); err != nil {
+					return err
+				}
+			}
+			if j < len(primaryKeys) {
+				fks[i].RefColumns[j] = primaryKeys[j]
+			}
+		}
+	}
+	return nil
+}
+
+// reorderRows sorts the diffs of one type and table so referenced rows come
+// first, or last when children first is asked for, in place within their slots
+func reorderRows(diffs []DataDiffResult, tableName, diffType string, fks []ForeignKeyInfo, childrenFirst bool) {
+	var slots []int
+	var rows []map[string]interface{}
+	for i, d := range diffs {
+		if d.TableName != tableName || d.Type != diffType {
+			continue
+		}
+		row := d.NewValues
+		if diffType == "delete" {
+			row = d.OldValues
+		}
+		if row == nil {
+			// Without the row's values its references can't be followed
+			return
+		}
+		slots = append(slots, i)
+		rows = append(rows, row)
+	}
+	if len(slots) < 2 {
+		return
+	}
+
+	order := parentsFirst(rows, fks)
+	if childrenFirst {
+		for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+			order[i], order[j] = order[j], order[i]
+		}
+	}
+
+	sorted := make([]DataDiffResult, len(slots))
+	for i, rowIndex := range order {
+		sorted[i] = diffs[slots[rowIndex]]
+	}
+	for i, slot := range slots {
+		diffs[slot] = sorted[i]
+	}
+}
+
+// parentsFirst returns the indexes of rows ordered so each row comes after the
+// rows among them it references through fks
+func parentsFirst(rows []map[string]interface{}, fks []ForeignKeyInfo) []int {
+	referenced := make([]map[string]int, len(fks))
+	for f, fk := range fks {
+		referenced[f] = make(map[string]int, len(rows))
+		for i, row := range rows {
+			referenced[f][rowKey(row, fk.RefColumns)] = i
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(rows))
+	order := make([]int, 0, len(rows))
+	var visit func(i int)
+	visit = func(i int) {
+		if state[i] != unvisited {
+			// visiting means a cycle, which can't be ordered
+			return
+		}
+		state[i] = visiting
+		for f, fk := range fks {
+			if parent, ok := referencedRow(rows[i], fk, referenced[f]); ok && parent != i {
+				visit(parent)
+			}
+		}
+		state[i] = done
+		order = append(order, i)
+	}
+	for i := range rows {
+		visit(i)
+	}
+	return order
+}
+
+// referencedRow finds the row that row references through fk, if it is among the rows
+func referencedRow(row map[string]interface{}, fk ForeignKeyInfo, referenced map[string]int) (int, bool) {
+	values := make(map[string]interface{}, len(fk.Columns))
+	for i, col := range fk.Columns {
+		if row[col] == nil || i >= len(fk.RefColumns) {
+			return 0, false
+		}
+		values[fk.RefColumns[i]] = row[col]
+	}
+	parent, ok := referenced[rowKey(values, fk.RefColumns)]
+	return parent, ok
+}
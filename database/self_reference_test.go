@@ -0,0 +1,107 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func employeeDiff(diffType string, id, managerID interface{}) DataDiffResult {
+	row := map[string]interface{}{"id": id, "manager_id": managerID}
+	d := DataDiffResult{Type: diffType, TableName: "employee"}
+	if diffType == "delete" {
+		d.OldValues = row
+	} else {
+		d.NewValues = row
+	}
+	return d
+}
+
+func diffOrder(diffs []DataDiffResult) []string {
+	var order []string
+	for _, d := range diffs {
+		row := d.NewValues
+		if row == nil {
+			row = d.OldValues
+		}
+		name := d.TableName + ":" + d.Type
+		if row != nil {
+			name += ":" + fmt.Sprint(row["id"])
+		}
+		order = append(order, name)
+	}
+	return order
+}
+
+func TestReorderRows(t *testing.T) {
+	fks := []ForeignKeyInfo{{Columns: []string{"manager_id"}, RefTable: "employee", RefColumns: []string{"id"}}}
+	other := DataDiffResult{Type: "insert", TableName: "dept", NewValues: map[string]interface{}{"id": int64(9)}}
+
+	tests := []struct {
+		name  string
+		diffs []DataDiffResult
+		want  []string
+	}{
+		{
+			"inserts parents first, other tables keep their slots",
+			[]DataDiffResult{employeeDiff("insert", int64(3), int64(2)), other, employeeDiff("insert", int64(2), int64(1)), employeeDiff("insert", int64(1), nil)},
+			[]string{"employee:insert:1", "dept:insert:9", "employee:insert:2", "employee:insert:3"},
+		},
+		{
+			"deletes children first",
+			[]DataDiffResult{employeeDiff("delete", int64(1), nil), employeeDiff("delete", int64(2), int64(1)), employeeDiff("delete", int64(3), int64(2))},
+			[]string{"employee:delete:3", "employee:delete:2", "employee:delete:1"},
+		},
+		{
+			"cycle keeps its order",
+			[]DataDiffResult{employeeDiff("insert", int64(1), int64(2)), employeeDiff("insert", int64(2), int64(1))},
+			[]string{"employee:insert:2", "employee:insert:1"},
+		},
+		{
+			"reference outside the diffs",
+			[]DataDiffResult{employeeDiff("insert", int64(5), int64(4)), employeeDiff("insert", int64(6), int64(5))},
+			[]string{"employee:insert:5", "employee:insert:6"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs := append([]DataDiffResult(nil), tt.diffs...)
+			reorderRows(diffs, "employee", "insert", fks, false)
+			reorderRows(diffs, "employee", "delete", fks, true)
+			if got := diffOrder(diffs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderSelfReferences(t *testing.T) {
+	config := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(t.TempDir(), "target.db")}
+	db, err := sql.Open("sqlite3", config.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE TABLE employee (id INTEGER PRIMARY KEY, manager_id INTEGER REFERENCES employee)"); err != nil {
+		t.Fatal(err)
+	}
+
+	diffs := []DataDiffResult{employeeDiff("insert", int64(2), int64(1)), employeeDiff("insert", int64(1), nil)}
+	target, err := Connect(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	ordered, err := orderSelfReferences(target, SQLite, "", diffs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := diffOrder(ordered), []string{"employee:insert:1", "employee:insert:2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %q, want %q", got, want)
+	}
+	if got := diffOrder(diffs); got[0] != "employee:insert:2" {
+		t.Errorf("input diffs were reordered in place: %q", got)
+	}
+}
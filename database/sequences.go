@@ -0,0 +1,132 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// SequenceInfo holds a PostgreSQL sequence
+type SequenceInfo struct {
+	Name      string `json:"name"`
+	DataType  string `json:"dataType"`
+	Start     int64  `json:"start"`
+	Min       int64  `json:"min"`
+	Max       int64  `json:"max"`
+	Increment int64  `json:"increment"`
+	Cache     int64  `json:"cache"`
+	Cycle     bool   `json:"cycle"`
+}
+
+// getPostgreSQLSequences reads the sequences of the public schema, including
+// those owned by serial/identity columns
+func getPostgreSQLSequences(db *sql.DB) (map[string]SequenceInfo, error) {
+	rows, err := db.Query(`
+		SELECT sequencename, data_type::text, start_value, min_value, max_value, increment_by, cache_size, cycle
+		FROM pg_sequences
+		WHERE schemaname = 'public'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sequences := make(map[string]SequenceInfo)
+	for rows.Next() {
+		var seq SequenceInfo
+		if err := rows.Scan(&seq.Name, &seq.DataType, &seq.Start, &seq.Min, &seq.Max, &seq.Increment, &seq.Cache, &seq.Cycle); err != nil {
+			return nil, err
+		}
+		sequences[seq.Name] = seq
+	}
+	return sequences, nil
+}
+
+// compareSequences diffs sequence properties. The current value is not
+// compared; it moves with the data.
+func compareSequences(source, target map[string]SequenceInfo) []DiffResult {
+	var results []DiffResult
+
+	for name, sourceSeq := range source {
+		targetSeq, exists := target[name]
+		if !exists {
+			results = append(results, DiffResult{
+				Type:       "added",
+				TableName:  name,
+				Detail:     "Sequence exists in source but not in target",
+				SQL:        buildCreateSequence(sourceSeq),
+				ObjectType: "sequence",
+			})
+			continue
+		}
+
+		clauses, changes := sequenceChanges(sourceSeq, targetSeq)
+		if len(clauses) > 0 {
+			results = append(results, DiffResult{
+				Type:       "modified",
+				TableName:  name,
+				Detail:     fmt.Sprintf("Sequence differs: %s", strings.Join(changes, ", ")),
+				SQL:        fmt.Sprintf("ALTER SEQUENCE \"%s\" %s;", name, strings.Join(clauses, " ")),
+				ObjectType: "sequence",
+			})
+		}
+	}
+
+	for name := range target {
+		if _, exists := source[name]; !exists {
+			// Sequences owned by a dropped table go with it, hence IF EXISTS
+			results = append(results, DiffResult{
+				Type:       "removed",
+				TableName:  name,
+				Detail:     "Sequence exists in target but not in source",
+				SQL:        fmt.Sprintf("DROP SEQUENCE IF EXISTS \"%s\";", name),
+				ObjectType: "sequence",
+			})
+		}
+	}
+
+	return results
+}
+
+// sequenceChanges returns the ALTER SEQUENCE clauses and a description of each difference
+func sequenceChanges(source, target SequenceInfo) ([]string, []string) {
+	var clauses, changes []string
+	add := func(label, from, to, clause string) {
+		changes = append(changes, fmt.Sprintf("%s %s -> %s", label, from, to))
+		clauses = append(clauses, clause)
+	}
+
+	if !strings.EqualFold(source.DataType, target.DataType) {
+		add("type", target.DataType, source.DataType, "AS "+source.DataType)
+	}
+	if source.Increment != target.Increment {
+		add("increment", fmt.Sprint(target.Increment), fmt.Sprint(source.Increment), fmt.Sprintf("INCREMENT BY %d", source.Increment))
+	}
+	if source.Min != target.Min {
+		add("min", fmt.Sprint(target.Min), fmt.Sprint(source.Min), fmt.Sprintf("MINVALUE %d", source.Min))
+	}
+	if source.Max != target.Max {
+		add("max", fmt.Sprint(target.Max), fmt.Sprint(source.Max), fmt.Sprintf("MAXVALUE %d", source.Max))
+	}
+	if source.Start != target.Start {
+		add("start", fmt.Sprint(target.Start), fmt.Sprint(source.Start), fmt.Sprintf("START WITH %d", source.Start))
+	}
+	if source.Cache != target.Cache {
+		add("cache", fmt.Sprint(target.Cache), fmt.Sprint(source.Cache), fmt.Sprintf("CACHE %d", source.Cache))
+	}
+	if source.Cycle != target.Cycle {
+		add("cycle", fmt.Sprint(target.Cycle), fmt.Sprint(source.Cycle), cycleClause(source.Cycle))
+	}
+	return clauses, changes
+}
+
+func buildCreateSequence(seq SequenceInfo) string {
+	return fmt.Sprintf("CREATE SEQUENCE \"%s\" AS %s INCREMENT BY %d MINVALUE %d MAXVALUE %d START WITH %d CACHE %d %s;",
+		seq.Name, seq.DataType, seq.Increment, seq.Min, seq.Max, seq.Start, seq.Cache, cycleClause(seq.Cycle))
+}
+
+func cycleClause(cycle bool) string {
+	if cycle {
+		return "CYCLE"
+	}
+	return "NO CYCLE"
+}
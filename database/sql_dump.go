@@ -0,0 +1,761 @@
+package database
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ParseSchemaFromSQL reads the tables of a SQL dump into a SchemaInfo, so a dump
+// can be compared with a live database. CREATE TABLE, CREATE INDEX, the key and
+// constraint clauses of ALTER TABLE, CREATE VIEW and CREATE TRIGGER are understood;
+// other statements are skipped.
+// Only MySQL dump syntax (mysqldump, phpMyAdmin) is supported.
+func ParseSchemaFromSQL(r io.Reader, dbType DBType) (*SchemaInfo, error) {
+	if dbType == "" {
+		dbType = MySQL
+	}
+	if dbType != MySQL {
+		return nil, fmt.Errorf("parsing %s dumps is not supported", dbType)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dump: %v", err)
+	}
+
+	schema := &SchemaInfo{
+		DBType: dbType,
+		Tables: make(map[string]TableInfo),
+		Views:  make(map[string]ViewInfo),
+	}
+	tables := make(map[string]*dumpTable)
+	var order []string
+
+	for _, stmt := range splitDumpStatements(string(data)) {
+		switch {
+		case dumpDropPattern.MatchString(stmt):
+			// mysqldump creates placeholders for views and drops them before the real view
+			m := dumpDropPattern.FindStringSubmatch(stmt)
+			for _, name := range splitTopLevel(m[2]) {
+				name = unquoteDumpIdentifier(name)
+				if strings.EqualFold(m[1], "VIEW") {
+					delete(schema.Views, name)
+				} else {
+					delete(tables, name)
+				}
+			}
+
+		case dumpCreateViewPattern.MatchString(stmt):
+			name := unquoteDumpIdentifier(dumpCreateViewPattern.FindStringSubmatch(stmt)[1])
+			schema.Views[name] = ViewInfo{Name: name, Definition: viewBody(stmt)}
+
+		case dumpCreateTriggerPattern.MatchString(stmt):
+			m := dumpCreateTriggerPattern.FindStringSubmatch(stmt)
+			table, ok := tables[unquoteDumpIdentifier(m[4])]
+			if !ok {
+				continue
+			}
+			trigger := TriggerInfo{
+				Name:      unquoteDumpIdentifier(m[1]),
+				Timing:    strings.ToUpper(m[2]),
+				Event:     strings.ToUpper(m[3]),
+				Statement: strings.TrimSpace(m[5]),
+			}
+			// ACTION_ORDER counts the triggers of an event and timing in creation order
+			trigger.Order = 1
+			for _, other := range table.info.Triggers {
+				if other.Timing == trigger.Timing && other.Event == trigger.Event {
+					trigger.Order++
+				}
+			}
+			table.info.Triggers = append(table.info.Triggers, trigger)
+
+		case dumpUsePattern.MatchString(stmt):
+			schema.Database = unquoteDumpIdentifier(dumpUsePattern.FindStringSubmatch(stmt)[1])
+
+		case dumpCreateTablePattern.MatchString(stmt):
+			m := dumpCreateTablePattern.FindStringSubmatchIndex(stmt)
+			name := unquoteDumpIdentifier(stmt[m[2]:m[3]])
+			body, ok := parenthesized(stmt[m[1]-1:])
+			if !ok {
+				return nil, fmt.Errorf("table %s: unbalanced parentheses", name)
+			}
+			table := &dumpTable{info: TableInfo{Name: name, CreateSQL: stmt}}
+			for _, item := range splitTopLevel(body) {
+				if err := table.addDefinition(item); err != nil {
+					return nil, fmt.Errorf("table %s: %v", name, err)
+				}
+			}
+			if _, exists := tables[name]; !exists {
+				order = append(order, name)
+			}
+			tables[name] = table
+
+		case dumpCreateIndexPattern.MatchString(stmt):
+			m := dumpCreateIndexPattern.FindStringSubmatch(stmt)
+			table, ok := tables[unquoteDumpIdentifier(m[3])]
+			if !ok {
+				continue
+			}
+			table.addIndex(unquoteDumpIdentifier(m[2]), !strings.EqualFold(strings.TrimSpace(m[1]), "UNIQUE"), m[4])
+
+		case dumpAlterTablePattern.MatchString(stmt):
+			m := dumpAlterTablePattern.FindStringSubmatch(stmt)
+			table, ok := tables[unquoteDumpIdentifier(m[1])]
+			if !ok {
+				continue
+			}
+			for _, clause := range splitTopLevel(m[2]) {
+				if err := table.alter(clause); err != nil {
+					return nil, fmt.Errorf("table %s: %v", table.info.Name, err)
+				}
+			}
+		}
+	}
+
+	for _, name := range order {
+		table, ok := tables[name]
+		if !ok {
+			continue
+		}
+		table.setColumnKeys()
+		schema.Tables[name] = table.info
+	}
+	return schema, nil
+}
+
+// dumpName matches a possibly quoted and schema-qualified name
+const dumpName = "((?:`[^`]+`|[\\w$]+)(?:\\.(?:`[^`]+`|[\\w$]+))?)"
+
+var (
+	dumpUsePattern           = regexp.MustCompile("(?is)^USE\\s+" + dumpName + "$")
+	dumpDropPattern          = regexp.MustCompile("(?is)^DROP\\s+(TABLE|VIEW)\\s+(?:IF\\s+EXISTS\\s+)?(.*)$")
+	dumpCreateTablePattern   = regexp.MustCompile("(?is)^CREATE\\s+(?:TEMPORARY\\s+)?TABLE\\s+(?:IF\\s+NOT\\s+EXISTS\\s+)?" + dumpName + "\\s*\\(")
+	dumpCreateIndexPattern   = regexp.MustCompile("(?is)^CREATE\\s+(UNIQUE\\s+|FULLTEXT\\s+|SPATIAL\\s+)?INDEX\\s+" + dumpName + "\\s+ON\\s+" + dumpName + "\\s*(\\(.*)$")
+	dumpAlterTablePattern    = regexp.MustCompile("(?is)^ALTER\\s+TABLE\\s+" + dumpName + "\\s+(.*)$")
+	dumpCreateViewPattern    = regexp.MustCompile("(?is)^CREATE\\s+(?:OR\\s+REPLACE\\s+)?(?:ALGORITHM\\s*=\\s*\\w+\\s+)?(?:DEFINER\\s*=\\s*\\S+\\s+)?(?:SQL\\s+SECURITY\\s+\\w+\\s+)?VIEW\\s+" + dumpName)
+	dumpCreateTriggerPattern = regexp.MustCompile("(?is)^CREATE\\s+(?:DEFINER\\s*=\\s*\\S+\\s+)?TRIGGER\\s+" + dumpName + "\\s+(BEFORE|AFTER)\\s+(INSERT|UPDATE|DELETE)\\s+ON\\s+" + dumpName + "\\s+FOR\\s+EACH\\s+ROW\\s+(?:(?:FOLLOWS|PRECEDES)\\s+\\S+\\s+)?(.*)$")
+	dumpTypeModifiers        = map[string]bool{"UNSIGNED": true, "SIGNED": true, "ZEROFILL": true, "PRECISION": true, "VARYING": true}
+)
+
+// dumpTable collects one table's definition while the dump is parsed
+type dumpTable struct {
+	info TableInfo
+}
+
+// alter applies the ADD key/constraint and MODIFY column clauses of an ALTER TABLE,
+// which phpMyAdmin uses for keys and AUTO_INCREMENT
+func (t *dumpTable) alter(clause string) error {
+	fields := strings.Fields(clause)
+	if len(fields) < 2 {
+		return nil
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "ADD":
+		def := strings.TrimSpace(clause[len(fields[0]):])
+		if isKeyDefinition(def) {
+			return t.addDefinition(def)
+		}
+	case "MODIFY":
+		def := strings.TrimSpace(clause[len(fields[0]):])
+		if strings.EqualFold(fields[1], "COLUMN") {
+			def = strings.TrimSpace(def[len(fields[1]):])
+		}
+		for i, col := range t.info.Columns {
+			if col.Name != unquoteDumpIdentifier(strings.Fields(def)[0]) {
+				continue
+			}
+			if err := t.addDefinition(def); err != nil {
+				return err
+			}
+			modified := t.info.Columns[len(t.info.Columns)-1]
+			modified.Position = col.Position
+			if col.Key != "" {
+				modified.Key = col.Key
+			}
+			t.info.Columns[i] = modified
+			t.info.Columns = t.info.Columns[:len(t.info.Columns)-1]
+			return nil
+		}
+	}
+	return nil
+}
+
+// addDefinition adds one comma-separated item of a CREATE TABLE body
+func (t *dumpTable) addDefinition(item string) error {
+	tokens := tokenizeDumpDefinition(item)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	upper := make([]string, len(tokens))
+	for i, tok := range tokens {
+		upper[i] = strings.ToUpper(tok)
+	}
+
+	// An optional CONSTRAINT name precedes PRIMARY KEY, UNIQUE, FOREIGN KEY and CHECK
+	constraintName := ""
+	if upper[0] == "CONSTRAINT" {
+		if len(tokens) > 2 && !isDumpKeyword(upper[1]) {
+			constraintName = unquoteDumpIdentifier(tokens[1])
+			tokens, upper = tokens[2:], upper[2:]
+		} else {
+			tokens, upper = tokens[1:], upper[1:]
+		}
+	}
+
+	switch upper[0] {
+	case "PRIMARY":
+		cols := findGroup(tokens)
+		t.addIndex("PRIMARY", false, cols)
+		t.info.PrimaryKey = &PrimaryKeyInfo{Name: "PRIMARY", Columns: indexColumnNames(cols)}
+		return nil
+	case "UNIQUE", "KEY", "INDEX", "FULLTEXT", "SPATIAL":
+		nonUnique := upper[0] != "UNIQUE"
+		name := constraintName
+		for _, tok := range tokens[1:] {
+			u := strings.ToUpper(tok)
+			if strings.HasPrefix(tok, "(") {
+				break
+			}
+			if u != "KEY" && u != "INDEX" {
+				name = unquoteDumpIdentifier(tok)
+				break
+			}
+		}
+		cols := findGroup(tokens)
+		if name == "" {
+			// MySQL names an unnamed index after its first column
+			if names := indexColumnNames(cols); len(names) > 0 {
+				name = names[0]
+			}
+		}
+		t.addIndex(name, nonUnique, cols)
+		t.setIndexVisibility(name, upper)
+		return nil
+	case "FOREIGN":
+		return t.addForeignKey(constraintName, tokens, upper)
+	case "CHECK":
+		return nil
+	}
+
+	return t.addColumn(tokens, upper)
+}
+
+func (t *dumpTable) addColumn(tokens, upper []string) error {
+	if len(tokens) < 2 {
+		return fmt.Errorf("incomplete column definition: %s", strings.Join(tokens, " "))
+	}
+	col := ColumnInfo{
+		Name:     unquoteDumpIdentifier(tokens[0]),
+		Type:     lowerTypeName(tokens[1]),
+		Nullable: "YES",
+		Position: len(t.info.Columns) + 1,
+	}
+
+	i := 2
+	for i < len(tokens) && dumpTypeModifiers[upper[i]] {
+		col.Type += " " + strings.ToLower(tokens[i])
+		i++
+	}
+
+	var extra []string
+	for ; i < len(tokens); i++ {
+		switch upper[i] {
+		case "NOT":
+			if i+1 < len(tokens) && upper[i+1] == "NULL" {
+				col.Nullable = "NO"
+				i++
+			}
+		case "NULL":
+			col.Nullable = "YES"
+		case "DEFAULT":
+			if i+1 < len(tokens) {
+				i++
+				col.Default = parseDumpDefault(tokens[i])
+			}
+		case "AUTO_INCREMENT":
+			extra = append(extra, "auto_increment")
+		case "ON":
+			if i+2 < len(tokens) && upper[i+1] == "UPDATE" {
+				col.OnUpdate = tokens[i+2]
+				i += 2
+			}
+		case "COLLATE":
+			if i+1 < len(tokens) {
+				col.Collation = unquoteDumpIdentifier(tokens[i+1])
+				i++
+			}
+		case "CHARACTER", "CHARSET":
+			// The character set follows from the collation
+			if upper[i] == "CHARACTER" {
+				i++
+			}
+			i++
+		case "COMMENT", "COLUMN_FORMAT", "STORAGE", "ENGINE_ATTRIBUTE", "SECONDARY_ENGINE_ATTRIBUTE":
+			i++
+		case "SRID":
+			if i+1 < len(tokens) {
+				if srid, err := strconv.Atoi(tokens[i+1]); err == nil {
+					col.SRID = &srid
+				}
+				i++
+			}
+		case "INVISIBLE":
+			col.Invisible = true
+		case "GENERATED", "ALWAYS":
+		case "AS":
+			kind := "VIRTUAL GENERATED"
+			for j := i + 1; j < len(tokens); j++ {
+				if upper[j] == "STORED" || upper[j] == "PERSISTENT" {
+					kind = "STORED GENERATED"
+				}
+			}
+			extra = append(extra, kind)
+			i++
+		case "VIRTUAL", "STORED", "PERSISTENT":
+		case "PRIMARY":
+			t.addIndex("PRIMARY", false, "`"+col.Name+"`")
+			t.info.PrimaryKey = &PrimaryKeyInfo{Name: "PRIMARY", Columns: []string{col.Name}}
+			col.Nullable = "NO"
+			if i+1 < len(tokens) && upper[i+1] == "KEY" {
+				i++
+			}
+		case "UNIQUE":
+			t.addIndex(col.Name, false, "`"+col.Name+"`")
+			if i+1 < len(tokens) && upper[i+1] == "KEY" {
+				i++
+			}
+		}
+	}
+	col.Extra = strings.Join(extra, " ")
+
+	t.info.Columns = append(t.info.Columns, col)
+	return nil
+}
+
+func (t *dumpTable) addForeignKey(name string, tokens, upper []string) error {
+	fk := ForeignKeyInfo{Name: name}
+	refSeen := false
+	for i := 1; i < len(tokens); i++ {
+		switch {
+		case strings.HasPrefix(tokens[i], "(") && !refSeen:
+			fk.Columns = indexColumnNames(tokens[i])
+		case upper[i] == "REFERENCES" && i+1 < len(tokens):
+			refSeen = true
+			i++
+			ref := tokens[i]
+			if idx := strings.Index(ref, "("); idx > 0 {
+				// Table name and column list written without a space
+				fk.RefColumns = indexColumnNames(ref[idx:])
+				ref = ref[:idx]
+			}
+			fk.RefTable = unquoteDumpIdentifier(ref)
+		case strings.HasPrefix(tokens[i], "(") && refSeen:
+			fk.RefColumns = indexColumnNames(tokens[i])
+		case upper[i] == "ON" && i+2 < len(tokens):
+			action := upper[i+2]
+			skip := 2
+			if (action == "SET" || action == "NO") && i+3 < len(tokens) {
+				action += " " + upper[i+3]
+				skip = 3
+			}
+			if upper[i+1] == "DELETE" {
+				fk.OnDelete = action
+			} else if upper[i+1] == "UPDATE" {
+				fk.OnUpdate = action
+			}
+			i += skip
+		}
+	}
+	if fk.RefTable == "" || len(fk.Columns) == 0 {
+		return fmt.Errorf("incomplete foreign key %s", name)
+	}
+	if fk.Name == "" {
+		// InnoDB's generated name
+		fk.Name = fmt.Sprintf("%s_ibfk_%d", t.info.Name, len(t.info.ForeignKeys)+1)
+	}
+	t.info.ForeignKeys = append(t.info.ForeignKeys, fk)
+	return nil
+}
+
+// addIndex adds the key parts of a parenthesized column list, e.g. (`a`,`b`(10),(lower(`c`)))
+func (t *dumpTable) addIndex(name string, nonUnique bool, parts string) {
+	unique := 1
+	if !nonUnique {
+		unique = 0
+	}
+	inner, ok := parenthesized(parts)
+	if !ok {
+		inner = parts
+	}
+	for seq, part := range splitTopLevel(inner) {
+		part = strings.TrimSpace(part)
+		idx := IndexInfo{Name: name, NonUnique: unique, SeqInIdx: seq + 1}
+		if strings.HasPrefix(part, "(") {
+			idx.Expression, _ = parenthesized(part)
+		} else {
+			idx.Column = keyPartColumn(part)
+		}
+		t.info.Indexes = append(t.info.Indexes, idx)
+	}
+}
+
+// setIndexVisibility marks an index INVISIBLE when its definition says so
+func (t *dumpTable) setIndexVisibility(name string, upper []string) {
+	for _, tok := range upper {
+		if tok == "INVISIBLE" {
+			for i := range t.info.Indexes {
+				if t.info.Indexes[i].Name == name {
+					t.info.Indexes[i].Invisible = true
+				}
+			}
+		}
+	}
+}
+
+// setColumnKeys fills in COLUMN_KEY the way MySQL reports it: PRI, UNI or MUL
+// for the first column of the primary key, a unique index or another index
+func (t *dumpTable) setColumnKeys() {
+	rank := map[string]int{"": 0, "MUL": 1, "UNI": 2, "PRI": 3}
+	for _, idx := range t.info.Indexes {
+		key := "MUL"
+		switch {
+		case idx.Name == "PRIMARY":
+			key = "PRI"
+		case idx.NonUnique == 0:
+			key = "UNI"
+		}
+		if idx.SeqInIdx != 1 && key != "PRI" {
+			continue
+		}
+		for i := range t.info.Columns {
+			if t.info.Columns[i].Name == idx.Column && rank[key] > rank[t.info.Columns[i].Key] {
+				t.info.Columns[i].Key = key
+			}
+		}
+	}
+}
+
+// lowerTypeName lowercases a type like VARCHAR(10) or ENUM('A','B') but not its arguments
+func lowerTypeName(t string) string {
+	if idx := strings.Index(t, "("); idx >= 0 {
+		return strings.ToLower(t[:idx]) + t[idx:]
+	}
+	return strings.ToLower(t)
+}
+
+// isKeyDefinition reports whether an ALTER TABLE ADD clause defines a key or constraint
+func isKeyDefinition(def string) bool {
+	word := strings.ToUpper(strings.Fields(def)[0])
+	switch word {
+	case "PRIMARY", "UNIQUE", "KEY", "INDEX", "FULLTEXT", "SPATIAL", "CONSTRAINT", "FOREIGN":
+		return true
+	}
+	return false
+}
+
+func isDumpKeyword(upper string) bool {
+	switch upper {
+	case "PRIMARY", "UNIQUE", "FOREIGN", "CHECK":
+		return true
+	}
+	return false
+}
+
+// findGroup returns the first parenthesized token
+func findGroup(tokens []string) string {
+	for _, tok := range tokens[1:] {
+		if strings.HasPrefix(tok, "(") {
+			return tok
+		}
+	}
+	return ""
+}
+
+// indexColumnNames returns the column names of a parenthesized key part list
+func indexColumnNames(group string) []string {
+	inner, ok := parenthesized(group)
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, part := range splitTopLevel(inner) {
+		if name := keyPartColumn(strings.TrimSpace(part)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// keyPartColumn strips the prefix length and sort order from a key part like `name`(10) DESC
+func keyPartColumn(part string) string {
+	if strings.HasPrefix(part, "`") {
+		if end := strings.Index(part[1:], "`"); end >= 0 {
+			return unquoteDumpIdentifier(part[:end+2])
+		}
+	}
+	if fields := strings.Fields(part); len(fields) > 0 {
+		name := fields[0]
+		if idx := strings.Index(name, "("); idx > 0 {
+			name = name[:idx]
+		}
+		return unquoteDumpIdentifier(name)
+	}
+	return ""
+}
+
+// parseDumpDefault turns a DEFAULT token into the value INFORMATION_SCHEMA reports
+func parseDumpDefault(tok string) *string {
+	if strings.EqualFold(tok, "NULL") {
+		return nil
+	}
+	value := tok
+	if strings.HasPrefix(tok, "'") && strings.HasSuffix(tok, "'") && len(tok) >= 2 {
+		value = unquoteDumpString(tok)
+	} else if inner, ok := parenthesized(tok); ok && len(inner) == len(tok)-2 {
+		// Expression defaults are reported without their parentheses
+		value = inner
+	}
+	return &value
+}
+
+// unquoteDumpIdentifier strips backticks and a schema qualifier: `db`.`t` -> t
+func unquoteDumpIdentifier(name string) string {
+	name = strings.TrimSpace(name)
+	last := 0
+	for i := 0; i < len(name); {
+		switch name[i] {
+		case '`':
+			i = closingQuote(name, i)
+			continue
+		case '.':
+			last = i + 1
+		}
+		i++
+	}
+	name = name[last:]
+	if len(name) >= 2 && name[0] == '`' && name[len(name)-1] == '`' {
+		// Doubled backticks inside an identifier stand for one
+		return strings.ReplaceAll(name[1:len(name)-1], "``", "`")
+	}
+	return name
+}
+
+func unquoteDumpString(s string) string {
+	s = s[1 : len(s)-1]
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '0':
+				b.WriteByte(0)
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		if c == '\'' && i+1 < len(s) && s[i+1] == '\'' {
+			i++
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// tokenizeDumpDefinition splits a column or key definition into words, quoted
+// strings and parenthesized groups. A group or string directly following a word,
+// as in varchar(255) or b'1', stays part of that word.
+func tokenizeDumpDefinition(def string) []string {
+	var tokens []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	for i := 0; i < len(def); {
+		c := def[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			flush()
+			i++
+		case c == '(':
+			if strings.HasSuffix(current.String(), "`") {
+				// `name`(cols): the identifier and the list are separate tokens
+				flush()
+			}
+			end := matchingParen(def, i)
+			current.WriteString(def[i:end])
+			i = end
+		case c == '\'' || c == '"' || c == '`':
+			end := closingQuote(def, i)
+			current.WriteString(def[i:end])
+			i = end
+		default:
+			current.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// parenthesized returns the content of the parenthesized group s starts with
+func parenthesized(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "(") {
+		return "", false
+	}
+	end := matchingParen(s, 0)
+	if end > len(s) || s[end-1] != ')' {
+		return "", false
+	}
+	return s[1 : end-1], true
+}
+
+// matchingParen returns the index just past the parenthesis closing the one at start
+func matchingParen(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case '\'', '"', '`':
+			i = closingQuote(s, i)
+			continue
+		}
+		i++
+	}
+	return len(s)
+}
+
+// closingQuote returns the index just past the quote closing the one at start,
+// honoring backslash escapes and doubled quotes
+func closingQuote(s string, start int) int {
+	q := s[start]
+	for i := start + 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && q != '`':
+			i++
+		case s[i] == q:
+			if i+1 < len(s) && s[i+1] == q {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// splitTopLevel splits s on commas outside parentheses and quotes
+func splitTopLevel(s string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '(':
+			i = matchingParen(s, i)
+			continue
+		case '\'', '"', '`':
+			i = closingQuote(s, i)
+			continue
+		case ',':
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+		i++
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+// splitDumpStatements splits a dump into statements. Comments are dropped except
+// MySQL's versioned /*!NNNNN ... */ comments, whose content is kept, and DELIMITER
+// lines change the statement terminator as in the mysql client.
+func splitDumpStatements(dump string) []string {
+	var statements []string
+	var current strings.Builder
+	delimiter := ";"
+	inVersioned := false
+
+	emit := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(dump); {
+		rest := dump[i:]
+		atLineStart := i == 0 || dump[i-1] == '\n'
+		switch {
+		case atLineStart && strings.TrimSpace(current.String()) == "" && len(rest) > 10 && strings.EqualFold(rest[:10], "DELIMITER "):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			if d := strings.TrimSpace(rest[10:end]); d != "" {
+				delimiter = d
+			}
+			current.Reset()
+			i += end
+		case strings.HasPrefix(rest, "/*!"):
+			// Versioned comment: keep the content, drop the marker and version number
+			inVersioned = true
+			i += 3
+			for i < len(dump) && dump[i] >= '0' && dump[i] <= '9' {
+				i++
+			}
+		case inVersioned && strings.HasPrefix(rest, "*/"):
+			inVersioned = false
+			i += 2
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				i = len(dump)
+			} else {
+				i += end + 4
+			}
+		case strings.HasPrefix(rest, "#") || strings.HasPrefix(rest, "-- ") || strings.HasPrefix(rest, "--\n") || rest == "--":
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			i += end
+		case rest[0] == '\'' || rest[0] == '"' || rest[0] == '`':
+			end := closingQuote(dump, i)
+			current.WriteString(dump[i:end])
+			i = end
+		case strings.HasPrefix(rest, delimiter):
+			emit()
+			i += len(delimiter)
+		default:
+			current.WriteByte(dump[i])
+			i++
+		}
+	}
+	emit()
+	return statements
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const mysqldumpSample = `-- MySQL dump 10.13
+/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;
+USE ` + "`shop`" + `;
+
+DROP TABLE IF EXISTS ` + "`customer`" + `;
+CREATE TABLE ` + "`customer`" + ` (
+  ` + "`id`" + ` int unsigned NOT NULL AUTO_INCREMENT,
+  ` + "`email`" + ` varchar(255) COLLATE utf8mb4_bin NOT NULL,
+  ` + "`note`" + ` text COMMENT 'free; text',
+  ` + "`created`" + ` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (` + "`id`" + `),
+  UNIQUE KEY ` + "`uq_email`" + ` (` + "`email`" + `),
+  KEY ` + "`idx_note`" + ` (` + "`note`" + `(20))
+) ENGINE=InnoDB;
+
+CREATE TABLE ` + "`orders`" + ` (
+  ` + "`id`" + ` int NOT NULL,
+  ` + "`customer_id`" + ` int unsigned NOT NULL,
+  CONSTRAINT ` + "`fk_customer`" + ` FOREIGN KEY (` + "`customer_id`" + `) REFERENCES ` + "`customer`" + ` (` + "`id`" + `) ON DELETE SET NULL
+);
+ALTER TABLE ` + "`orders`" + `
+  ADD PRIMARY KEY (` + "`id`" + `),
+  MODIFY ` + "`id`" + ` int NOT NULL AUTO_INCREMENT;
+
+/*!50001 CREATE TABLE ` + "`active`" + ` (` + "`id`" + ` tinyint NOT NULL) */;
+/*!50001 DROP VIEW IF EXISTS ` + "`active`" + `*/;
+/*!50001 DROP TABLE IF EXISTS ` + "`active`" + `*/;
+/*!50001 CREATE ALGORITHM=UNDEFINED */
+/*!50013 DEFINER=` + "`root`@`localhost`" + ` SQL SECURITY DEFINER */
+/*!50001 VIEW ` + "`active`" + ` AS select ` + "`id`" + ` from ` + "`customer`" + ` */;
+
+DELIMITER ;;
+CREATE TRIGGER ` + "`customer_bi`" + ` BEFORE INSERT ON ` + "`customer`" + ` FOR EACH ROW BEGIN
+  SET NEW.email = LOWER(NEW.email);
+END ;;
+DELIMITER ;
+`
+
+func TestParseSchemaFromSQL(t *testing.T) {
+	schema, err := ParseSchemaFromSQL(strings.NewReader(mysqldumpSample), MySQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.Database != "shop" {
+		t.Errorf("database = %q, want shop", schema.Database)
+	}
+	if _, ok := schema.Tables["active"]; ok {
+		t.Error("view placeholder table was kept")
+	}
+	if len(schema.Tables) != 2 {
+		t.Fatalf("tables = %v, want customer and orders", schema.Tables)
+	}
+
+	customer := schema.Tables["customer"]
+	var cols []string
+	for _, c := range customer.Columns {
+		cols = append(cols, c.Name+" "+c.Type+" "+c.Nullable+" "+c.Key+" "+c.Extra)
+	}
+	wantCols := []string{
+		"id int unsigned NO PRI auto_increment",
+		"email varchar(255) NO UNI ",
+		"note text YES MUL ",
+		"created timestamp YES  ",
+	}
+	if !reflect.DeepEqual(cols, wantCols) {
+		t.Errorf("columns = %q, want %q", cols, wantCols)
+	}
+	if got := customer.Columns[1].Collation; got != "utf8mb4_bin" {
+		t.Errorf("email collation = %q, want utf8mb4_bin", got)
+	}
+	if d := customer.Columns[3].Default; d == nil || *d != "CURRENT_TIMESTAMP" || customer.Columns[3].OnUpdate != "CURRENT_TIMESTAMP" {
+		t.Errorf("created default = %v, on update %q", d, customer.Columns[3].OnUpdate)
+	}
+	if customer.PrimaryKey == nil || !reflect.DeepEqual(customer.PrimaryKey.Columns, []string{"id"}) {
+		t.Errorf("primary key = %+v, want (id)", customer.PrimaryKey)
+	}
+	if len(customer.Triggers) != 1 || customer.Triggers[0].Name != "customer_bi" || customer.Triggers[0].Timing != "BEFORE" || customer.Triggers[0].Event != "INSERT" {
+		t.Errorf("triggers = %+v, want customer_bi BEFORE INSERT", customer.Triggers)
+	}
+
+	orders := schema.Tables["orders"]
+	if orders.PrimaryKey == nil || orders.Columns[0].Extra != "auto_increment" || orders.Columns[0].Key != "PRI" {
+		t.Errorf("ALTER TABLE keys not applied: pk %+v, id %+v", orders.PrimaryKey, orders.Columns[0])
+	}
+	wantFK := ForeignKeyInfo{Name: "fk_customer", Columns: []string{"customer_id"}, RefTable: "customer", RefColumns: []string{"id"}, OnDelete: "SET NULL"}
+	if len(orders.ForeignKeys) != 1 || !reflect.DeepEqual(orders.ForeignKeys[0], wantFK) {
+		t.Errorf("foreign keys = %+v, want %+v", orders.ForeignKeys, wantFK)
+	}
+
+	view, ok := schema.Views["active"]
+	if !ok || !strings.Contains(view.Definition, "from `customer`") {
+		t.Errorf("view = %+v, want the definition of active", view)
+	}
+}
+
+func TestParseSchemaFromSQLRejectsOtherDialects(t *testing.T) {
+	if _, err := ParseSchemaFromSQL(strings.NewReader(""), PostgreSQL); err == nil {
+		t.Error("expected an error for a PostgreSQL dump")
+	}
+}
+
+func TestSplitDumpStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		dump string
+		want []string
+	}{
+		{"semicolon in string", "INSERT INTO t VALUES ('a;b');\nSELECT 1;", []string{"INSERT INTO t VALUES ('a;b')", "SELECT 1"}},
+		{"comments dropped", "-- note\n# other\n/* block; */SELECT 1;", []string{"SELECT 1"}},
+		{"versioned comment kept", "/*!40101 SET NAMES utf8 */;", []string{"SET NAMES utf8"}},
+		{"delimiter", "DELIMITER $$\nCREATE TRIGGER x BEFORE INSERT ON t FOR EACH ROW BEGIN SET @a = 1; END$$\nDELIMITER ;\nSELECT 2;",
+			[]string{"CREATE TRIGGER x BEFORE INSERT ON t FOR EACH ROW BEGIN SET @a = 1; END", "SELECT 2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitDumpStatements(tt.dump)
+			for i := range got {
+				got[i] = strings.TrimSpace(got[i])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"strings"
+	"unicode"
+)
+
+// qualifiedNameKeywords precede a table name, where a two-part name is schema.table
+var qualifiedNameKeywords = map[string]bool{
+	"from": true, "join": true, "into": true, "update": true, "table": true, "on": true,
+}
+
+// normalizeSQLBody reduces a view, routine or trigger body to a canonical form so
+// definitions that only differ in how the engine stored them compare equal:
+// whitespace, comments and identifier quoting are dropped, words are lower-cased
+// and trailing semicolons removed. Schema qualifiers engines add are stripped:
+// the first part of three-part column names and of two-part names after FROM,
+// JOIN and the like. String literals are kept as written.
+func normalizeSQLBody(body string) string {
+	tokens := tokenizeSQLBody(body)
+	for len(tokens) > 0 && tokens[len(tokens)-1].text == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	var out []string
+	for i := 0; i < len(tokens); i++ {
+		// a.b.c -> b.c everywhere, a.b -> b after a table keyword
+		if tokens[i].ident && i+2 < len(tokens) && tokens[i+1].text == "." && tokens[i+2].ident {
+			threePart := i+4 < len(tokens) && tokens[i+3].text == "." && tokens[i+4].ident
+			afterKeyword := len(out) > 0 && qualifiedNameKeywords[out[len(out)-1]] &&
+				!(i+3 < len(tokens) && tokens[i+3].text == ".")
+			if threePart || afterKeyword {
+				i++ // skip the qualifier, the loop skips the dot
+				continue
+			}
+		}
+		out = append(out, tokens[i].text)
+	}
+	return strings.Join(out, " ")
+}
+
+type sqlToken struct {
+	text  string
+	ident bool // a word or quoted identifier, as opposed to a literal or punctuation
+}
+
+// tokenizeSQLBody splits SQL into lower-cased words, unquoted identifiers, string
+// literals and single punctuation characters, skipping whitespace and comments
+func tokenizeSQLBody(body string) []sqlToken {
+	var tokens []sqlToken
+	runes := []rune(body)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				i++
+			}
+			i += 2
+		case r == '\'':
+			start := i
+			i++
+			for i < len(runes) {
+				if runes[i] == '\\' {
+					i += 2
+					continue
+				}
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			if i < len(runes) {
+				i++
+			}
+			tokens = append(tokens, sqlToken{text: string(runes[start:i])})
+		case r == '`' || r == '"' || r == '[':
+			closing := r
+			if r == '[' {
+				closing = ']'
+			}
+			start := i + 1
+			i = start
+			for i < len(runes) && runes[i] != closing {
+				i++
+			}
+			tokens = append(tokens, sqlToken{text: strings.ToLower(string(runes[start:i])), ident: true})
+			if i < len(runes) {
+				i++
+			}
+		case isSQLWordRune(r):
+			start := i
+			for i < len(runes) && isSQLWordRune(runes[i]) {
+				i++
+			}
+			word := strings.ToLower(string(runes[start:i]))
+			tokens = append(tokens, sqlToken{text: word, ident: !unicode.IsDigit(runes[start])})
+		default:
+			tokens = append(tokens, sqlToken{text: string(r)})
+			i++
+		}
+	}
+	return tokens
+}
+
+func isSQLWordRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,31 @@
+package database
+
+import "testing"
+
+func TestNormalizeSQLBody(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"whitespace and case", "SELECT id\n  FROM t;", "select id from t", true},
+		{"comments", "SELECT id -- the key\nFROM /* main */ t", "SELECT id FROM t", true},
+		{"identifier quoting", "SELECT `id` FROM `t`", `SELECT "id" FROM [t]`, true},
+		{"schema after FROM", "SELECT id FROM shop.t", "SELECT id FROM t", true},
+		{"schema after JOIN", "SELECT a.id FROM a JOIN shop.b ON a.id = b.id", "SELECT a.id FROM a JOIN b ON a.id = b.id", true},
+		{"three-part column", "SELECT shop.t.id FROM t", "SELECT t.id FROM t", true},
+		{"trailing semicolons", "SELECT 1;;", "SELECT 1", true},
+		{"string literal case kept", "SELECT 'Abc'", "SELECT 'abc'", false},
+		{"string literal spacing kept", "SELECT 'a  b'", "SELECT 'a b'", false},
+		{"column qualifier kept", "SELECT t.id FROM t", "SELECT id FROM t", false},
+		{"different body", "SELECT id FROM t", "SELECT name FROM t", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			na, nb := normalizeSQLBody(tt.a), normalizeSQLBody(tt.b)
+			if (na == nb) != tt.same {
+				t.Errorf("normalized %q and %q, same = %v, want %v", na, nb, na == nb, tt.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// getMySQLRowFormat returns the table's row format, e.g. DYNAMIC or COMPRESSED
+func getMySQLRowFormat(db *sql.DB, tableName string) (string, error) {
+	var format sql.NullString
+	err := db.QueryRow(`
+		SELECT ROW_FORMAT
+		FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?`, tableName).Scan(&format)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return strings.ToUpper(format.String), err
+}
+
+// getSQLServerCompression returns the data compression of the table's heap or
+// clustered index (NONE, ROW, PAGE, ...), taken from its first partition
+func getSQLServerCompression(db *sql.DB, tableName string) (string, error) {
+	var compression string
+	err := db.QueryRow(`
+		SELECT TOP 1 data_compression_desc
+		FROM sys.partitions
+		WHERE object_id = OBJECT_ID(@p1) AND index_id IN (0, 1)
+		ORDER BY partition_number`, tableName).Scan(&compression)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return compression, err
+}
+
+// compareStorageOptions diffs MySQL row formats and SQL Server data compression.
+// Options only one side reports, as across dialects, aren't compared.
+func compareStorageOptions(tableName string, source, target TableInfo, q func(string) string) []DiffResult {
+	var results []DiffResult
+	if source.RowFormat != "" && target.RowFormat != "" && !strings.EqualFold(source.RowFormat, target.RowFormat) {
+		results = append(results, DiffResult{
+			Type:      "modified",
+			TableName: tableName,
+			Detail:    fmt.Sprintf("Change row format: %s -> %s", target.RowFormat, source.RowFormat),
+			SQL:       fmt.Sprintf("ALTER TABLE %s ROW_FORMAT=%s;", q(tableName), strings.ToUpper(source.RowFormat)),
+		})
+	}
+	if source.Compression != "" && target.Compression != "" && !strings.EqualFold(source.Compression, target.Compression) {
+		results = append(results, DiffResult{
+			Type:      "modified",
+			TableName: tableName,
+			Detail:    fmt.Sprintf("Change data compression: %s -> %s", target.Compression, source.Compression),
+			SQL:       fmt.Sprintf("ALTER TABLE %s REBUILD WITH (DATA_COMPRESSION = %s);", q(tableName), strings.ToUpper(source.Compression)),
+		})
+	}
+	return results
+}
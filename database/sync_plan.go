@@ -0,0 +1,191 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// TableSyncOptions are the stored sync settings of one table
+type TableSyncOptions struct {
+	Compare DataCompareOptions `json:"compare"`
+	Sync    SyncOptions        `json:"sync"`
+}
+
+// SyncPlan is a named set of per-table sync settings for a source/target pair,
+// so a data sync can be re-run without choosing the options again
+type SyncPlan struct {
+	Name   string                      `json:"name"`
+	Pair   string                      `json:"pair"` // see syncPairKey
+	Tables map[string]TableSyncOptions `json:"tables"`
+}
+
+// OptionsFor returns the stored settings of a table, and false for tables not in the plan
+func (p *SyncPlan) OptionsFor(tableName string) (TableSyncOptions, bool) {
+	opts, ok := p.Tables[tableName]
+	return opts, ok
+}
+
+// syncPairKey identifies a source/target pair by server and database, without
+// credentials, so a plan still matches after a password change
+func syncPairKey(source, target ConnectionConfig) string {
+	return databaseKey(source) + " -> " + databaseKey(target)
+}
+
+// databaseKey identifies the database a config points at
+func databaseKey(config ConnectionConfig) string {
+	dbType := config.Type
+	if dbType == "" {
+		dbType = MySQL
+	}
+	if dbType == SQLite {
+		return fmt.Sprintf("%s:%s", dbType, config.FilePath)
+	}
+	port := config.Port
+	if port == 0 {
+		port = defaultPort(dbType)
+	}
+	return fmt.Sprintf("%s://%s:%d/%s", dbType, config.Host, port, config.Database)
+}
+
+// SyncPlanStore manages saved sync plans
+type SyncPlanStore struct {
+	Plans    []SyncPlan `json:"plans"`
+	filePath string
+	mu       sync.RWMutex
+}
+
+// NewSyncPlanStore opens the sync plans saved in the .syncforge directory
+func NewSyncPlanStore() (*SyncPlanStore, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	configDir := filepath.Join(homeDir, ".syncforge")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return nil, err
+	}
+	return openSyncPlanStore(filepath.Join(configDir, "sync_plans.json"))
+}
+
+func openSyncPlanStore(filePath string) (*SyncPlanStore, error) {
+	store := &SyncPlanStore{filePath: filePath, Plans: []SyncPlan{}}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("failed to read sync plans: %v", err)
+	}
+	return store, nil
+}
+
+// save writes the plans to file
+func (s *SyncPlanStore) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.filePath, data, 0600)
+}
+
+// Save adds or replaces the plan with the same name for the source/target pair
+func (s *SyncPlanStore) Save(source, target ConnectionConfig, plan SyncPlan) error {
+	if plan.Name == "" {
+		return fmt.Errorf("sync plan name is required")
+	}
+	for table, opts := range plan.Tables {
+		if err := ValidateWhereClause(opts.Compare.WhereClause); err != nil {
+			return fmt.Errorf("table %s: %v", table, err)
+		}
+	}
+	plan.Pair = syncPairKey(source, target)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, p := range s.Plans {
+		if p.Pair == plan.Pair && p.Name == plan.Name {
+			s.Plans[i] = plan
+			return s.save()
+		}
+	}
+	s.Plans = append(s.Plans, plan)
+	return s.save()
+}
+
+// Get returns the named plan of the source/target pair, or nil if there is none
+func (s *SyncPlanStore) Get(source, target ConnectionConfig, name string) *SyncPlan {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pair := syncPairKey(source, target)
+	for _, p := range s.Plans {
+		if p.Pair == pair && p.Name == name {
+			plan := p
+			return &plan
+		}
+	}
+	return nil
+}
+
+// List returns the plans saved for the source/target pair
+func (s *SyncPlanStore) List(source, target ConnectionConfig) []SyncPlan {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pair := syncPairKey(source, target)
+	var plans []SyncPlan
+	for _, p := range s.Plans {
+		if p.Pair == pair {
+			plans = append(plans, p)
+		}
+	}
+	return plans
+}
+
+// Delete removes the named plan of the source/target pair
+func (s *SyncPlanStore) Delete(source, target ConnectionConfig, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pair := syncPairKey(source, target)
+	for i, p := range s.Plans {
+		if p.Pair == pair && p.Name == name {
+			s.Plans = append(s.Plans[:i], s.Plans[i+1:]...)
+			return s.save()
+		}
+	}
+	return nil
+}
+
+// CompareSyncPlan compares every table of the plan with its stored options,
+// returning the diffs by table
+func CompareSyncPlan(ctx context.Context, source, target ConnectionConfig, plan *SyncPlan) (map[string][]DataDiffResult, error) {
+	results := make(map[string][]DataDiffResult, len(plan.Tables))
+	for _, table := range sortedTableNames(plan.Tables) {
+		diffs, err := CompareTableDataContext(ctx, source, target, table, plan.Tables[table].Compare)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare %s: %v", table, err)
+		}
+		results[table] = diffs
+	}
+	return results, nil
+}
+
+func sortedTableNames(tables map[string]TableSyncOptions) []string {
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,6 +1,9 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 )
@@ -8,6 +11,8 @@ import (
 // TableRowData holds a row of table data
 type TableRowData struct {
 	Values map[string]interface{} `json:"values"`
+	// Matches lists the columns containing the search term, for highlighting
+	Matches []string `json:"matches,omitempty"`
 }
 
 // TableDataResult holds paginated table data
@@ -17,11 +22,86 @@ type TableDataResult struct {
 	TotalCount int            `json:"totalCount"`
 	Page       int            `json:"page"`
 	PageSize   int            `json:"pageSize"`
+	// NextCursor holds the primary key of the last row for keyset pagination,
+	// nil when there are no more rows
+	NextCursor map[string]interface{} `json:"nextCursor,omitempty"`
 }
 
-// GetTableData retrieves paginated table data
+// GetTableData retrieves paginated table data in primary key order
 func GetTableData(config ConnectionConfig, tableName string, page, pageSize int) (*TableDataResult, error) {
-	db, err := Connect(config)
+	return GetTableDataContext(context.Background(), config, tableName, page, pageSize)
+}
+
+// GetTableDataContext retrieves paginated data from a table, aborting when ctx is done
+func GetTableDataContext(ctx context.Context, config ConnectionConfig, tableName string, page, pageSize int) (*TableDataResult, error) {
+	return getTablePage(ctx, config, tableName, pageQuery{}, page, pageSize, 0)
+}
+
+// GetTableDataSorted retrieves paginated table data sorted by a column, which must
+// be one of the table's columns. An empty orderBy sorts by the primary key.
+func GetTableDataSorted(config ConnectionConfig, tableName string, page, pageSize int, orderBy string, orderDesc bool) (*TableDataResult, error) {
+	return GetTableDataSortedContext(context.Background(), config, tableName, page, pageSize, orderBy, orderDesc)
+}
+
+// GetTableDataSortedContext is GetTableDataSorted, aborting when ctx is done
+func GetTableDataSortedContext(ctx context.Context, config ConnectionConfig, tableName string, page, pageSize int, orderBy string, orderDesc bool) (*TableDataResult, error) {
+	return GetTableDataQueryContext(ctx, config, tableName, page, pageSize, TableQuery{OrderBy: orderBy, OrderDesc: orderDesc})
+}
+
+// GetTableDataQuery retrieves paginated table data sorted and searched as q asks.
+// TotalCount counts only the rows matching the search.
+func GetTableDataQuery(config ConnectionConfig, tableName string, page, pageSize int, q TableQuery) (*TableDataResult, error) {
+	return GetTableDataQueryContext(context.Background(), config, tableName, page, pageSize, q)
+}
+
+// GetTableDataQueryContext is GetTableDataQuery, aborting when ctx is done
+func GetTableDataQueryContext(ctx context.Context, config ConnectionConfig, tableName string, page, pageSize int, q TableQuery) (*TableDataResult, error) {
+	return getTablePage(ctx, config, tableName, pageQuery{TableQuery: q}, page, pageSize, 0)
+}
+
+// GetTableDataFiltered retrieves paginated rows matching a SQL condition, which is
+// inserted verbatim after WHERE; see ValidateWhereClause
+func GetTableDataFiltered(config ConnectionConfig, tableName, where string, page, pageSize int) (*TableDataResult, error) {
+	return GetTableDataFilteredContext(context.Background(), config, tableName, where, page, pageSize)
+}
+
+// GetTableDataFilteredContext is GetTableDataFiltered, aborting when ctx is done
+func GetTableDataFilteredContext(ctx context.Context, config ConnectionConfig, tableName, where string, page, pageSize int) (*TableDataResult, error) {
+	if err := ValidateWhereClause(where); err != nil {
+		return nil, err
+	}
+	return getTablePage(ctx, config, tableName, pageQuery{where: where}, page, pageSize, 0)
+}
+
+// GetTableDataAdaptive retrieves paginated table data with the page size lowered so a
+// page of average rows stays within byteBudget bytes of JSON. Wide tables get fewer
+// rows per page; the effective size is returned in PageSize and stays the same for
+// every page of the table, so page numbers remain consistent.
+func GetTableDataAdaptive(config ConnectionConfig, tableName string, page, maxPageSize, byteBudget int) (*TableDataResult, error) {
+	return GetTableDataAdaptiveContext(context.Background(), config, tableName, page, maxPageSize, byteBudget)
+}
+
+// GetTableDataAdaptiveContext is GetTableDataAdaptive, aborting when ctx is done
+func GetTableDataAdaptiveContext(ctx context.Context, config ConnectionConfig, tableName string, page, maxPageSize, byteBudget int) (*TableDataResult, error) {
+	if byteBudget <= 0 {
+		return nil, fmt.Errorf("byte budget must be positive")
+	}
+	return getTablePage(ctx, config, tableName, pageQuery{}, page, maxPageSize, byteBudget)
+}
+
+// adaptiveSampleRows is how many leading rows are measured to estimate the row width
+const adaptiveSampleRows = 50
+
+// pageQuery narrows and sorts the rows of a table page
+type pageQuery struct {
+	TableQuery
+	where string // SQL condition, checked by ValidateWhereClause
+}
+
+// getTablePage reads one offset page of the rows matching q, adapting pageSize
+// to byteBudget when it is set
+func getTablePage(ctx context.Context, config ConnectionConfig, tableName string, q pageQuery, page, pageSize, byteBudget int) (*TableDataResult, error) {
+	db, err := ConnectContext(ctx, config)
 	if err != nil {
 		return nil, err
 	}
@@ -32,50 +112,288 @@ func GetTableData(config ConnectionConfig, tableName string, page, pageSize int)
 		dbType = MySQL
 	}
 
+	// Get columns
+	columns, err := getColumns(db, dbType, config.Database, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	where := q.where
+	var args []interface{}
+	var searched []string
+	if q.SearchTerm != "" {
+		var types map[string]string
+		searched, types, err = searchColumns(db, dbType, config.Database, tableName, columns, q.TableQuery)
+		if err != nil {
+			return nil, err
+		}
+		var cond string
+		cond, args = searchCondition(dbType, searched, types, q.SearchTerm)
+		where = andWhere(where, cond)
+	}
+
 	// Get total count
 	var totalCount int
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s", quoteIdentifier(dbType, tableName))
-	err = db.QueryRow(countQuery).Scan(&totalCount)
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s%s", quoteIdentifier(dbType, tableName), whereSQL(where))
+	err = db.QueryRow(countQuery, args...).Scan(&totalCount)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get columns
-	columns, err := getColumns(db, dbType, config.Database, tableName)
+	orderBy, err := pageOrder(db, dbType, config.Database, tableName, columns, q)
 	if err != nil {
 		return nil, err
 	}
 
+	// Sample from the start of the table so every page gets the same size
+	if byteBudget > 0 {
+		sample, err := queryTablePage(db, dbType, tableName, columns, where, args, orderBy, 0, min(adaptiveSampleRows, pageSize))
+		if err != nil {
+			return nil, err
+		}
+		pageSize = adaptPageSize(sample, pageSize, byteBudget)
+	}
+
 	// Calculate offset
 	offset := (page - 1) * pageSize
 	if offset < 0 {
 		offset = 0
 	}
 
+	resultRows, err := queryTablePage(db, dbType, tableName, columns, where, args, orderBy, offset, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	if q.SearchTerm != "" {
+		markMatches(resultRows, searched, q.SearchTerm)
+	}
+
+	return &TableDataResult{
+		Columns:    columns,
+		Rows:       resultRows,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+	}, nil
+}
+
+// adaptPageSize lowers pageSize so a page of rows as wide as the sample average fits in byteBudget
+func adaptPageSize(sample []TableRowData, pageSize, byteBudget int) int {
+	if len(sample) == 0 {
+		return pageSize
+	}
+	total := 0
+	for _, row := range sample {
+		encoded, err := json.Marshal(row)
+		if err != nil {
+			return pageSize
+		}
+		total += len(encoded)
+	}
+	avg := max(total/len(sample), 1)
+	return max(min(byteBudget/avg, pageSize), 1)
+}
+
+// queryTablePage reads limit rows matching where, bound with args, starting at offset,
+// sorted by the quoted orderBy columns
+func queryTablePage(db *sql.DB, dbType DBType, tableName string, columns []string, where string, args []interface{}, orderBy []string, offset, limit int) ([]TableRowData, error) {
 	// Build query with database-specific pagination
 	quotedCols := make([]string, len(columns))
 	for i, col := range columns {
 		quotedCols[i] = quoteIdentifier(dbType, col)
 	}
 
+	orderClause := ""
+	if len(orderBy) > 0 {
+		orderClause = " ORDER BY " + strings.Join(orderBy, ", ")
+	}
+
 	var query string
 	switch dbType {
 	case SQLServer:
-		// SQL Server uses OFFSET FETCH
-		query = fmt.Sprintf("SELECT %s FROM %s ORDER BY (SELECT NULL) OFFSET %d ROWS FETCH NEXT %d ROWS ONLY",
-			strings.Join(quotedCols, ", "), quoteIdentifier(dbType, tableName), offset, pageSize)
+		// SQL Server uses OFFSET FETCH, which needs an ORDER BY
+		if orderClause == "" {
+			orderClause = " ORDER BY (SELECT NULL)"
+		}
+		query = fmt.Sprintf("SELECT %s FROM %s%s%s OFFSET %d ROWS FETCH NEXT %d ROWS ONLY",
+			strings.Join(quotedCols, ", "), quoteIdentifier(dbType, tableName), whereSQL(where), orderClause, offset, limit)
 	default:
 		// MySQL, PostgreSQL, SQLite use LIMIT OFFSET
-		query = fmt.Sprintf("SELECT %s FROM %s LIMIT %d OFFSET %d",
-			strings.Join(quotedCols, ", "), quoteIdentifier(dbType, tableName), pageSize, offset)
+		query = fmt.Sprintf("SELECT %s FROM %s%s%s LIMIT %d OFFSET %d",
+			strings.Join(quotedCols, ", "), quoteIdentifier(dbType, tableName), whereSQL(where), orderClause, limit, offset)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTableRows(rows, columns)
+}
+
+// pageOrder returns the quoted ORDER BY terms of a page: the requested column, then
+// the primary key so rows with equal values keep a stable order across pages.
+// Without a primary key or requested column the rows are left unsorted.
+func pageOrder(db *sql.DB, dbType DBType, database, tableName string, columns []string, q pageQuery) ([]string, error) {
+	var keys []string
+	if q.OrderBy != "" {
+		// Only names of actual columns reach the query
+		column, ok := resolveColumn(columns, q.OrderBy)
+		if !ok {
+			return nil, fmt.Errorf("cannot sort by %s: no such column in table %s", q.OrderBy, tableName)
+		}
+		keys = append(keys, column)
+	}
+
+	primaryKeys, err := getPrimaryKeys(db, dbType, database, tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, pk := range primaryKeys {
+		if !containsString(keys, pk) {
+			keys = append(keys, pk)
+		}
+	}
+
+	direction := ""
+	if q.OrderDesc {
+		direction = " DESC"
+	}
+	terms := make([]string, len(keys))
+	for i, key := range keys {
+		terms[i] = quoteIdentifier(dbType, key) + direction
+	}
+	return terms, nil
+}
+
+// resolveColumn finds name among columns, exactly or else ignoring case
+func resolveColumn(columns []string, name string) (string, bool) {
+	column := ""
+	for _, col := range columns {
+		if col == name {
+			return col, true
+		}
+		if column == "" && strings.EqualFold(col, name) {
+			column = col
+		}
+	}
+	return column, column != ""
+}
+
+// GetTableDataKeyset retrieves the page of rows that follows the given primary-key cursor.
+// It seeks by primary key instead of skipping rows, so deep pages cost the same as the first.
+// Pass a nil cursor for the first page, then the returned NextCursor for each following page.
+func GetTableDataKeyset(config ConnectionConfig, tableName string, after map[string]interface{}, pageSize int) (*TableDataResult, error) {
+	return GetTableDataKeysetContext(context.Background(), config, tableName, after, pageSize)
+}
+
+// GetTableDataKeysetContext is GetTableDataKeyset, aborting when ctx is done
+func GetTableDataKeysetContext(ctx context.Context, config ConnectionConfig, tableName string, after map[string]interface{}, pageSize int) (*TableDataResult, error) {
+	db, err := ConnectContext(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	dbType := config.Type
+	if dbType == "" {
+		dbType = MySQL
+	}
+
+	primaryKeys, err := getPrimaryKeys(db, dbType, config.Database, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(primaryKeys) == 0 {
+		return nil, fmt.Errorf("table %s has no primary key, keyset pagination is not possible", tableName)
+	}
+
+	var totalCount int
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s", quoteIdentifier(dbType, tableName))
+	if err := db.QueryRow(countQuery).Scan(&totalCount); err != nil {
+		return nil, err
+	}
+
+	columns, err := getColumns(db, dbType, config.Database, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	quotedCols := make([]string, len(columns))
+	for i, col := range columns {
+		quotedCols[i] = quoteIdentifier(dbType, col)
+	}
+	orderParts := make([]string, len(primaryKeys))
+	for i, pk := range primaryKeys {
+		orderParts[i] = quoteIdentifier(dbType, pk)
+	}
+
+	whereClause, args := buildKeysetWhere(dbType, primaryKeys, after)
+
+	var query string
+	switch dbType {
+	case SQLServer:
+		query = fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s OFFSET 0 ROWS FETCH NEXT %d ROWS ONLY",
+			strings.Join(quotedCols, ", "), quoteIdentifier(dbType, tableName), whereClause, strings.Join(orderParts, ", "), pageSize)
+	default:
+		query = fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s LIMIT %d",
+			strings.Join(quotedCols, ", "), quoteIdentifier(dbType, tableName), whereClause, strings.Join(orderParts, ", "), pageSize)
 	}
 
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	resultRows, err := scanTableRows(rows, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TableDataResult{
+		Columns:    columns,
+		Rows:       resultRows,
+		TotalCount: totalCount,
+		PageSize:   pageSize,
+	}
+	if len(resultRows) == pageSize && pageSize > 0 {
+		result.NextCursor = extractPrimaryKey(resultRows[len(resultRows)-1].Values, primaryKeys)
+	}
+	return result, nil
+}
+
+// buildKeysetWhere builds a WHERE clause selecting rows strictly after the cursor
+// in primary-key order
+func buildKeysetWhere(dbType DBType, primaryKeys []string, after map[string]interface{}) (string, []interface{}) {
+	if len(after) == 0 {
+		return "", nil
+	}
+	cond, args := keysetAfter(dbType, primaryKeys, after, nil)
+	return " WHERE " + cond, args
+}
+
+// keysetAfter builds a condition matching rows strictly after the cursor in primary-key
+// order, numbering its placeholders after args. Composite keys expand to
+// (a > ?) OR (a = ? AND b > ?) ... since SQL Server has no row-value comparison.
+func keysetAfter(dbType DBType, primaryKeys []string, after map[string]interface{}, args []interface{}) (string, []interface{}) {
+	var ors []string
+	for i := range primaryKeys {
+		var ands []string
+		for j := 0; j < i; j++ {
+			args = append(args, after[primaryKeys[j]])
+			ands = append(ands, fmt.Sprintf("%s = %s", quoteIdentifier(dbType, primaryKeys[j]), placeholder(dbType, len(args))))
+		}
+		args = append(args, after[primaryKeys[i]])
+		ands = append(ands, fmt.Sprintf("%s > %s", quoteIdentifier(dbType, primaryKeys[i]), placeholder(dbType, len(args))))
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+// scanTableRows reads all rows into TableRowData, converting []byte values to strings
+func scanTableRows(rows *sql.Rows, columns []string) ([]TableRowData, error) {
 	var resultRows []TableRowData
 	for rows.Next() {
 		values := make([]interface{}, len(columns))
@@ -99,19 +417,17 @@ func GetTableData(config ConnectionConfig, tableName string, page, pageSize int)
 		}
 		resultRows = append(resultRows, rowData)
 	}
-
-	return &TableDataResult{
-		Columns:    columns,
-		Rows:       resultRows,
-		TotalCount: totalCount,
-		Page:       page,
-		PageSize:   pageSize,
-	}, nil
+	return resultRows, rows.Err()
 }
 
 // GetTableStructure retrieves detailed table structure
 func GetTableStructure(config ConnectionConfig, tableName string) (*TableInfo, error) {
-	db, err := Connect(config)
+	return GetTableStructureContext(context.Background(), config, tableName)
+}
+
+// GetTableStructureContext retrieves detailed table structure, aborting when ctx is done
+func GetTableStructureContext(ctx context.Context, config ConnectionConfig, tableName string) (*TableInfo, error) {
+	db, err := ConnectContext(ctx, config)
 	if err != nil {
 		return nil, err
 	}
@@ -135,3 +451,169 @@ func GetTableStructure(config ConnectionConfig, tableName string) (*TableInfo, e
 func GetAllTables(config ConnectionConfig) ([]TableDataInfo, error) {
 	return GetTablesForSync(config)
 }
+
+// UpdateTableRow sets the given column values on the row identified by its primary
+// key values and returns the number of rows changed, which is always 1: anything
+// else is rolled back
+func UpdateTableRow(config ConnectionConfig, tableName string, primaryKey, values map[string]interface{}) (int64, error) {
+	return UpdateTableRowContext(context.Background(), config, tableName, primaryKey, values)
+}
+
+// UpdateTableRowContext is UpdateTableRow, aborting when ctx is done
+func UpdateTableRowContext(ctx context.Context, config ConnectionConfig, tableName string, primaryKey, values map[string]interface{}) (int64, error) {
+	if len(values) == 0 {
+		return 0, fmt.Errorf("no column values to update")
+	}
+	return editTableRow(ctx, config, tableName, true, func(dbType DBType, columns, primaryKeys []string) (string, []interface{}, error) {
+		cols, err := editColumns(columns, values, tableName)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := checkKeyValues(primaryKeys, primaryKey, tableName); err != nil {
+			return "", nil, err
+		}
+
+		sqlVals := &sqlValues{dbType: dbType, bind: true}
+		sets := make([]string, len(cols))
+		for i, col := range cols {
+			sets[i] = fmt.Sprintf("%s = %s", quoteIdentifier(dbType, col), sqlVals.render(values[col]))
+		}
+		wheres := make([]string, len(primaryKeys))
+		for i, pk := range primaryKeys {
+			wheres[i] = fmt.Sprintf("%s = %s", quoteIdentifier(dbType, pk), sqlVals.render(primaryKey[pk]))
+		}
+		query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", quoteIdentifier(dbType, tableName),
+			strings.Join(sets, ", "), strings.Join(wheres, " AND "))
+		return query, sqlVals.args, nil
+	})
+}
+
+// InsertTableRow inserts a row with the given column values; columns left out get
+// their defaults. It returns the number of rows inserted.
+func InsertTableRow(config ConnectionConfig, tableName string, values map[string]interface{}) (int64, error) {
+	return InsertTableRowContext(context.Background(), config, tableName, values)
+}
+
+// InsertTableRowContext is InsertTableRow, aborting when ctx is done
+func InsertTableRowContext(ctx context.Context, config ConnectionConfig, tableName string, values map[string]interface{}) (int64, error) {
+	if len(values) == 0 {
+		return 0, fmt.Errorf("no column values to insert")
+	}
+	return editTableRow(ctx, config, tableName, false, func(dbType DBType, columns, primaryKeys []string) (string, []interface{}, error) {
+		cols, err := editColumns(columns, values, tableName)
+		if err != nil {
+			return "", nil, err
+		}
+		row := make(map[string]interface{}, len(cols))
+		for _, col := range cols {
+			row[col] = values[col]
+		}
+		query, args := insertStatement(dbType, tableName, row, cols)
+		return query, args, nil
+	})
+}
+
+// DeleteTableRow deletes the row identified by its primary key values and returns
+// the number of rows deleted, which is always 1: anything else is rolled back
+func DeleteTableRow(config ConnectionConfig, tableName string, primaryKey map[string]interface{}) (int64, error) {
+	return DeleteTableRowContext(context.Background(), config, tableName, primaryKey)
+}
+
+// DeleteTableRowContext is DeleteTableRow, aborting when ctx is done
+func DeleteTableRowContext(ctx context.Context, config ConnectionConfig, tableName string, primaryKey map[string]interface{}) (int64, error) {
+	return editTableRow(ctx, config, tableName, true, func(dbType DBType, columns, primaryKeys []string) (string, []interface{}, error) {
+		if err := checkKeyValues(primaryKeys, primaryKey, tableName); err != nil {
+			return "", nil, err
+		}
+		query, args := deleteStatement(dbType, tableName, primaryKeys, primaryKey)
+		return query, args, nil
+	})
+}
+
+// rowStatement builds the statement of a row edit from the table's columns and primary key
+type rowStatement func(dbType DBType, columns, primaryKeys []string) (string, []interface{}, error)
+
+// editTableRow runs the statement built by build in a transaction. byKey edits
+// target one row by primary key, so the table must have one and exactly one row
+// must be affected, or the change is rolled back.
+func editTableRow(ctx context.Context, config ConnectionConfig, tableName string, byKey bool, build rowStatement) (int64, error) {
+	db, err := ConnectContext(ctx, config)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	dbType := config.Type
+	if dbType == "" {
+		dbType = MySQL
+	}
+
+	columns, err := getColumns(db, dbType, config.Database, tableName)
+	if err != nil {
+		return 0, err
+	}
+	primaryKeys, err := getPrimaryKeys(db, dbType, config.Database, tableName)
+	if err != nil {
+		return 0, err
+	}
+	if byKey && len(primaryKeys) == 0 {
+		return 0, fmt.Errorf("table %s has no primary key, rows cannot be edited", tableName)
+	}
+
+	query, args, err := build(dbType, columns, primaryKeys)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if byKey && affected != 1 {
+		tx.Rollback()
+		return 0, fmt.Errorf("expected to change 1 row of %s, %d matched; rolled back", tableName, affected)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit: %v", err)
+	}
+	return affected, nil
+}
+
+// editColumns resolves the column names of edited values against the table's
+// columns, so only actual columns reach the query, in a stable order
+func editColumns(columns []string, values map[string]interface{}, tableName string) ([]string, error) {
+	var cols []string
+	for _, col := range columns {
+		if _, ok := values[col]; ok {
+			cols = append(cols, col)
+		}
+	}
+	if len(cols) != len(values) {
+		for name := range values {
+			if !containsString(columns, name) {
+				return nil, fmt.Errorf("no such column in table %s: %s", tableName, name)
+			}
+		}
+	}
+	return cols, nil
+}
+
+// checkKeyValues checks that primaryKey has a non-NULL value for every key column
+func checkKeyValues(primaryKeys []string, primaryKey map[string]interface{}, tableName string) error {
+	for _, pk := range primaryKeys {
+		if primaryKey[pk] == nil {
+			return fmt.Errorf("missing value for primary key column %s of table %s", pk, tableName)
+		}
+	}
+	return nil
+}
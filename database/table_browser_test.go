@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeysetAfter(t *testing.T) {
+	after := map[string]interface{}{"a": 1, "b": "x", "c": 2.5}
+	tests := []struct {
+		name     string
+		dbType   DBType
+		keys     []string
+		args     []interface{}
+		want     string
+		wantArgs []interface{}
+	}{
+		{"single key", MySQL, []string{"a"}, nil, "((`a` > ?))", []interface{}{1}},
+		{
+			"composite key", MySQL, []string{"a", "b"}, nil,
+			"((`a` > ?) OR (`a` = ? AND `b` > ?))",
+			[]interface{}{1, 1, "x"},
+		},
+		{
+			"postgres numbering continues after args", PostgreSQL, []string{"a", "b"}, []interface{}{"filter"},
+			`(("a" > $2) OR ("a" = $3 AND "b" > $4))`,
+			[]interface{}{"filter", 1, 1, "x"},
+		},
+		{
+			"sql server three keys", SQLServer, []string{"a", "b", "c"}, nil,
+			"(([a] > @p1) OR ([a] = @p2 AND [b] > @p3) OR ([a] = @p4 AND [b] = @p5 AND [c] > @p6))",
+			[]interface{}{1, 1, "x", 1, "x", 2.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args := keysetAfter(tt.dbType, tt.keys, after, tt.args)
+			if got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestGetTableDataKeysetPagesCompositeKey(t *testing.T) {
+	config := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(t.TempDir(), "keyset.db")}
+	db, err := sql.Open("sqlite3", config.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("CREATE TABLE line (order_id INT, line_no INT, sku TEXT, PRIMARY KEY (order_id, line_no))"); err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for order := 1; order <= 3; order++ {
+		for line := 1; line <= 3; line++ {
+			if _, err := db.Exec("INSERT INTO line VALUES (?, ?, ?)", order, line, "sku"); err != nil {
+				t.Fatal(err)
+			}
+			want = append(want, fmt.Sprintf("%d/%d", order, line))
+		}
+	}
+	db.Close()
+
+	var got []string
+	var after map[string]interface{}
+	for pages := 0; pages < 10; pages++ {
+		result, err := GetTableDataKeyset(config, "line", after, 4)
+		if err != nil {
+			t.Fatalf("GetTableDataKeyset: %v", err)
+		}
+		for _, row := range result.Rows {
+			got = append(got, fmt.Sprintf("%v/%v", row.Values["order_id"], row.Values["line_no"]))
+		}
+		if result.NextCursor == nil {
+			break
+		}
+		after = result.NextCursor
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paged rows %v, want %v", got, want)
+	}
+}
+
+func TestTableRowEdits(t *testing.T) {
+	config := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(t.TempDir(), "edit.db")}
+	db, err := sql.Open("sqlite3", config.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE TABLE item (id INTEGER PRIMARY KEY, name TEXT); INSERT INTO item VALUES (1, 'a'), (2, 'b'); CREATE TABLE log (msg TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	names := func() string {
+		var got []string
+		rows, err := db.Query("SELECT id || '=' || name FROM item ORDER BY id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var row string
+			if err := rows.Scan(&row); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, row)
+		}
+		return strings.Join(got, ",")
+	}
+
+	if n, err := UpdateTableRow(config, "item", map[string]interface{}{"id": 1}, map[string]interface{}{"name": "renamed"}); err != nil || n != 1 {
+		t.Fatalf("update = %d, %v", n, err)
+	}
+	if n, err := InsertTableRow(config, "item", map[string]interface{}{"id": 3, "name": "c"}); err != nil || n != 1 {
+		t.Fatalf("insert = %d, %v", n, err)
+	}
+	if n, err := DeleteTableRow(config, "item", map[string]interface{}{"id": 2}); err != nil || n != 1 {
+		t.Fatalf("delete = %d, %v", n, err)
+	}
+	if got := names(); got != "1=renamed,3=c" {
+		t.Fatalf("table holds %s after edits", got)
+	}
+
+	if _, err := UpdateTableRow(config, "item", map[string]interface{}{"id": 9}, map[string]interface{}{"name": "x"}); err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Errorf("update of a missing row = %v, want a rollback", err)
+	}
+	if _, err := UpdateTableRow(config, "item", map[string]interface{}{"id": 1}, map[string]interface{}{"bogus": 1}); err == nil {
+		t.Error("update of an unknown column succeeded")
+	}
+	if _, err := DeleteTableRow(config, "item", map[string]interface{}{"id": 9}); err == nil {
+		t.Error("delete of a missing row succeeded")
+	}
+	if _, err := DeleteTableRow(config, "log", map[string]interface{}{"msg": "x"}); err == nil || !strings.Contains(err.Error(), "no primary key") {
+		t.Errorf("delete from a table without a key = %v", err)
+	}
+
+	// A by-key edit that matches more than one row is undone
+	_, err = editTableRow(context.Background(), config, "item", true, func(dbType DBType, columns, primaryKeys []string) (string, []interface{}, error) {
+		return `UPDATE "item" SET "name" = 'all'`, nil, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "2 matched; rolled back") {
+		t.Errorf("edit of two rows = %v, want a rollback", err)
+	}
+	if got := names(); got != "1=renamed,3=c" {
+		t.Errorf("table holds %s after the rolled back edit", got)
+	}
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+)
+
+// TableFilter narrows a schema to the tables of interest. A table is kept when it
+// matches an Include pattern (or Include is empty) and matches no Exclude pattern.
+type TableFilter struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	// Regex treats the patterns as regular expressions instead of globs like tmp_*
+	Regex bool `json:"regex,omitempty"`
+}
+
+// Validate reports the first pattern that isn't a valid glob or regular expression
+func (f *TableFilter) Validate() error {
+	if f == nil {
+		return nil
+	}
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		var err error
+		if f.Regex {
+			_, err = regexp.Compile(pattern)
+		} else {
+			_, err = path.Match(pattern, "")
+		}
+		if err != nil {
+			return fmt.Errorf("invalid table filter pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the table is kept. A nil filter keeps every table.
+func (f *TableFilter) Matches(tableName string) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Include) > 0 && !f.matchAny(f.Include, tableName) {
+		return false
+	}
+	return !f.matchAny(f.Exclude, tableName)
+}
+
+// Apply returns the matching table names, keeping order
+func (f *TableFilter) Apply(tableNames []string) ([]string, error) {
+	if f == nil {
+		return tableNames, nil
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	var kept []string
+	for _, name := range tableNames {
+		if f.Matches(name) {
+			kept = append(kept, name)
+		}
+	}
+	return kept, nil
+}
+
+// matchAny reports whether name matches one of the patterns; invalid patterns match nothing
+func (f *TableFilter) matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if f.Regex {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(name) {
+				return true
+			}
+		} else if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filterSchema returns a copy of schema holding only the matching tables
+func (f *TableFilter) filterSchema(schema *SchemaInfo) *SchemaInfo {
+	if f == nil {
+		return schema
+	}
+	filtered := *schema
+	filtered.Tables = make(map[string]TableInfo, len(schema.Tables))
+	for name, table := range schema.Tables {
+		if f.Matches(name) {
+			filtered.Tables[name] = table
+		}
+	}
+	return &filtered
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TableQuery sorts and searches the rows of a browsed table
+type TableQuery struct {
+	OrderBy   string `json:"orderBy,omitempty"` // column name; empty sorts by the primary key
+	OrderDesc bool   `json:"orderDesc,omitempty"`
+	// SearchTerm keeps rows where any search column contains it, ignoring case
+	// where the column's collation does
+	SearchTerm string `json:"searchTerm,omitempty"`
+	// SearchColumns limits the search; empty searches every text column
+	SearchColumns []string `json:"searchColumns,omitempty"`
+}
+
+var textTypePattern = regexp.MustCompile(`^(n?(var)?char|character|(tiny|medium|long)?n?text|citext|clob|string|enum|set|uuid|uniqueidentifier)\b`)
+
+// isTextType reports whether values of a data type can be matched with LIKE as they are.
+// SQLite columns may be declared without a type and hold text.
+func isTextType(dbType DBType, dataType string) bool {
+	t := strings.ToLower(strings.TrimSpace(dataType))
+	return textTypePattern.MatchString(t) || (dbType == SQLite && t == "")
+}
+
+// searchColumns resolves the columns a search runs over: the requested ones, which
+// must exist, or every text column of the table. It also returns the data types.
+func searchColumns(db *sql.DB, dbType DBType, database, tableName string, columns []string, q TableQuery) ([]string, map[string]string, error) {
+	types, err := getColumnDataTypes(db, dbType, database, tableName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var selected []string
+	if len(q.SearchColumns) > 0 {
+		for _, name := range q.SearchColumns {
+			column, ok := resolveColumn(columns, name)
+			if !ok {
+				return nil, nil, fmt.Errorf("cannot search %s: no such column in table %s", name, tableName)
+			}
+			if !containsString(selected, column) {
+				selected = append(selected, column)
+			}
+		}
+		return selected, types, nil
+	}
+
+	for _, col := range columns {
+		if isTextType(dbType, types[col]) {
+			selected = append(selected, col)
+		}
+	}
+	return selected, types, nil
+}
+
+// searchCondition builds "col1 LIKE ? OR col2 LIKE ?" over the given columns with one
+// bound pattern per column. Columns that aren't text are cast to text first.
+func searchCondition(dbType DBType, columns []string, types map[string]string, term string) (string, []interface{}) {
+	if len(columns) == 0 {
+		return "1 = 0", nil
+	}
+
+	pattern := "%" + escapeLike(dbType, term) + "%"
+	op := "LIKE"
+	if dbType == PostgreSQL {
+		op = "ILIKE"
+	}
+
+	terms := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, col := range columns {
+		expr := quoteIdentifier(dbType, col)
+		if dbType == PostgreSQL || !isTextType(dbType, types[col]) {
+			expr = castToText(dbType, expr)
+		}
+		terms[i] = fmt.Sprintf("%s %s %s ESCAPE '!'", expr, op, placeholder(dbType, i+1))
+		args[i] = pattern
+	}
+	return strings.Join(terms, " OR "), args
+}
+
+// castToText converts a column expression to text in the dialect's syntax
+func castToText(dbType DBType, expr string) string {
+	switch dbType {
+	case PostgreSQL, SQLite:
+		return fmt.Sprintf("CAST(%s AS TEXT)", expr)
+	case SQLServer:
+		return fmt.Sprintf("CAST(%s AS NVARCHAR(MAX))", expr)
+	default:
+		return fmt.Sprintf("CAST(%s AS CHAR)", expr)
+	}
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern with '!', so the term matches literally
+func escapeLike(dbType DBType, term string) string {
+	special := "!%_"
+	if dbType == SQLServer {
+		special += "["
+	}
+	var b strings.Builder
+	for _, r := range term {
+		if strings.ContainsRune(special, r) {
+			b.WriteRune('!')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// markMatches records on each row which of the searched columns contain the term
+func markMatches(rows []TableRowData, columns []string, term string) {
+	needle := strings.ToLower(term)
+	for i := range rows {
+		for _, col := range columns {
+			val := rows[i].Values[col]
+			if val != nil && strings.Contains(strings.ToLower(fmt.Sprintf("%v", val)), needle) {
+				rows[i].Matches = append(rows[i].Matches, col)
+			}
+		}
+	}
+}
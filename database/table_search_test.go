@@ -0,0 +1,76 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		dbType DBType
+		term   string
+		want   string
+	}{
+		{MySQL, "50%", "50!%"},
+		{MySQL, "a_b", "a!_b"},
+		{MySQL, "wow!", "wow!!"},
+		{MySQL, "[x]", "[x]"},
+		{SQLServer, "[x]", "![x]"},
+		{PostgreSQL, `back\slash`, `back\slash`},
+	}
+	for _, tt := range tests {
+		if got := escapeLike(tt.dbType, tt.term); got != tt.want {
+			t.Errorf("escapeLike(%s, %q) = %q, want %q", tt.dbType, tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestSearchTableMatchesLiterally(t *testing.T) {
+	config := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(t.TempDir(), "search.db")}
+	db, err := sql.Open("sqlite3", config.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TABLE note (id INTEGER PRIMARY KEY, title TEXT, body TEXT, score INTEGER);
+		INSERT INTO note VALUES
+			(1, '50% off', 'sale', 7),
+			(2, '500 items', 'stock', 50),
+			(3, 'a_b', 'under_score', 1),
+			(4, 'axb', 'wow!', 2),
+			(5, 'Plain', 'text', 3)`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	tests := []struct {
+		term    string
+		columns []string
+		want    []string
+	}{
+		{"50%", nil, []string{"1:title"}},
+		{"a_b", nil, []string{"3:title"}},
+		{"!", nil, []string{"4:body"}},
+		{"PLAIN", nil, []string{"5:title"}},
+		{"50", []string{"score"}, []string{"2:score"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			result, err := GetTableDataQuery(config, "note", 1, 10, TableQuery{SearchTerm: tt.term, SearchColumns: tt.columns})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, row := range result.Rows {
+				for _, col := range row.Matches {
+					got = append(got, fmt.Sprintf("%v:%s", row.Values["id"], col))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matches = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
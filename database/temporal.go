@@ -0,0 +1,145 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// TemporalInfo describes a system-versioned (temporal) table
+type TemporalInfo struct {
+	PeriodStart  string `json:"periodStart"`
+	PeriodEnd    string `json:"periodEnd"`
+	HistoryTable string `json:"historyTable,omitempty"` // schema.table on SQL Server, empty on MariaDB
+}
+
+// getSQLServerTemporal returns the period and history table of a system-versioned table,
+// or nil when the table isn't temporal or the server predates SQL Server 2016
+func getSQLServerTemporal(db *sql.DB, tableName string) *TemporalInfo {
+	var t TemporalInfo
+	err := db.QueryRow(`
+		SELECT cs.name, ce.name, SCHEMA_NAME(h.schema_id) + '.' + h.name
+		FROM sys.tables t
+		JOIN sys.periods p ON p.object_id = t.object_id
+		JOIN sys.columns cs ON cs.object_id = t.object_id AND cs.column_id = p.start_column_id
+		JOIN sys.columns ce ON ce.object_id = t.object_id AND ce.column_id = p.end_column_id
+		JOIN sys.tables h ON h.object_id = t.history_table_id
+		WHERE t.object_id = OBJECT_ID(@p1) AND t.temporal_type = 2`, tableName).Scan(&t.PeriodStart, &t.PeriodEnd, &t.HistoryTable)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// getSQLServerHistoryTables returns the history tables of all temporal tables.
+// They are maintained by the server and compared as part of their temporal table.
+func getSQLServerHistoryTables(db *sql.DB) map[string]bool {
+	history := make(map[string]bool)
+	rows, err := db.Query("SELECT name FROM sys.tables WHERE temporal_type = 1")
+	if err != nil {
+		// temporal_type doesn't exist before SQL Server 2016
+		return history
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err == nil {
+			history[name] = true
+		}
+	}
+	return history
+}
+
+// getMariaDBTemporal returns the implicit period of a MariaDB system-versioned table.
+// MySQL itself has no system versioning, so this is always nil there.
+func getMariaDBTemporal(db *sql.DB, tableName string) *TemporalInfo {
+	var tableType string
+	err := db.QueryRow(`
+		SELECT TABLE_TYPE FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?`, tableName).Scan(&tableType)
+	if err != nil || tableType != "SYSTEM VERSIONED" {
+		return nil
+	}
+	return &TemporalInfo{PeriodStart: "ROW_START", PeriodEnd: "ROW_END"}
+}
+
+// filterTableNames removes the excluded names, keeping order
+func filterTableNames(names []string, exclude map[string]bool) []string {
+	if len(exclude) == 0 {
+		return names
+	}
+	var kept []string
+	for _, name := range names {
+		if !exclude[name] {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+func temporalEqual(a, b *TemporalInfo) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// compareTemporal diffs the system versioning of a table. Only SQL Server
+// reports a history table, which is how the two dialects are told apart.
+func compareTemporal(tableName string, source, target *TemporalInfo) []DiffResult {
+	if temporalEqual(source, target) {
+		return nil
+	}
+
+	var detail string
+	var stmts []string
+	switch {
+	case target == nil:
+		detail = "Enable system versioning"
+		if source.HistoryTable == "" {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE `%s` ADD SYSTEM VERSIONING;", tableName))
+		} else {
+			stmts = append(stmts,
+				fmt.Sprintf("ALTER TABLE [%s] ADD PERIOD FOR SYSTEM_TIME ([%s], [%s]);", tableName, source.PeriodStart, source.PeriodEnd),
+				fmt.Sprintf("ALTER TABLE [%s] SET (SYSTEM_VERSIONING = ON (HISTORY_TABLE = %s));", tableName, quoteSQLServerQualified(source.HistoryTable)))
+		}
+	case source == nil:
+		detail = "Disable system versioning"
+		if target.HistoryTable == "" {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE `%s` DROP SYSTEM VERSIONING;", tableName))
+		} else {
+			// The history table stays behind as a regular table
+			detail += fmt.Sprintf(" (history table %s is kept)", target.HistoryTable)
+			stmts = append(stmts,
+				fmt.Sprintf("ALTER TABLE [%s] SET (SYSTEM_VERSIONING = OFF);", tableName),
+				fmt.Sprintf("ALTER TABLE [%s] DROP PERIOD FOR SYSTEM_TIME;", tableName))
+		}
+	default:
+		detail = fmt.Sprintf("Change system versioning: period (%s, %s) history %s -> period (%s, %s) history %s",
+			target.PeriodStart, target.PeriodEnd, target.HistoryTable, source.PeriodStart, source.PeriodEnd, source.HistoryTable)
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE [%s] SET (SYSTEM_VERSIONING = OFF);", tableName))
+		if source.PeriodStart != target.PeriodStart || source.PeriodEnd != target.PeriodEnd {
+			stmts = append(stmts,
+				fmt.Sprintf("ALTER TABLE [%s] DROP PERIOD FOR SYSTEM_TIME;", tableName),
+				fmt.Sprintf("ALTER TABLE [%s] ADD PERIOD FOR SYSTEM_TIME ([%s], [%s]);", tableName, source.PeriodStart, source.PeriodEnd))
+		}
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE [%s] SET (SYSTEM_VERSIONING = ON (HISTORY_TABLE = %s));", tableName, quoteSQLServerQualified(source.HistoryTable)))
+	}
+
+	return []DiffResult{{
+		Type:      "modified",
+		TableName: tableName,
+		Detail:    detail,
+		SQL:       strings.Join(stmts, "\n"),
+	}}
+}
+
+// quoteSQLServerQualified quotes each part of a schema.table name
+func quoteSQLServerQualified(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = "[" + p + "]"
+	}
+	return strings.Join(parts, ".")
+}
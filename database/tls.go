@@ -0,0 +1,173 @@
+package database
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// SSL modes, named after PostgreSQL's sslmode values
+const (
+	SSLDisable    = "disable"     // no encryption
+	SSLPrefer     = "prefer"      // encrypt if the server supports it
+	SSLRequire    = "require"     // encrypt, don't verify the certificate
+	SSLVerifyCA   = "verify-ca"   // encrypt and verify the certificate chain
+	SSLVerifyFull = "verify-full" // also verify the host name
+)
+
+// validateSSLFiles checks that configured certificate files exist before connecting
+func validateSSLFiles(config ConnectionConfig) error {
+	files := []struct{ label, path string }{
+		{"SSL root certificate", config.SSLRootCert},
+		{"SSL client certificate", config.SSLCert},
+		{"SSL client key", config.SSLKey},
+	}
+	for _, f := range files {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			return fmt.Errorf("%s not found: %s", f.label, f.path)
+		}
+	}
+	if (config.SSLCert == "") != (config.SSLKey == "") {
+		return fmt.Errorf("SSL client certificate and key must be set together")
+	}
+	return nil
+}
+
+// sslMode returns the configured mode, lower-cased, with "" meaning the driver default
+func sslMode(config ConnectionConfig) (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(config.SSLMode))
+	switch mode {
+	case "", SSLDisable, SSLPrefer, SSLRequire, SSLVerifyCA, SSLVerifyFull:
+		return mode, nil
+	case "allow", "preferred":
+		return SSLPrefer, nil
+	case "required":
+		return SSLRequire, nil
+	}
+	return "", fmt.Errorf("unsupported SSL mode: %s", config.SSLMode)
+}
+
+// postgresSSLParams returns the lib/pq TLS parameters; without a mode it keeps sslmode=disable
+func postgresSSLParams(config ConnectionConfig, mode string) string {
+	if mode == "" {
+		if config.TrustServerCertificate {
+			mode = SSLRequire
+		} else {
+			mode = SSLDisable
+		}
+	}
+	if config.TrustServerCertificate && (mode == SSLVerifyCA || mode == SSLVerifyFull) {
+		mode = SSLRequire
+	}
+
+	params := fmt.Sprintf("sslmode=%s", mode)
+	if config.SSLRootCert != "" {
+		params += " sslrootcert=" + quotePostgresParam(config.SSLRootCert)
+	}
+	if config.SSLCert != "" {
+		params += " sslcert=" + quotePostgresParam(config.SSLCert) + " sslkey=" + quotePostgresParam(config.SSLKey)
+	}
+	return params
+}
+
+// quotePostgresParam quotes a lib/pq connection parameter value, escaping the
+// backslashes of Windows paths and any single quotes
+func quotePostgresParam(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+// sqlServerSSLParams returns the go-mssqldb encryption parameters, or "" to keep the driver default
+func sqlServerSSLParams(config ConnectionConfig, mode string) string {
+	if mode == "" && !config.TrustServerCertificate {
+		return ""
+	}
+
+	var params []string
+	switch mode {
+	case SSLDisable:
+		return ";encrypt=disable"
+	case SSLPrefer:
+		params = append(params, "encrypt=false")
+	default:
+		params = append(params, "encrypt=true")
+	}
+
+	trust := config.TrustServerCertificate || mode == SSLRequire || mode == SSLPrefer
+	params = append(params, fmt.Sprintf("TrustServerCertificate=%t", trust))
+	if config.SSLRootCert != "" {
+		params = append(params, "certificate="+config.SSLRootCert)
+	}
+	return ";" + strings.Join(params, ";")
+}
+
+// mysqlTLSParam registers a tls.Config for the connection with the MySQL driver
+// and returns the value of its tls DSN parameter, or "" to keep the default
+func mysqlTLSParam(config ConnectionConfig, mode string) (string, error) {
+	if mode == "" && !config.TrustServerCertificate {
+		return "", nil
+	}
+	switch mode {
+	case SSLDisable:
+		return "false", nil
+	case SSLPrefer:
+		return "preferred", nil
+	}
+
+	tlsConfig := &tls.Config{ServerName: config.Host}
+	if config.SSLRootCert != "" {
+		pem, err := os.ReadFile(config.SSLRootCert)
+		if err != nil {
+			return "", fmt.Errorf("failed to read SSL root certificate: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return "", fmt.Errorf("no certificates found in %s", config.SSLRootCert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.SSLCert != "" {
+		cert, err := tls.LoadX509KeyPair(config.SSLCert, config.SSLKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to load SSL client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	switch {
+	case config.TrustServerCertificate || mode == SSLRequire || mode == "":
+		tlsConfig.InsecureSkipVerify = true
+	case mode == SSLVerifyCA:
+		// Verify the chain but not the host name
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return fmt.Errorf("server presented no certificate")
+			}
+			opts := x509.VerifyOptions{Roots: tlsConfig.RootCAs, Intermediates: x509.NewCertPool()}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		}
+	}
+
+	// One registration per distinct TLS setup; re-registering replaces it
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s|%s|%s|%s|%s|%t", config.Host, mode, config.SSLRootCert, config.SSLCert, config.SSLKey, config.TrustServerCertificate)
+	name := fmt.Sprintf("syncforge-%x", h.Sum32())
+	if err := mysql.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return "", err
+	}
+	return name, nil
+}
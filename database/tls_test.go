@@ -0,0 +1,25 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestPostgresSSLParamsEscapesPaths(t *testing.T) {
+	config := ConnectionConfig{
+		Type:        PostgreSQL,
+		SSLMode:     SSLVerifyFull,
+		SSLRootCert: `C:\certs\ca.pem`,
+		SSLCert:     `C:\certs\client's.pem`,
+		SSLKey:      `/keys/client.key`,
+	}
+	got := postgresSSLParams(config, SSLVerifyFull)
+	want := `sslmode=verify-full sslrootcert='C:\\certs\\ca.pem' sslcert='C:\\certs\\client\'s.pem' sslkey='/keys/client.key'`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if _, err := pq.NewConnector("host=localhost dbname=app " + got); err != nil {
+		t.Errorf("lib/pq rejects the parameters: %v", err)
+	}
+}
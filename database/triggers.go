@@ -0,0 +1,268 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TriggerInfo holds a table trigger
+type TriggerInfo struct {
+	Name   string `json:"name"`
+	Event  string `json:"event"`  // INSERT, UPDATE or DELETE
+	Timing string `json:"timing"` // BEFORE, AFTER or INSTEAD OF
+	// Order is the MySQL ACTION_ORDER among triggers with the same event and timing, 0 if unordered
+	Order     int    `json:"order,omitempty"`
+	Statement string `json:"statement"`
+	// Definition is the complete CREATE TRIGGER statement where the server keeps one
+	Definition string `json:"definition,omitempty"`
+}
+
+// compareTriggers diffs the triggers of a table by name. A trigger can't be altered,
+// so a change of timing, event, order or body drops and recreates it.
+func compareTriggers(tableName string, source, target []TriggerInfo, dialect DBType, q func(string) string) []DiffResult {
+	var results []DiffResult
+
+	targetMap := make(map[string]TriggerInfo)
+	for _, t := range target {
+		targetMap[t.Name] = t
+	}
+	sourceMap := make(map[string]TriggerInfo)
+	for _, t := range source {
+		sourceMap[t.Name] = t
+	}
+
+	for _, sourceTrigger := range source {
+		targetTrigger, exists := targetMap[sourceTrigger.Name]
+		if !exists {
+			results = append(results, DiffResult{
+				Type:       "modified",
+				TableName:  tableName,
+				Detail:     fmt.Sprintf("Add trigger: %s", sourceTrigger.Name),
+				SQL:        buildCreateTrigger(tableName, sourceTrigger, source, q),
+				ObjectType: "trigger",
+			})
+			continue
+		}
+
+		if changes := triggerChanges(sourceTrigger, targetTrigger); len(changes) > 0 {
+			results = append(results, DiffResult{
+				Type:       "modified",
+				TableName:  tableName,
+				Detail:     fmt.Sprintf("Recreate trigger: %s (%s)", sourceTrigger.Name, strings.Join(changes, ", ")),
+				SQL:        dropTriggerSQL(tableName, targetTrigger.Name, dialect, q) + "\n" + buildCreateTrigger(tableName, sourceTrigger, source, q),
+				ObjectType: "trigger",
+			})
+		}
+	}
+
+	// Drops go first: MySQL before 5.7.2 allows only one trigger per event and timing,
+	// so a renamed trigger can only be created once the old one is gone
+	var drops []DiffResult
+	for _, targetTrigger := range target {
+		if _, exists := sourceMap[targetTrigger.Name]; !exists {
+			drops = append(drops, DiffResult{
+				Type:       "modified",
+				TableName:  tableName,
+				Detail:     fmt.Sprintf("Drop trigger: %s", targetTrigger.Name),
+				SQL:        dropTriggerSQL(tableName, targetTrigger.Name, dialect, q),
+				ObjectType: "trigger",
+			})
+		}
+	}
+
+	return append(drops, results...)
+}
+
+// dropTriggerSQL drops a trigger; PostgreSQL trigger names are scoped to their table
+func dropTriggerSQL(tableName, triggerName string, dialect DBType, q func(string) string) string {
+	if dialect == PostgreSQL {
+		return fmt.Sprintf("DROP TRIGGER %s ON %s;", q(triggerName), q(tableName))
+	}
+	return fmt.Sprintf("DROP TRIGGER %s;", q(triggerName))
+}
+
+// triggerChanges describes how the source trigger differs from the target
+func triggerChanges(source, target TriggerInfo) []string {
+	var changes []string
+	if !strings.EqualFold(source.Timing, target.Timing) {
+		changes = append(changes, fmt.Sprintf("timing %s -> %s", target.Timing, source.Timing))
+	}
+	if !strings.EqualFold(source.Event, target.Event) {
+		changes = append(changes, fmt.Sprintf("event %s -> %s", target.Event, source.Event))
+	}
+	if source.Order != target.Order {
+		changes = append(changes, fmt.Sprintf("order %d -> %d", target.Order, source.Order))
+	}
+	if normalizeSQLBody(source.Statement) != normalizeSQLBody(target.Statement) {
+		changes = append(changes, "body")
+	}
+	return changes
+}
+
+// buildCreateTrigger returns the trigger's CREATE statement. Without a stored
+// definition it is built in MySQL form, placed after the trigger that precedes
+// it in siblings so the firing order is kept.
+func buildCreateTrigger(tableName string, t TriggerInfo, siblings []TriggerInfo, q func(string) string) string {
+	if t.Definition != "" {
+		return strings.TrimSuffix(strings.TrimSpace(t.Definition), ";") + ";"
+	}
+
+	follows := ""
+	if t.Order > 1 {
+		for _, other := range siblings {
+			if other.Order == t.Order-1 && strings.EqualFold(other.Event, t.Event) && strings.EqualFold(other.Timing, t.Timing) {
+				follows = fmt.Sprintf(" FOLLOWS %s", q(other.Name))
+				break
+			}
+		}
+	}
+	body := strings.TrimSuffix(strings.TrimSpace(t.Statement), ";")
+	return fmt.Sprintf("CREATE TRIGGER %s %s %s ON %s FOR EACH ROW%s %s;",
+		q(t.Name), strings.ToUpper(t.Timing), strings.ToUpper(t.Event), q(tableName), follows, body)
+}
+
+func getMySQLTriggers(db *sql.DB, tableName string) ([]TriggerInfo, error) {
+	rows, err := db.Query(`
+		SELECT TRIGGER_NAME, EVENT_MANIPULATION, ACTION_TIMING, ACTION_ORDER, ACTION_STATEMENT
+		FROM INFORMATION_SCHEMA.TRIGGERS
+		WHERE TRIGGER_SCHEMA = DATABASE() AND EVENT_OBJECT_TABLE = ?
+		ORDER BY ACTION_TIMING, EVENT_MANIPULATION, ACTION_ORDER`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var triggers []TriggerInfo
+	for rows.Next() {
+		var t TriggerInfo
+		if err := rows.Scan(&t.Name, &t.Event, &t.Timing, &t.Order, &t.Statement); err != nil {
+			return nil, err
+		}
+		triggers = append(triggers, t)
+	}
+	return triggers, rows.Err()
+}
+
+// pg_trigger.tgtype bits
+const (
+	pgTriggerBefore   = 1 << 1
+	pgTriggerInsert   = 1 << 2
+	pgTriggerDelete   = 1 << 3
+	pgTriggerUpdate   = 1 << 4
+	pgTriggerTruncate = 1 << 5
+	pgTriggerInstead  = 1 << 6
+)
+
+func getPostgreSQLTriggers(db *sql.DB, tableName string) ([]TriggerInfo, error) {
+	rows, err := db.Query(`
+		SELECT t.tgname, t.tgtype, pg_get_triggerdef(t.oid)
+		FROM pg_trigger t
+		JOIN pg_class c ON c.oid = t.tgrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE NOT t.tgisinternal AND n.nspname = 'public' AND c.relname = $1
+		ORDER BY t.tgname`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var triggers []TriggerInfo
+	for rows.Next() {
+		var t TriggerInfo
+		var tgType int
+		if err := rows.Scan(&t.Name, &tgType, &t.Definition); err != nil {
+			return nil, err
+		}
+		switch {
+		case tgType&pgTriggerBefore != 0:
+			t.Timing = "BEFORE"
+		case tgType&pgTriggerInstead != 0:
+			t.Timing = "INSTEAD OF"
+		default:
+			t.Timing = "AFTER"
+		}
+		var events []string
+		for _, e := range []struct {
+			bit  int
+			name string
+		}{{pgTriggerInsert, "INSERT"}, {pgTriggerUpdate, "UPDATE"}, {pgTriggerDelete, "DELETE"}, {pgTriggerTruncate, "TRUNCATE"}} {
+			if tgType&e.bit != 0 {
+				events = append(events, e.name)
+			}
+		}
+		t.Event = strings.Join(events, " OR ")
+		t.Statement = t.Definition
+		triggers = append(triggers, t)
+	}
+	return triggers, rows.Err()
+}
+
+// sqliteTriggerPattern reads timing and event from a CREATE TRIGGER statement;
+// SQLite defaults to BEFORE when no timing is given
+var sqliteTriggerPattern = regexp.MustCompile(`(?is)^\s*CREATE\s+(?:TEMP\s+|TEMPORARY\s+)?TRIGGER\s+(?:IF\s+NOT\s+EXISTS\s+)?(?:"[^"]*"|\S+)\s+(BEFORE\s+|AFTER\s+|INSTEAD\s+OF\s+)?(INSERT|UPDATE|DELETE)`)
+
+func getSQLiteTriggers(db *sql.DB, tableName string) ([]TriggerInfo, error) {
+	rows, err := db.Query("SELECT name, sql FROM sqlite_master WHERE type = 'trigger' AND tbl_name = ? ORDER BY name", tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var triggers []TriggerInfo
+	for rows.Next() {
+		var t TriggerInfo
+		if err := rows.Scan(&t.Name, &t.Definition); err != nil {
+			return nil, err
+		}
+		t.Timing = "BEFORE"
+		if m := sqliteTriggerPattern.FindStringSubmatch(t.Definition); m != nil {
+			if timing := strings.Join(strings.Fields(m[1]), " "); timing != "" {
+				t.Timing = strings.ToUpper(timing)
+			}
+			t.Event = strings.ToUpper(m[2])
+		}
+		t.Statement = t.Definition
+		triggers = append(triggers, t)
+	}
+	return triggers, rows.Err()
+}
+
+func getSQLServerTriggers(db *sql.DB, tableName string) ([]TriggerInfo, error) {
+	rows, err := db.Query(`
+		SELECT t.name, e.type_desc, CASE WHEN t.is_instead_of_trigger = 1 THEN 'INSTEAD OF' ELSE 'AFTER' END, m.definition
+		FROM sys.triggers t
+		JOIN sys.trigger_events e ON e.object_id = t.object_id
+		JOIN sys.sql_modules m ON m.object_id = t.object_id
+		WHERE t.parent_id = OBJECT_ID(@p1)
+		ORDER BY t.name, e.type`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// One row per event; a trigger can fire on several
+	var triggers []TriggerInfo
+	byName := make(map[string]int)
+	for rows.Next() {
+		var name, event, timing string
+		var definition sql.NullString // NULL for triggers created WITH ENCRYPTION
+		if err := rows.Scan(&name, &event, &timing, &definition); err != nil {
+			return nil, err
+		}
+		if i, ok := byName[name]; ok {
+			triggers[i].Event += ", " + event
+			continue
+		}
+		byName[name] = len(triggers)
+		triggers = append(triggers, TriggerInfo{
+			Name:       name,
+			Event:      event,
+			Timing:     timing,
+			Statement:  definition.String,
+			Definition: definition.String,
+		})
+	}
+	return triggers, rows.Err()
+}
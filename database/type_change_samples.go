@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ColumnTypeChange describes a column type change and, once sampled, how the
+// target's existing values would fare under the new type
+type ColumnTypeChange struct {
+	Column   string `json:"column"`
+	FromType string `json:"fromType"` // the target's current type
+	ToType   string `json:"toType"`   // the source's type it changes to
+	// Samples are a few of the column's non-NULL values in the target
+	Samples []string `json:"samples,omitempty"`
+	// Checked is set when FailingValues could be counted for the new type
+	Checked bool `json:"checked"`
+	// FailingValues counts non-NULL values that don't parse as the new type
+	FailingValues int64 `json:"failingValues"`
+}
+
+// Numeric types the conversion check can count failures for. Values are only
+// checked for their form, not for the range of the new type.
+var decimalTypePattern = regexp.MustCompile(`^(decimal|numeric|float[48]?|double|real|money|smallmoney)\b`)
+
+// SampleTypeChanges reads up to sampleSize values of each column whose type
+// changes from the target, and counts the values that wouldn't convert to the
+// new type, e.g. non-numeric text in a VARCHAR becoming INT. diffs are updated
+// in place.
+func SampleTypeChanges(target ConnectionConfig, diffs []DiffResult, sampleSize int) error {
+	return SampleTypeChangesContext(context.Background(), target, diffs, sampleSize)
+}
+
+// SampleTypeChangesContext is SampleTypeChanges, aborting when ctx is done
+func SampleTypeChangesContext(ctx context.Context, target ConnectionConfig, diffs []DiffResult, sampleSize int) error {
+	var changes []*DiffResult
+	for i := range diffs {
+		if diffs[i].TypeChange != nil {
+			changes = append(changes, &diffs[i])
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	db, err := ConnectContext(ctx, target)
+	if err != nil {
+		return fmt.Errorf("failed to connect to target: %v", err)
+	}
+	defer db.Close()
+
+	dbType := target.Type
+	if dbType == "" {
+		dbType = MySQL
+	}
+
+	for _, diff := range changes {
+		change := diff.TypeChange
+		col := quoteIdentifier(dbType, change.Column)
+		notNull := col + " IS NOT NULL"
+
+		change.Samples = nil
+		err := forEachTableRow(db, dbType, diff.TableName, []string{change.Column}, tableReadOptions{where: notNull, limit: sampleSize}, func(row map[string]interface{}) {
+			change.Samples = append(change.Samples, fmt.Sprintf("%v", row[change.Column]))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to sample %s.%s: %v", diff.TableName, change.Column, err)
+		}
+
+		failing, ok := conversionFailure(dbType, col, change.ToType)
+		change.Checked = ok
+		change.FailingValues = 0
+		if !ok {
+			continue
+		}
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s AND %s", quoteIdentifier(dbType, diff.TableName), notNull, failing)
+		if err := db.QueryRowContext(ctx, query).Scan(&change.FailingValues); err != nil {
+			return fmt.Errorf("failed to check conversion of %s.%s: %v", diff.TableName, change.Column, err)
+		}
+	}
+	return nil
+}
+
+// conversionFailure returns a condition matching values of col that don't convert
+// to toType. SQL Server tries the conversion itself; the other dialects match the
+// text of the value against the form of an integer or decimal number, so only
+// numeric target types can be checked there.
+func conversionFailure(dbType DBType, col, toType string) (string, bool) {
+	if dbType == SQLServer {
+		return fmt.Sprintf("TRY_CAST(%s AS %s) IS NULL", col, toType), true
+	}
+
+	base := strings.ToLower(strings.TrimSpace(toType))
+	var pattern string
+	switch {
+	case integerTypePattern.MatchString(base):
+		pattern = `^ *[-+]?[0-9]+ *$`
+	case decimalTypePattern.MatchString(base):
+		pattern = `^ *[-+]?([0-9]+[.]?[0-9]*|[.][0-9]+)([eE][-+]?[0-9]+)? *$`
+	default:
+		return "", false
+	}
+
+	switch dbType {
+	case PostgreSQL:
+		return fmt.Sprintf("%s::text !~ '%s'", col, pattern), true
+	case SQLite:
+		// SQLite has no regular expressions by default, so the characters are
+		// checked with GLOB after stripping the spaces and sign
+		text := fmt.Sprintf("LTRIM(TRIM(CAST(%s AS TEXT)), '+-')", col)
+		if integerTypePattern.MatchString(base) {
+			return fmt.Sprintf("(%s = '' OR %s GLOB '*[^0-9]*')", text, text), true
+		}
+		return fmt.Sprintf("(%s NOT GLOB '*[0-9]*' OR %s GLOB '*[^0-9.eE+-]*')", text, text), true
+	default:
+		return fmt.Sprintf("NOT (CAST(%s AS CHAR) REGEXP '%s')", col, pattern), true
+	}
+}
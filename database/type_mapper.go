@@ -0,0 +1,306 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// TypeMapper normalizes and translates column types between dialects.
+// Custom mappers return "" for types they don't handle, deferring to the
+// next registered mapper and finally the built-in mappings.
+type TypeMapper interface {
+	// Normalize returns the dialect-independent name of a type, e.g. "int"
+	// for MySQL int(11), PostgreSQL integer and SQLite INTEGER
+	Normalize(dbType DBType, rawType string) string
+	// Translate returns the type to declare in the to dialect
+	Translate(from, to DBType, rawType string) string
+}
+
+var typeMappers = struct {
+	mu      sync.RWMutex
+	mappers []TypeMapper
+}{}
+
+// RegisterTypeMapper adds a mapper that is consulted before the built-in
+// mappings. Mappers registered later take precedence.
+func RegisterTypeMapper(m TypeMapper) {
+	typeMappers.mu.Lock()
+	defer typeMappers.mu.Unlock()
+	typeMappers.mappers = append([]TypeMapper{m}, typeMappers.mappers...)
+}
+
+// ResetTypeMappers removes all registered mappers
+func ResetTypeMappers() {
+	typeMappers.mu.Lock()
+	defer typeMappers.mu.Unlock()
+	typeMappers.mappers = nil
+}
+
+// NormalizeType returns the dialect-independent name of a column type
+func NormalizeType(dbType DBType, rawType string) string {
+	typeMappers.mu.RLock()
+	defer typeMappers.mu.RUnlock()
+	for _, m := range typeMappers.mappers {
+		if t := m.Normalize(dbType, rawType); t != "" {
+			return t
+		}
+	}
+	return DefaultTypeMapper{}.Normalize(dbType, rawType)
+}
+
+// TranslateType returns the column type to declare in the to dialect
+func TranslateType(from, to DBType, rawType string) string {
+	typeMappers.mu.RLock()
+	defer typeMappers.mu.RUnlock()
+	for _, m := range typeMappers.mappers {
+		if t := m.Translate(from, to, rawType); t != "" {
+			return t
+		}
+	}
+	return DefaultTypeMapper{}.Translate(from, to, rawType)
+}
+
+// typeWithArgs splits "varchar(255) unsigned" into "varchar", "(255)" and " unsigned"
+var typeWithArgs = regexp.MustCompile(`^([a-z][a-z0-9_ ]*?)\s*(\([^)]*\))?(\s+unsigned)?(\s+zerofill)?$`)
+
+// canonicalTypes maps type names of every dialect to a common name
+var canonicalTypes = map[string]string{
+	"int": "int", "integer": "int", "int4": "int", "mediumint": "int",
+	"tinyint": "tinyint", "smallint": "smallint", "int2": "smallint",
+	"bigint": "bigint", "int8": "bigint",
+	"serial": "int", "bigserial": "bigint", "smallserial": "smallint",
+	"bool": "boolean", "boolean": "boolean", "bit": "bit",
+	"decimal": "decimal", "numeric": "decimal", "money": "decimal",
+	"float": "double", "double": "double", "double precision": "double", "float8": "double",
+	"real": "float", "float4": "float",
+	"varchar": "varchar", "character varying": "varchar", "nvarchar": "varchar",
+	"char": "char", "character": "char", "nchar": "char", "bpchar": "char",
+	"text": "text", "tinytext": "text", "mediumtext": "text", "longtext": "text", "ntext": "text", "clob": "text",
+	"blob": "blob", "tinyblob": "blob", "mediumblob": "blob", "longblob": "blob", "bytea": "blob",
+	"varbinary": "blob", "binary": "blob", "image": "blob",
+	"date": "date", "time": "time", "time without time zone": "time",
+	"datetime": "timestamp", "datetime2": "timestamp", "smalldatetime": "timestamp",
+	"timestamp": "timestamp", "timestamp without time zone": "timestamp",
+	"timestamptz": "timestamptz", "timestamp with time zone": "timestamptz", "datetimeoffset": "timestamptz",
+	"json": "json", "jsonb": "json",
+	"uuid": "uuid", "uniqueidentifier": "uuid",
+}
+
+// dialectTypes renders a canonical type in each dialect; missing entries keep the canonical name
+var dialectTypes = map[DBType]map[string]string{
+	MySQL: {
+		"boolean": "tinyint(1)", "timestamp": "datetime", "timestamptz": "datetime",
+		"blob": "longblob", "uuid": "char(36)", "text": "longtext",
+	},
+	PostgreSQL: {
+		"tinyint": "smallint", "int": "integer", "double": "double precision", "float": "real",
+		"blob": "bytea", "timestamptz": "timestamp with time zone", "json": "jsonb",
+		"bit": "boolean",
+	},
+	SQLServer: {
+		"boolean": "bit", "tinyint": "tinyint", "double": "float", "text": "nvarchar(max)",
+		"blob": "varbinary(max)", "timestamp": "datetime2", "timestamptz": "datetimeoffset",
+		"json": "nvarchar(max)", "uuid": "uniqueidentifier", "varchar": "nvarchar", "char": "nchar",
+	},
+	SQLite: {
+		"int": "INTEGER", "tinyint": "INTEGER", "smallint": "INTEGER", "bigint": "INTEGER", "boolean": "INTEGER",
+		"bit": "INTEGER", "decimal": "NUMERIC", "double": "REAL", "float": "REAL",
+		"varchar": "TEXT", "char": "TEXT", "text": "TEXT", "blob": "BLOB",
+		"date": "TEXT", "time": "TEXT", "timestamp": "TEXT", "timestamptz": "TEXT", "json": "TEXT", "uuid": "TEXT",
+	},
+}
+
+// DefaultTypeMapper holds the built-in mappings between MySQL, PostgreSQL, SQL Server and SQLite
+type DefaultTypeMapper struct{}
+
+// Normalize returns the canonical name, keeping length/precision arguments where they matter
+func (DefaultTypeMapper) Normalize(dbType DBType, rawType string) string {
+	name, args, unsigned := splitType(rawType)
+	canonical, ok := canonicalTypes[name]
+	if !ok {
+		return strings.ToLower(strings.TrimSpace(rawType))
+	}
+
+	// MySQL's tinyint(1) is its boolean
+	if canonical == "tinyint" && args == "(1)" && (dbType == MySQL || dbType == "") {
+		return "boolean"
+	}
+	if canonical == "bit" && (args == "" || args == "(1)") {
+		return "boolean"
+	}
+
+	switch canonical {
+	case "varchar", "char", "decimal":
+		// Length and precision are significant; "max" marks an unbounded SQL Server type
+		if args == "(max)" {
+			if canonical == "varchar" {
+				return "text"
+			}
+			return "blob"
+		}
+		canonical += args
+	case "blob":
+		if name == "varbinary" && args != "" && args != "(max)" {
+			return "varbinary" + args
+		}
+	}
+	return canonical + unsigned
+}
+
+// Translate renders the normalized type in the target dialect
+func (m DefaultTypeMapper) Translate(from, to DBType, rawType string) string {
+	if from == to || (from == "" && to == MySQL) || (to == "" && from == MySQL) {
+		return rawType
+	}
+
+	normalized := m.Normalize(from, rawType)
+	name, args, unsigned := splitType(normalized)
+	if _, ok := canonicalTypes[name]; !ok {
+		// Unknown to the built-in mappings; keep the declared type
+		return rawType
+	}
+
+	target := to
+	if target == "" {
+		target = MySQL
+	}
+	if name == "varchar" && args == "" && target == MySQL {
+		// MySQL requires a length; an unbounded varchar is text
+		name = "text"
+	}
+	if rendered, ok := dialectTypes[target][name]; ok {
+		if strings.Contains(rendered, "(") || target == SQLite {
+			return rendered
+		}
+		name = rendered
+	}
+	if target != MySQL {
+		unsigned = ""
+	}
+	return name + args + unsigned
+}
+
+// splitType returns the lower-cased type name, its argument list and an unsigned suffix
+func splitType(rawType string) (string, string, string) {
+	t := strings.ToLower(strings.TrimSpace(rawType))
+	m := typeWithArgs.FindStringSubmatch(t)
+	if m == nil {
+		return t, "", ""
+	}
+	args := strings.ReplaceAll(m[2], " ", "")
+	return strings.TrimSpace(m[1]), args, m[3]
+}
+
+// pgCastLiteral matches PostgreSQL defaults such as 'abc'::character varying
+var pgCastLiteral = regexp.MustCompile(`^'((?:[^']|'')*)'::[a-z ]+$`)
+
+// portableDefault converts a captured default to the unquoted form used by
+// MySQL and buildColumnDef. Engine-specific expressions such as nextval() have
+// no portable form and yield nil.
+func portableDefault(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*value)
+	// SQL Server wraps defaults in parentheses: ((0)), ('abc')
+	for strings.HasPrefix(v, "(") && strings.HasSuffix(v, ")") {
+		v = strings.TrimSpace(v[1 : len(v)-1])
+	}
+
+	if m := pgCastLiteral.FindStringSubmatch(v); m != nil {
+		v = strings.ReplaceAll(m[1], "''", "'")
+		return &v
+	}
+	if len(v) >= 2 && strings.HasPrefix(v, "'") && strings.HasSuffix(v, "'") {
+		v = strings.ReplaceAll(v[1:len(v)-1], "''", "'")
+		return &v
+	}
+	if isNumericDefault(v) || isSpecialDefault(v) {
+		return &v
+	}
+	if strings.Contains(v, "(") || strings.Contains(v, "::") {
+		return nil
+	}
+	return &v
+}
+
+// CompareSchemasCrossDialect compares schemas read from different database types.
+// Column types are compared by their normalized form and generated DDL declares
+// source columns with types translated to the target dialect.
+func CompareSchemasCrossDialect(source, target *SchemaInfo, sourceType, targetType DBType, opts CompareOptions) []DiffResult {
+	translated := &SchemaInfo{
+		Database:          source.Database,
+		DBType:            sourceType,
+		Tables:            make(map[string]TableInfo, len(source.Tables)),
+		Enums:             source.Enums,
+		MaterializedViews: source.MaterializedViews,
+		Sequences:         source.Sequences,
+	}
+	aligned := &SchemaInfo{
+		Database:          target.Database,
+		DBType:            targetType,
+		Tables:            make(map[string]TableInfo, len(target.Tables)),
+		Enums:             target.Enums,
+		MaterializedViews: target.MaterializedViews,
+		Sequences:         target.Sequences,
+	}
+
+	for name, table := range source.Tables {
+		columns := make([]ColumnInfo, len(table.Columns))
+		for i, col := range table.Columns {
+			col.Type = TranslateType(sourceType, targetType, col.Type)
+			col.Default = portableDefault(col.Default)
+			columns[i] = col
+		}
+		table.Columns = columns
+		table.CreateSQL = buildCreateTableSQL(targetType, table)
+		translated.Tables[name] = table
+	}
+
+	// Target columns whose type means the same as the translated source type take
+	// its spelling, so only real type differences are reported
+	for name, table := range target.Tables {
+		sourceCols := make(map[string]ColumnInfo)
+		for _, col := range translated.Tables[name].Columns {
+			sourceCols[col.Name] = col
+		}
+		columns := make([]ColumnInfo, len(table.Columns))
+		for i, col := range table.Columns {
+			if sourceCol, ok := sourceCols[col.Name]; ok &&
+				NormalizeType(targetType, sourceCol.Type) == NormalizeType(targetType, col.Type) {
+				col.Type = sourceCol.Type
+			}
+			col.Default = portableDefault(col.Default)
+			columns[i] = col
+		}
+		table.Columns = columns
+		aligned.Tables[name] = table
+	}
+
+	return CompareSchemasWithOptions(translated, aligned, opts)
+}
+
+// buildCreateTableSQL builds a CREATE TABLE statement for the dialect from captured columns
+func buildCreateTableSQL(dbType DBType, table TableInfo) string {
+	var parts []string
+	var primaryKeys []string
+	for _, col := range table.Columns {
+		def := fmt.Sprintf("%s %s", quoteIdentifier(dbType, col.Name), col.Type)
+		if col.Nullable == "NO" {
+			def += " NOT NULL"
+		}
+		if col.Default != nil {
+			def += " DEFAULT " + formatDefault(*col.Default)
+		}
+		parts = append(parts, def)
+		if col.Key == "PRI" {
+			primaryKeys = append(primaryKeys, quoteIdentifier(dbType, col.Name))
+		}
+	}
+	if len(primaryKeys) > 0 {
+		parts = append(parts, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKeys, ", ")))
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", quoteIdentifier(dbType, table.Name), strings.Join(parts, ",\n  "))
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// buildMultiInsertSQL inserts several rows with one statement
+func buildMultiInsertSQL(values *sqlValues, tableName string, rows []map[string]interface{}, columns []string) string {
+	dbType := values.dbType
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s;",
+		quoteIdentifier(dbType, tableName), quoteColumnList(columns, func(c string) string { return quoteIdentifier(dbType, c) }),
+		rowTuples(values, rows, columns))
+}
+
+// buildUpsertSQL inserts rows or, where a row with the same primary key exists,
+// overwrites its other columns: ON DUPLICATE KEY UPDATE on MySQL, ON CONFLICT on
+// PostgreSQL and SQLite, and MERGE on SQL Server
+func buildUpsertSQL(values *sqlValues, tableName string, rows []map[string]interface{}, columns, primaryKeys []string) string {
+	dbType := values.dbType
+	q := func(name string) string { return quoteIdentifier(dbType, name) }
+	table := q(tableName)
+	cols := quoteColumnList(columns, q)
+	tuples := rowTuples(values, rows, columns)
+
+	var updated []string
+	for _, col := range columns {
+		if !containsString(primaryKeys, col) {
+			updated = append(updated, col)
+		}
+	}
+
+	switch dbType {
+	case PostgreSQL, SQLite:
+		action := "DO NOTHING"
+		if len(updated) > 0 {
+			sets := make([]string, len(updated))
+			for i, col := range updated {
+				sets[i] = fmt.Sprintf("%s = excluded.%s", q(col), q(col))
+			}
+			action = "DO UPDATE SET " + strings.Join(sets, ", ")
+		}
+		return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s ON CONFLICT (%s) %s;",
+			table, cols, tuples, quoteColumnList(primaryKeys, q), action)
+	case SQLServer:
+		on := make([]string, len(primaryKeys))
+		for i, pk := range primaryKeys {
+			on[i] = fmt.Sprintf("t.%s = s.%s", q(pk), q(pk))
+		}
+		sourceCols := make([]string, len(columns))
+		for i, col := range columns {
+			sourceCols[i] = "s." + q(col)
+		}
+		matched := ""
+		if len(updated) > 0 {
+			sets := make([]string, len(updated))
+			for i, col := range updated {
+				sets[i] = fmt.Sprintf("%s = s.%s", q(col), q(col))
+			}
+			matched = " WHEN MATCHED THEN UPDATE SET " + strings.Join(sets, ", ")
+		}
+		return fmt.Sprintf("MERGE INTO %s AS t USING (VALUES %s) AS s (%s) ON %s%s WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s);",
+			table, tuples, cols, strings.Join(on, " AND "), matched, cols, strings.Join(sourceCols, ", "))
+	default:
+		// Updating a key column to itself turns a duplicate into a no-op
+		if len(updated) == 0 {
+			updated = primaryKeys[:1]
+		}
+		sets := make([]string, len(updated))
+		for i, col := range updated {
+			sets[i] = fmt.Sprintf("%s = VALUES(%s)", q(col), q(col))
+		}
+		return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s ON DUPLICATE KEY UPDATE %s;",
+			table, cols, tuples, strings.Join(sets, ", "))
+	}
+}
+
+// upsertAll generates an upsert of every source row, in primary key order,
+// without reading the target
+func (c *dataComparison) upsertAll() ([]DataDiffResult, error) {
+	var results []DataDiffResult
+	err := forEachTableRow(c.sourceDB, c.sourceType, c.tableName, c.columns, tableReadOptions{
+		bitColumns:      c.sourceBits,
+		readExpressions: c.options.SourceReadExpressions,
+		where:           c.filtered(""),
+		orderBy:         c.primaryKeys,
+		fetchSize:       c.options.FetchSize,
+	}, func(row map[string]interface{}) {
+		rows := []map[string]interface{}{row}
+		values := &sqlValues{dbType: c.targetType, bind: true}
+		query := buildUpsertSQL(values, c.tableName, rows, c.columns, c.primaryKeys)
+		results = append(results, DataDiffResult{
+			Type:       "upsert",
+			TableName:  c.tableName,
+			PrimaryKey: extractPrimaryKey(row, c.primaryKeys),
+			NewValues:  row,
+			SQL:        buildUpsertSQL(&sqlValues{dbType: c.targetType}, c.tableName, rows, c.columns, c.primaryKeys),
+			Query:      query,
+			Args:       values.args,
+			Warning:    checkEnumValues(row, c.targetEnums),
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source data: %v", err)
+	}
+	return results, nil
+}
+
+// rowTuples renders rows as "(v1, v2), (v3, v4)" in column order; missing values are NULL
+func rowTuples(values *sqlValues, rows []map[string]interface{}, columns []string) string {
+	tuples := make([]string, len(rows))
+	for i, row := range rows {
+		vals := make([]string, len(columns))
+		for j, col := range columns {
+			vals[j] = values.render(row[col])
+		}
+		tuples[i] = "(" + strings.Join(vals, ", ") + ")"
+	}
+	return strings.Join(tuples, ", ")
+}
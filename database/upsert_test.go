@@ -0,0 +1,91 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBuildUpsertSQL(t *testing.T) {
+	rows := []map[string]interface{}{{"id": int64(1), "name": "a"}}
+	tests := []struct {
+		dbType  DBType
+		columns []string
+		want    string
+	}{
+		{MySQL, []string{"id", "name"}, "INSERT INTO `item` (`id`, `name`) VALUES (1, 'a') ON DUPLICATE KEY UPDATE `name` = VALUES(`name`);"},
+		{MySQL, []string{"id"}, "INSERT INTO `item` (`id`) VALUES (1) ON DUPLICATE KEY UPDATE `id` = VALUES(`id`);"},
+		{PostgreSQL, []string{"id", "name"}, `INSERT INTO "item" ("id", "name") VALUES (1, 'a') ON CONFLICT ("id") DO UPDATE SET "name" = excluded."name";`},
+		{SQLite, []string{"id"}, `INSERT INTO "item" ("id") VALUES (1) ON CONFLICT ("id") DO NOTHING;`},
+		{SQLServer, []string{"id", "name"}, "MERGE INTO [item] AS t USING (VALUES (1, 'a')) AS s ([id], [name]) ON t.[id] = s.[id] WHEN MATCHED THEN UPDATE SET [name] = s.[name] WHEN NOT MATCHED THEN INSERT ([id], [name]) VALUES (s.[id], s.[name]);"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.dbType), func(t *testing.T) {
+			got := buildUpsertSQL(&sqlValues{dbType: tt.dbType}, "item", rows, tt.columns, []string{"id"})
+			if got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpsertStrategyIsIdempotent(t *testing.T) {
+	dir := t.TempDir()
+	source := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(dir, "source.db")}
+	target := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(dir, "target.db")}
+	for config, data := range map[ConnectionConfig]string{
+		source: "INSERT INTO item VALUES (1, 'one'), (2, 'two'), (3, 'three')",
+		target: "INSERT INTO item VALUES (1, 'stale'), (4, 'target only')",
+	} {
+		db, err := sql.Open("sqlite3", config.FilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec("CREATE TABLE item (id INTEGER PRIMARY KEY, name TEXT); " + data); err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+	}
+
+	diffs, err := CompareTableDataWithOptions(source, target, "item", DataCompareOptions{Strategy: SyncStrategyUpsert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 3 {
+		t.Fatalf("got %d diffs, want one upsert per source row", len(diffs))
+	}
+	for run := 0; run < 2; run++ {
+		report, err := ApplyDataSync(target, diffs, SyncOptions{SyncInsert: true, SyncUpdate: true})
+		if err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		if report.Upserted != 3 {
+			t.Errorf("run %d: upserted %d rows, want 3", run, report.Upserted)
+		}
+	}
+
+	db, err := sql.Open("sqlite3", target.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	rows, err := db.Query("SELECT name FROM item ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, name)
+	}
+	// The upsert strategy never deletes target rows
+	want := []string{"one", "two", "three", "target only"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("target holds %q, want %q", got, want)
+	}
+}
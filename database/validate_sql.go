@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// Validation methods reported in StatementCheck.Method
+const (
+	ValidationRollback  = "rollback"  // executed in a transaction that is rolled back
+	ValidationPrepare   = "prepare"   // parsed by the server with PREPARE, not executed
+	ValidationParseOnly = "parseonly" // parsed by the server with SET PARSEONLY
+)
+
+// StatementCheck is the validation outcome of one statement. Method is empty
+// when the server can't check the statement without running it.
+type StatementCheck struct {
+	Statement string `json:"statement"`
+	Method    string `json:"method"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ValidateStatements checks generated statements against the target before they
+// are applied, without changing it. PostgreSQL and SQLite run them in a transaction
+// that is rolled back, so later statements see the effect of earlier ones. MySQL
+// commits DDL implicitly and only parses each statement with PREPARE; SQL Server
+// parses each statement with SET PARSEONLY.
+func ValidateStatements(ctx context.Context, config ConnectionConfig, statements []string) ([]StatementCheck, error) {
+	db, err := ConnectContext(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	switch config.Type {
+	case PostgreSQL, SQLite:
+		return validateWithRollback(ctx, conn, statements)
+	case SQLServer:
+		return validateWithParseOnly(ctx, conn, statements)
+	default:
+		return validateWithPrepare(ctx, conn, statements)
+	}
+}
+
+// validateWithRollback runs each statement under a savepoint, so a failing
+// statement is undone on its own and the rest are still checked
+func validateWithRollback(ctx context.Context, conn *sql.Conn, statements []string) ([]StatementCheck, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	checks := make([]StatementCheck, len(statements))
+	for i, stmt := range statements {
+		checks[i] = StatementCheck{Statement: stmt, Method: ValidationRollback}
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT syncforge_validate"); err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code == "25001" {
+				// e.g. CREATE INDEX CONCURRENTLY can't run inside a transaction
+				checks[i].Method = ""
+			} else {
+				checks[i].Error = err.Error()
+			}
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT syncforge_validate"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT syncforge_validate"); err != nil {
+			return nil, err
+		}
+	}
+	return checks, nil
+}
+
+// mysqlUnsupportedPrepare is ER_UNSUPPORTED_PS, returned for statements such as
+// CREATE TRIGGER that the prepared statement protocol doesn't accept
+const mysqlUnsupportedPrepare = 1295
+
+func validateWithPrepare(ctx context.Context, conn *sql.Conn, statements []string) ([]StatementCheck, error) {
+	checks := make([]StatementCheck, len(statements))
+	for i, stmt := range statements {
+		checks[i] = StatementCheck{Statement: stmt, Method: ValidationPrepare}
+		if _, err := conn.ExecContext(ctx, "SET @syncforge_validate = ?", stmt); err != nil {
+			return nil, err
+		}
+		if _, err := conn.ExecContext(ctx, "PREPARE syncforge_validate FROM @syncforge_validate"); err != nil {
+			var myErr *mysql.MySQLError
+			if errors.As(err, &myErr) && myErr.Number == mysqlUnsupportedPrepare {
+				checks[i].Method = ""
+			} else {
+				checks[i].Error = err.Error()
+			}
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, "DEALLOCATE PREPARE syncforge_validate"); err != nil {
+			return nil, err
+		}
+	}
+	return checks, nil
+}
+
+func validateWithParseOnly(ctx context.Context, conn *sql.Conn, statements []string) ([]StatementCheck, error) {
+	if _, err := conn.ExecContext(ctx, "SET PARSEONLY ON"); err != nil {
+		return nil, fmt.Errorf("failed to enable parse-only mode: %v", err)
+	}
+	// The connection is discarded afterwards, but don't leave it parsing only if reused
+	defer conn.ExecContext(context.Background(), "SET PARSEONLY OFF")
+
+	checks := make([]StatementCheck, len(statements))
+	for i, stmt := range statements {
+		checks[i] = StatementCheck{Statement: stmt, Method: ValidationParseOnly}
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			checks[i].Error = err.Error()
+		}
+	}
+	return checks, nil
+}
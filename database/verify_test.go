@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifySyncContext(t *testing.T) {
+	dir := t.TempDir()
+	source := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(dir, "source.db")}
+	target := ConnectionConfig{Type: SQLite, FilePath: filepath.Join(dir, "target.db")}
+	for _, config := range []ConnectionConfig{source, target} {
+		db, err := sql.Open("sqlite3", config.FilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, stmt := range []string{
+			"CREATE TABLE item (id INTEGER PRIMARY KEY, name TEXT)",
+			"INSERT INTO item VALUES (1, 'a'), (2, 'b')",
+		} {
+			if _, err := db.Exec(stmt); err != nil {
+				t.Fatal(err)
+			}
+		}
+		db.Close()
+	}
+
+	result, err := VerifySyncContext(context.Background(), source, target, "item")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Match || result.SourceRows != 2 {
+		t.Errorf("got %+v, want a match over 2 rows", result)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := VerifySyncContext(ctx, source, target, "item"); err == nil {
+		t.Error("VerifySyncContext succeeded with a cancelled context")
+	}
+}
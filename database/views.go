@@ -0,0 +1,184 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ViewInfo holds a view and the SELECT statement that defines it
+type ViewInfo struct {
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+}
+
+// viewBodyPattern captures the query of a CREATE VIEW statement, after any column list and options
+var viewBodyPattern = regexp.MustCompile(`(?is)^\s*CREATE\s+.*?\bVIEW\s+.*?\bAS\s+(.*)$`)
+
+// viewBody strips the CREATE VIEW ... AS prefix from a stored view statement
+func viewBody(createSQL string) string {
+	if m := viewBodyPattern.FindStringSubmatch(createSQL); m != nil {
+		return m[1]
+	}
+	return createSQL
+}
+
+// getMySQLViews reads the views of the current database. MySQL qualifies every
+// table in the stored body with the database name, which is dropped so the same
+// view compares equal across databases.
+func getMySQLViews(db *sql.DB) (map[string]ViewInfo, error) {
+	rows, err := db.Query(`
+		SELECT TABLE_NAME, VIEW_DEFINITION, TABLE_SCHEMA
+		FROM INFORMATION_SCHEMA.VIEWS
+		WHERE TABLE_SCHEMA = DATABASE()`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	views := make(map[string]ViewInfo)
+	for rows.Next() {
+		var v ViewInfo
+		var schemaName string
+		if err := rows.Scan(&v.Name, &v.Definition, &schemaName); err != nil {
+			return nil, err
+		}
+		v.Definition = strings.ReplaceAll(v.Definition, fmt.Sprintf("`%s`.", schemaName), "")
+		views[v.Name] = v
+	}
+	return views, rows.Err()
+}
+
+// getPostgreSQLViews reads the views of the public schema
+func getPostgreSQLViews(db *sql.DB) (map[string]ViewInfo, error) {
+	rows, err := db.Query(`
+		SELECT c.relname, pg_get_viewdef(c.oid)
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind = 'v' AND n.nspname = 'public'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	views := make(map[string]ViewInfo)
+	for rows.Next() {
+		var v ViewInfo
+		if err := rows.Scan(&v.Name, &v.Definition); err != nil {
+			return nil, err
+		}
+		views[v.Name] = v
+	}
+	return views, rows.Err()
+}
+
+func getSQLiteViews(db *sql.DB) (map[string]ViewInfo, error) {
+	rows, err := db.Query("SELECT name, sql FROM sqlite_master WHERE type = 'view'")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	views := make(map[string]ViewInfo)
+	for rows.Next() {
+		var name, createSQL string
+		if err := rows.Scan(&name, &createSQL); err != nil {
+			return nil, err
+		}
+		views[name] = ViewInfo{Name: name, Definition: viewBody(createSQL)}
+	}
+	return views, rows.Err()
+}
+
+func getSQLServerViews(db *sql.DB) (map[string]ViewInfo, error) {
+	rows, err := db.Query(`
+		SELECT v.name, m.definition
+		FROM sys.views v
+		JOIN sys.sql_modules m ON m.object_id = v.object_id
+		WHERE v.is_ms_shipped = 0`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	views := make(map[string]ViewInfo)
+	for rows.Next() {
+		var name string
+		var definition sql.NullString // NULL for views created WITH ENCRYPTION
+		if err := rows.Scan(&name, &definition); err != nil {
+			return nil, err
+		}
+		views[name] = ViewInfo{Name: name, Definition: viewBody(definition.String)}
+	}
+	return views, rows.Err()
+}
+
+// compareViews diffs views by their whitespace-normalized definition. A changed
+// view is replaced in place where the dialect can, else dropped and recreated.
+func compareViews(source, target map[string]ViewInfo, dialect DBType, q func(string) string) []DiffResult {
+	var results []DiffResult
+
+	for name, sourceView := range source {
+		targetView, exists := target[name]
+		switch {
+		case !exists:
+			results = append(results, DiffResult{
+				Type:       "added",
+				TableName:  name,
+				Detail:     "View exists in source but not in target",
+				SQL:        buildCreateView(sourceView, dialect, q),
+				ObjectType: "view",
+			})
+		case normalizeViewDefinition(sourceView.Definition) != normalizeViewDefinition(targetView.Definition):
+			results = append(results, DiffResult{
+				Type:       "modified",
+				TableName:  name,
+				Detail:     "View definition differs",
+				SQL:        buildReplaceView(sourceView, dialect, q),
+				ObjectType: "view",
+			})
+		}
+	}
+
+	for name := range target {
+		if _, exists := source[name]; !exists {
+			results = append(results, DiffResult{
+				Type:       "removed",
+				TableName:  name,
+				Detail:     "View exists in target but not in source",
+				SQL:        fmt.Sprintf("DROP VIEW %s;", q(name)),
+				ObjectType: "view",
+			})
+		}
+	}
+
+	return results
+}
+
+// normalizeViewDefinition ignores formatting, identifier quoting and schema qualifiers
+func normalizeViewDefinition(definition string) string {
+	return normalizeSQLBody(definition)
+}
+
+func buildCreateView(v ViewInfo, dialect DBType, q func(string) string) string {
+	definition := strings.TrimSuffix(strings.TrimSpace(v.Definition), ";")
+	if dialect == SQLite || dialect == SQLServer {
+		return fmt.Sprintf("CREATE VIEW %s AS\n%s;", q(v.Name), definition)
+	}
+	return fmt.Sprintf("CREATE OR REPLACE VIEW %s AS\n%s;", q(v.Name), definition)
+}
+
+// buildReplaceView redefines an existing view: SQL Server has CREATE OR ALTER,
+// SQLite can only drop and recreate
+func buildReplaceView(v ViewInfo, dialect DBType, q func(string) string) string {
+	definition := strings.TrimSuffix(strings.TrimSpace(v.Definition), ";")
+	switch dialect {
+	case SQLServer:
+		return fmt.Sprintf("CREATE OR ALTER VIEW %s AS\n%s;", q(v.Name), definition)
+	case SQLite:
+		return fmt.Sprintf("DROP VIEW %s;\n%s", q(v.Name), buildCreateView(v, dialect, q))
+	default:
+		return buildCreateView(v, dialect, q)
+	}
+}
@@ -98,6 +98,7 @@ export default {
     connectFirst: 'Please connect to source database first',
     failedLoadTables: 'Failed to load tables',
     copiedSQL: 'Copied {count} SQL statements',
+    confirmProd: '{error}\n\nType the connection name to continue:',
     noPK: 'No PK',
     pk: 'PK',
     rows: 'rows',
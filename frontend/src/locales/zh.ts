@@ -98,6 +98,7 @@ export default {
     connectFirst: '请先连接源数据库',
     failedLoadTables: '加载表失败',
     copiedSQL: '已复制 {count} 条 SQL 语句',
+    confirmProd: '{error}\n\n请输入连接名称以继续：',
     noPK: '无主键',
     pk: '主键',
     rows: '行',
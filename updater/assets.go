@@ -0,0 +1,101 @@
+package updater
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Names release assets use for each platform, matched case-insensitively
+var (
+	osAliases = map[string][]string{
+		"darwin":  {"macos", "darwin", "osx"},
+		"windows": {"windows"},
+		"linux":   {"linux"},
+	}
+	archAliases = map[string][]string{
+		"amd64": {"amd64", "x86_64", "x64"},
+		"arm64": {"arm64", "aarch64"},
+		"386":   {"386", "i686"},
+	}
+	universalAliases = []string{"universal", "fat"}
+)
+
+// selectAsset picks the release asset for the current platform
+func selectAsset(assets []Asset) (*Asset, error) {
+	return selectAssetFor(assets, runtime.GOOS, runtime.GOARCH)
+}
+
+// selectAssetFor picks the asset for goos and goarch. An asset naming the
+// architecture wins, then a universal build; an asset naming no architecture
+// at all is only taken when it is the platform's only one. No asset for the OS
+// returns nil; assets for the OS but none usable for the architecture is an error.
+func selectAssetFor(assets []Asset, goos, goarch string) (*Asset, error) {
+	var candidates []*Asset
+	for i := range assets {
+		name := strings.ToLower(assets[i].Name)
+		if isChecksumAsset(name) || !containsAny(name, platformAliases(osAliases, goos)) {
+			continue
+		}
+		candidates = append(candidates, &assets[i])
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	for _, asset := range candidates {
+		if containsAny(strings.ToLower(asset.Name), platformAliases(archAliases, goarch)) {
+			return asset, nil
+		}
+	}
+	for _, asset := range candidates {
+		if containsAny(strings.ToLower(asset.Name), universalAliases) {
+			return asset, nil
+		}
+	}
+	if len(candidates) == 1 && !namesAnyArch(candidates[0].Name) {
+		return candidates[0], nil
+	}
+
+	names := make([]string, len(candidates))
+	for i, asset := range candidates {
+		names[i] = asset.Name
+	}
+	return nil, fmt.Errorf("no update for %s/%s among the release assets: %s", goos, goarch, strings.Join(names, ", "))
+}
+
+// platformAliases returns the names of key in aliases, or key itself when unknown
+func platformAliases(aliases map[string][]string, key string) []string {
+	if names, ok := aliases[key]; ok {
+		return names
+	}
+	return []string{key}
+}
+
+func namesAnyArch(name string) bool {
+	name = strings.ToLower(name)
+	for _, names := range archAliases {
+		if containsAny(name, names) {
+			return true
+		}
+	}
+	return containsAny(name, universalAliases)
+}
+
+func isChecksumAsset(name string) bool {
+	for _, checksumName := range checksumFileNames {
+		if name == checksumName {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, ".sha256")
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package updater
+
+import "testing"
+
+func TestSelectAssetFor(t *testing.T) {
+	assets := func(names ...string) []Asset {
+		list := make([]Asset, len(names))
+		for i, name := range names {
+			list[i] = Asset{Name: name}
+		}
+		return list
+	}
+	release := assets(
+		"SyncForge-macos-universal.zip",
+		"SyncForge-windows-amd64.exe",
+		"SyncForge-windows-arm64.exe",
+		"SyncForge-linux-x86_64",
+		"SyncForge-linux-aarch64",
+		"checksums.txt",
+	)
+
+	tests := []struct {
+		name         string
+		assets       []Asset
+		goos, goarch string
+		want         string
+		wantErr      bool
+	}{
+		{"windows amd64", release, "windows", "amd64", "SyncForge-windows-amd64.exe", false},
+		{"windows arm64", release, "windows", "arm64", "SyncForge-windows-arm64.exe", false},
+		{"linux alias", release, "linux", "amd64", "SyncForge-linux-x86_64", false},
+		{"linux arm alias", release, "linux", "arm64", "SyncForge-linux-aarch64", false},
+		{"universal fallback", release, "darwin", "arm64", "SyncForge-macos-universal.zip", false},
+		{"only asset without arch", assets("SyncForge-darwin.zip"), "darwin", "arm64", "SyncForge-darwin.zip", false},
+		{"case insensitive", assets("SyncForge-Windows-AMD64.exe"), "windows", "amd64", "SyncForge-Windows-AMD64.exe", false},
+		{"checksum skipped", assets("SyncForge-linux-amd64.sha256", "SyncForge-linux-amd64"), "linux", "amd64", "SyncForge-linux-amd64", false},
+		{"no asset for os", release, "freebsd", "amd64", "", false},
+		{"wrong arch only", assets("SyncForge-windows-amd64.exe"), "windows", "386", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectAssetFor(tt.assets, tt.goos, tt.goarch)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			name := ""
+			if got != nil {
+				name = got.Name
+			}
+			if name != tt.want {
+				t.Errorf("got %q, want %q", name, tt.want)
+			}
+		})
+	}
+}
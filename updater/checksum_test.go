@@ -0,0 +1,116 @@
+package updater
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseChecksum(t *testing.T) {
+	const (
+		sumA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+		sumB = "BBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB"
+	)
+	tests := []struct {
+		name    string
+		input   string
+		asset   string
+		want    string
+		wantErr bool
+	}{
+		{"shared file", sumA + "  app-linux\n" + sumB + "  app-windows.exe\n", "app-windows.exe", strings.ToLower(sumB), false},
+		{"binary mode", sumA + " *app-linux\n", "app-linux", sumA, false},
+		{"bare hash", sumA + "\n", "app-linux", sumA, false},
+		{"skips junk lines", "# checksums\n\nnot-a-hash app-linux\n" + sumA + "  app-linux\n", "app-linux", sumA, false},
+		{"no prefix match", sumA + "  app-linux.zip\n", "app-linux", "", true},
+		{"missing", sumA + "  app-linux\n", "app-darwin.zip", "", true},
+		{"empty", "", "app-linux", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksum(strings.NewReader(tt.input), tt.asset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadUpdateChecksum(t *testing.T) {
+	payload := []byte("new syncforge build")
+	sum := sha256.Sum256(payload)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(payload)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		checksum string
+		wantErr  bool
+	}{
+		{"match", hex.EncodeToString(sum[:]), false},
+		{"match upper case", strings.ToUpper(hex.EncodeToString(sum[:])), false},
+		{"mismatch", strings.Repeat("0", 64), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("TMPDIR", dir)
+
+			progress := make(chan int, 100)
+			path, err := DownloadUpdateContext(context.Background(), server.URL, tt.checksum, progress)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			entries, _ := os.ReadDir(dir)
+			if tt.wantErr {
+				if len(entries) != 0 {
+					t.Errorf("failed download left %d file(s) behind", len(entries))
+				}
+				return
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != string(payload) {
+				t.Errorf("downloaded %q, want %q", data, payload)
+			}
+		})
+	}
+}
+
+func TestDownloadUpdateUnreadProgress(t *testing.T) {
+	payload := []byte("new syncforge build")
+	sum := sha256.Sum256(payload)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(payload)
+	}))
+	defer server.Close()
+	t.Setenv("TMPDIR", t.TempDir())
+
+	// Nobody reads progress; the download must still return
+	done := make(chan error, 1)
+	go func() {
+		_, err := DownloadUpdateContext(context.Background(), server.URL, hex.EncodeToString(sum[:]), make(chan int))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("DownloadUpdateContext blocked on the progress channel")
+	}
+}
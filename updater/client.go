@@ -0,0 +1,79 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// httpClient is used for every updater request. It goes through the proxy
+// named by HTTP_PROXY/HTTPS_PROXY (and skips it for NO_PROXY hosts).
+var httpClient = &http.Client{Transport: proxyTransport()}
+
+func proxyTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	return transport
+}
+
+var (
+	tokenMu     sync.RWMutex
+	githubToken string
+)
+
+// SetGithubToken sets the token sent to GitHub, which raises the API rate limit
+// from 60 requests an hour per address. Empty falls back to GITHUB_TOKEN.
+func SetGithubToken(token string) {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+	githubToken = token
+}
+
+func currentGithubToken() string {
+	tokenMu.RLock()
+	defer tokenMu.RUnlock()
+	if githubToken != "" {
+		return githubToken
+	}
+	return os.Getenv("GITHUB_TOKEN")
+}
+
+// get sends a GET request through httpClient. The GitHub token is only sent to
+// GitHub itself; the client drops it when a download redirects elsewhere.
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch req.URL.Hostname() {
+	case "api.github.com", "github.com":
+		if token := currentGithubToken(); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	return httpClient.Do(req)
+}
+
+// rateLimitError explains a 403 or 429 from GitHub caused by the API rate limit,
+// or returns nil for other responses
+func rateLimitError(resp *http.Response) error {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return nil
+	}
+
+	reset := "later"
+	if epoch, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		reset = "at " + time.Unix(epoch, 0).Format("15:04")
+	}
+	if currentGithubToken() == "" {
+		return fmt.Errorf("GitHub API rate limit exceeded, try again %s or set a GitHub token (GITHUB_TOKEN) to raise the limit", reset)
+	}
+	return fmt.Errorf("GitHub API rate limit exceeded for the configured token, try again %s", reset)
+}
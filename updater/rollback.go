@@ -0,0 +1,32 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Readiness handshake: the update script starts the new build with ReadyFileEnv
+// set to a path and waits up to readyTimeoutSeconds for that file to appear. The
+// new build calls SignalReady once it has started, which writes the file. If the
+// file doesn't appear in time, or the process exits first, the script restores
+// the backup of the previous build (the executable or .app with a .bak suffix)
+// and starts it instead.
+const (
+	ReadyFileEnv        = "SYNCFORGE_UPDATE_READY_FILE"
+	readyTimeoutSeconds = 60
+)
+
+// SignalReady tells a waiting update script that this build started successfully.
+// It does nothing unless the process was started by an update.
+func SignalReady() error {
+	path := os.Getenv(ReadyFileEnv)
+	if path == "" {
+		return nil
+	}
+	return os.WriteFile(path, []byte(Version), 0644)
+}
+
+// readyFilePath is where the relaunched build signals readiness
+func readyFilePath() string {
+	return filepath.Join(os.TempDir(), "syncforge-update-ready")
+}
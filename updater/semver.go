@@ -0,0 +1,85 @@
+package updater
+
+import (
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed semantic version; build metadata is dropped since it
+// doesn't affect precedence
+type semver struct {
+	core       [3]int
+	prerelease []string
+}
+
+// parseSemver parses versions like "1.2.3", "v1.2.3-rc.1+build.5". Missing
+// minor or patch numbers count as 0; non-numeric ones as 0 too.
+func parseSemver(v string) semver {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "+")
+	v, pre, _ := strings.Cut(v, "-")
+
+	var parsed semver
+	for i, part := range strings.SplitN(v, ".", 3) {
+		parsed.core[i], _ = strconv.Atoi(part)
+	}
+	if pre != "" {
+		parsed.prerelease = strings.Split(pre, ".")
+	}
+	return parsed
+}
+
+// compareVersions compares two versions by semver precedence: major, minor and
+// patch numerically, then a pre-release sorts before its release, and
+// pre-releases compare identifier by identifier.
+// Returns: 1 if v1 > v2, -1 if v1 < v2, 0 if equal
+func compareVersions(v1, v2 string) int {
+	a, b := parseSemver(v1), parseSemver(v2)
+	for i := range a.core {
+		if c := compareInts(a.core[i], b.core[i]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(a.prerelease) == 0 && len(b.prerelease) == 0:
+		return 0
+	case len(a.prerelease) == 0:
+		return 1
+	case len(b.prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(a.prerelease) && i < len(b.prerelease); i++ {
+		if c := comparePrereleaseIdentifiers(a.prerelease[i], b.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	// A longer set of identifiers wins when all shared ones are equal
+	return compareInts(len(a.prerelease), len(b.prerelease))
+}
+
+// comparePrereleaseIdentifiers compares numeric identifiers numerically and
+// others lexically; a numeric identifier sorts before an alphanumeric one
+func comparePrereleaseIdentifiers(a, b string) int {
+	na, aErr := strconv.Atoi(a)
+	nb, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareInts(na, nb)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,54 @@
+package updater
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		in   string
+		want semver
+	}{
+		{"1.2.3", semver{core: [3]int{1, 2, 3}}},
+		{"v1.2.3", semver{core: [3]int{1, 2, 3}}},
+		{" v2 ", semver{core: [3]int{2, 0, 0}}},
+		{"1.4", semver{core: [3]int{1, 4, 0}}},
+		{"1.2.3-rc.1", semver{core: [3]int{1, 2, 3}, prerelease: []string{"rc", "1"}}},
+		{"1.2.3-beta+build.5", semver{core: [3]int{1, 2, 3}, prerelease: []string{"beta"}}},
+		{"1.2.3+build.5", semver{core: [3]int{1, 2, 3}}},
+		{"1.x.3", semver{core: [3]int{1, 0, 3}}},
+	}
+	for _, tt := range tests {
+		if got := parseSemver(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSemver(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		v1, v2 string
+		want   int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3+build.1", "1.2.3+build.2", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"1.2.3", "1.2.4", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc.1", 1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-beta", "1.0.0-alpha", 1},
+		{"1.0.0-rc.1", "1.0.0-beta.11", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.v1, tt.v2); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.v1, tt.v2, got, tt.want)
+		}
+	}
+}
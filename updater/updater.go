@@ -2,10 +2,12 @@ package updater
 
 import (
 	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -19,13 +21,25 @@ const (
 	GithubRepo = "nanablast/syncforge"
 )
 
+// Channel selects which releases CheckForUpdates offers
+type Channel string
+
+const (
+	// ChannelStable only offers full releases; it is the default
+	ChannelStable Channel = "stable"
+	// ChannelBeta also offers pre-releases
+	ChannelBeta Channel = "beta"
+)
+
 // Release represents a GitHub release
 type Release struct {
-	TagName string  `json:"tag_name"`
-	Name    string  `json:"name"`
-	Body    string  `json:"body"`
-	HTMLURL string  `json:"html_url"`
-	Assets  []Asset `json:"assets"`
+	TagName    string  `json:"tag_name"`
+	Name       string  `json:"name"`
+	Body       string  `json:"body"`
+	HTMLURL    string  `json:"html_url"`
+	Draft      bool    `json:"draft"`
+	Prerelease bool    `json:"prerelease"`
+	Assets     []Asset `json:"assets"`
 }
 
 // Asset represents a release asset
@@ -45,6 +59,7 @@ type UpdateInfo struct {
 	ReleaseURL     string `json:"releaseUrl"`
 	AssetName      string `json:"assetName"`
 	AssetSize      int64  `json:"assetSize"`
+	Checksum       string `json:"checksum"` // expected SHA-256 of the asset, hex encoded
 }
 
 // GetCurrentVersion returns the current app version
@@ -52,17 +67,24 @@ func GetCurrentVersion() string {
 	return Version
 }
 
-// CheckForUpdates checks GitHub releases for updates
-func CheckForUpdates() (*UpdateInfo, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", GithubRepo)
-
-	resp, err := http.Get(url)
+// CheckForUpdates checks GitHub releases on the given channel for updates;
+// an empty channel is the stable one
+func CheckForUpdates(channel Channel) (*UpdateInfo, error) {
+	var release *Release
+	var err error
+	switch channel {
+	case ChannelStable, "":
+		release, err = fetchLatestRelease()
+	case ChannelBeta:
+		release, err = fetchNewestRelease()
+	default:
+		return nil, fmt.Errorf("unknown update channel %q", channel)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to check for updates: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == 404 {
+	if release == nil {
 		// No releases yet
 		return &UpdateInfo{
 			Available:      false,
@@ -71,15 +93,6 @@ func CheckForUpdates() (*UpdateInfo, error) {
 		}, nil
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
-	}
-
-	var release Release
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return nil, fmt.Errorf("failed to parse release info: %v", err)
-	}
-
 	latestVersion := strings.TrimPrefix(release.TagName, "v")
 	isNewer := compareVersions(latestVersion, Version) > 0
 
@@ -92,71 +105,117 @@ func CheckForUpdates() (*UpdateInfo, error) {
 	}
 
 	// Find the appropriate asset for current platform
-	assetName := getAssetName()
-	for _, asset := range release.Assets {
-		if strings.Contains(asset.Name, assetName) {
-			info.DownloadURL = asset.BrowserDownloadURL
-			info.AssetName = asset.Name
-			info.AssetSize = asset.Size
-			break
+	asset, err := selectAsset(release.Assets)
+	if err != nil {
+		return nil, err
+	}
+	if asset != nil {
+		info.DownloadURL = asset.BrowserDownloadURL
+		info.AssetName = asset.Name
+		info.AssetSize = asset.Size
+	}
+
+	// A release without a checksum is still reported; DownloadUpdate refuses it
+	if info.AssetName != "" {
+		if checksumAsset := findChecksumAsset(release.Assets, info.AssetName); checksumAsset != nil {
+			checksum, err := fetchChecksum(checksumAsset.BrowserDownloadURL, info.AssetName)
+			if err != nil {
+				return nil, err
+			}
+			info.Checksum = checksum
 		}
 	}
 
 	return info, nil
 }
 
-// getAssetName returns the expected asset name for current platform
-func getAssetName() string {
-	switch runtime.GOOS {
-	case "darwin":
-		return "macos"
-	case "windows":
-		return "windows"
-	case "linux":
-		return "linux"
-	default:
-		return runtime.GOOS
+// fetchLatestRelease returns the latest full release, or nil if there is none
+func fetchLatestRelease() (*Release, error) {
+	var release Release
+	found, err := getGithubJSON(fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", GithubRepo), &release)
+	if err != nil || !found {
+		return nil, err
 	}
+	return &release, nil
 }
 
-// compareVersions compares two version strings
-// Returns: 1 if v1 > v2, -1 if v1 < v2, 0 if equal
-func compareVersions(v1, v2 string) int {
-	parts1 := strings.Split(v1, ".")
-	parts2 := strings.Split(v2, ".")
-
-	maxLen := len(parts1)
-	if len(parts2) > maxLen {
-		maxLen = len(parts2)
+// fetchNewestRelease returns the release with the highest version among the
+// recent ones, pre-releases included, or nil if there is none
+func fetchNewestRelease() (*Release, error) {
+	var releases []Release
+	found, err := getGithubJSON(fmt.Sprintf("https://api.github.com/repos/%s/releases?per_page=30", GithubRepo), &releases)
+	if err != nil || !found {
+		return nil, err
 	}
 
-	for i := 0; i < maxLen; i++ {
-		var n1, n2 int
-		if i < len(parts1) {
-			fmt.Sscanf(parts1[i], "%d", &n1)
+	var newest *Release
+	for i := range releases {
+		if releases[i].Draft {
+			continue
 		}
-		if i < len(parts2) {
-			fmt.Sscanf(parts2[i], "%d", &n2)
+		if newest == nil || compareVersions(strings.TrimPrefix(releases[i].TagName, "v"), strings.TrimPrefix(newest.TagName, "v")) > 0 {
+			newest = &releases[i]
 		}
+	}
+	return newest, nil
+}
 
-		if n1 > n2 {
-			return 1
-		}
-		if n1 < n2 {
-			return -1
-		}
+// getGithubJSON decodes a GitHub API response into v; a 404 reports not found
+func getGithubJSON(url string, v interface{}) (bool, error) {
+	resp, err := get(context.Background(), url)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for updates: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return false, nil
+	}
+	if err := rateLimitError(resp); err != nil {
+		return false, err
 	}
-	return 0
+	if resp.StatusCode != 200 {
+		return false, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("failed to parse release info: %v", err)
+	}
+	return true, nil
 }
 
-// DownloadUpdate downloads the update to a temporary file
-func DownloadUpdate(downloadURL string, progressChan chan<- int) (string, error) {
-	resp, err := http.Get(downloadURL)
+// DownloadUpdate downloads the update to a temporary file and verifies it
+// against the expected SHA-256 checksum, deleting the file on a mismatch
+func DownloadUpdate(downloadURL, checksum string, progressChan chan<- int) (string, error) {
+	return DownloadUpdateContext(context.Background(), downloadURL, checksum, progressChan)
+}
+
+// DownloadUpdateContext is DownloadUpdate, aborting when ctx is done. The
+// partial file is deleted on any failure. Progress percentages, including a
+// final 100 once the download is verified, are sent on progress only when the
+// receiver is ready, so a slow reader never stalls the download; the channel is
+// closed when the function returns.
+func DownloadUpdateContext(ctx context.Context, downloadURL, checksum string, progress chan<- int) (string, error) {
+	if progress != nil {
+		defer close(progress)
+	}
+	if checksum == "" && !SkipChecksumVerification {
+		return "", fmt.Errorf("no checksum published for this update; refusing to download it")
+	}
+
+	resp, err := get(ctx, downloadURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to download update: %v", err)
 	}
 	defer resp.Body.Close()
 
+	if err := rateLimitError(resp); err != nil {
+		return "", err
+	}
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("update download returned status %d", resp.StatusCode)
+	}
+
 	// Determine file extension
 	ext := ".zip"
 	if runtime.GOOS == "windows" {
@@ -165,28 +224,41 @@ func DownloadUpdate(downloadURL string, progressChan chan<- int) (string, error)
 		ext = ""
 	}
 
-	// Create temp file
+	// Create temp file, removed again unless the download completes
 	tmpDir := os.TempDir()
 	tmpFile, err := os.CreateTemp(tmpDir, "syncforge-update-*"+ext)
 	if err != nil {
 		return "", fmt.Errorf("failed to create temp file: %v", err)
 	}
-	defer tmpFile.Close()
+	complete := false
+	defer func() {
+		tmpFile.Close()
+		if !complete {
+			os.Remove(tmpFile.Name())
+		}
+	}()
 
-	// Download with progress
+	// Download with progress, hashing as we go
 	totalSize := resp.ContentLength
 	var downloaded int64
+	hash := sha256.New()
 
 	buffer := make([]byte, 32*1024)
 	for {
+		if err := ctx.Err(); err != nil {
+			return "", fmt.Errorf("download cancelled: %v", err)
+		}
 		n, err := resp.Body.Read(buffer)
 		if n > 0 {
-			tmpFile.Write(buffer[:n])
+			if _, werr := tmpFile.Write(buffer[:n]); werr != nil {
+				return "", fmt.Errorf("failed to write update: %v", werr)
+			}
+			hash.Write(buffer[:n])
 			downloaded += int64(n)
-			if progressChan != nil && totalSize > 0 {
-				progress := int(float64(downloaded) / float64(totalSize) * 100)
+			if progress != nil && totalSize > 0 {
+				percent := int(float64(downloaded) / float64(totalSize) * 100)
 				select {
-				case progressChan <- progress:
+				case progress <- percent:
 				default:
 				}
 			}
@@ -195,10 +267,26 @@ func DownloadUpdate(downloadURL string, progressChan chan<- int) (string, error)
 			break
 		}
 		if err != nil {
+			if ctx.Err() != nil {
+				return "", fmt.Errorf("download cancelled: %v", ctx.Err())
+			}
 			return "", fmt.Errorf("download error: %v", err)
 		}
 	}
 
+	if checksum != "" {
+		if actual := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(actual, checksum) {
+			return "", fmt.Errorf("checksum mismatch: expected %s, got %s", strings.ToLower(checksum), actual)
+		}
+	}
+
+	complete = true
+	if progress != nil {
+		select {
+		case progress <- 100:
+		default:
+		}
+	}
 	return tmpFile.Name(), nil
 }
 
@@ -263,15 +351,39 @@ func applyUpdateMacOS(zipFile string) error {
 		return fmt.Errorf("no .app found in update package")
 	}
 
-	// Create update script
+	// Create update script: back up the current app, move the new one in and
+	// start its executable directly so it sees the readiness file variable
+	target := filepath.Join(appDir, appName)
+	newExec := filepath.Join(target, "Contents", "MacOS", filepath.Base(execPath))
 	scriptContent := fmt.Sprintf(`#!/bin/bash
 sleep 2
-rm -rf "%s"
-mv "%s" "%s"
-open "%s"
-rm -rf "%s"
+rm -f "%[4]s"
+rm -rf "%[1]s.bak"
+mv "%[1]s" "%[1]s.bak" || exit 1
+if ! mv "%[2]s" "%[1]s"; then
+  mv "%[1]s.bak" "%[1]s"
+  open "%[1]s"
+  exit 1
+fi
+%[6]s="%[4]s" "%[3]s" &
+pid=$!
+for i in $(seq 1 %[7]d); do
+  if [ -f "%[4]s" ]; then
+    rm -f "%[4]s"
+    rm -rf "%[1]s.bak" "%[5]s"
+    rm "$0"
+    exit 0
+  fi
+  kill -0 $pid 2>/dev/null || break
+  sleep 1
+done
+kill $pid 2>/dev/null
+rm -rf "%[1]s"
+mv "%[1]s.bak" "%[1]s"
+open "%[1]s"
+rm -rf "%[5]s"
 rm "$0"
-`, appPath, newAppPath, filepath.Join(appDir, appName), filepath.Join(appDir, appName), tmpExtractDir)
+`, target, newAppPath, newExec, readyFilePath(), tmpExtractDir, ReadyFileEnv, readyTimeoutSeconds)
 
 	scriptPath := filepath.Join(os.TempDir(), "syncforge-update.sh")
 	if err := os.WriteFile(scriptPath, []byte(scriptContent), 0755); err != nil {
@@ -298,14 +410,36 @@ func applyUpdateWindows(newExe string) error {
 		return fmt.Errorf("failed to get executable path: %v", err)
 	}
 
-	// Create batch script
+	// Create batch script: back up the current exe, move the new one in and
+	// restore the backup if the new one doesn't signal readiness in time
 	scriptContent := fmt.Sprintf(`@echo off
 timeout /t 2 /nobreak >nul
-del "%s"
-move "%s" "%s"
-start "" "%s"
+del "%[3]s" 2>nul
+del "%[1]s.bak" 2>nul
+move /y "%[1]s" "%[1]s.bak" >nul || exit /b 1
+move /y "%[2]s" "%[1]s" >nul || goto rollback
+set %[5]s=%[3]s
+start "" "%[1]s"
+set %[5]s=
+set /a tries=0
+:wait
+if exist "%[3]s" goto ok
+if %%tries%% geq %[6]d goto rollback
+set /a tries+=1
+timeout /t 1 /nobreak >nul
+goto wait
+:rollback
+taskkill /f /im "%[4]s" >nul 2>&1
+timeout /t 1 /nobreak >nul
+move /y "%[1]s.bak" "%[1]s" >nul
+start "" "%[1]s"
+goto done
+:ok
+del "%[3]s"
+del "%[1]s.bak"
+:done
 del "%%~f0"
-`, execPath, newExe, execPath, execPath)
+`, execPath, newExe, readyFilePath(), filepath.Base(execPath), ReadyFileEnv, readyTimeoutSeconds)
 
 	scriptPath := filepath.Join(os.TempDir(), "syncforge-update.bat")
 	if err := os.WriteFile(scriptPath, []byte(scriptContent), 0755); err != nil {
@@ -336,14 +470,34 @@ func applyUpdateLinux(newBinary string) error {
 		return fmt.Errorf("failed to make update executable: %v", err)
 	}
 
-	// Create update script
+	// Create update script: back up the current binary, move the new one in and
+	// restore the backup if the new one doesn't signal readiness in time
 	scriptContent := fmt.Sprintf(`#!/bin/bash
 sleep 2
-mv "%s" "%s"
-chmod +x "%s"
-"%s" &
+rm -f "%[3]s"
+mv -f "%[1]s" "%[1]s.bak" || exit 1
+if ! mv "%[2]s" "%[1]s"; then
+  mv -f "%[1]s.bak" "%[1]s"
+  "%[1]s" &
+  exit 1
+fi
+chmod +x "%[1]s"
+%[4]s="%[3]s" "%[1]s" &
+pid=$!
+for i in $(seq 1 %[5]d); do
+  if [ -f "%[3]s" ]; then
+    rm -f "%[3]s" "%[1]s.bak"
+    rm "$0"
+    exit 0
+  fi
+  kill -0 $pid 2>/dev/null || break
+  sleep 1
+done
+kill $pid 2>/dev/null
+mv -f "%[1]s.bak" "%[1]s"
+"%[1]s" &
 rm "$0"
-`, newBinary, execPath, execPath, execPath)
+`, execPath, newBinary, readyFilePath(), ReadyFileEnv, readyTimeoutSeconds)
 
 	scriptPath := filepath.Join(os.TempDir(), "syncforge-update.sh")
 	if err := os.WriteFile(scriptPath, []byte(scriptContent), 0755); err != nil {